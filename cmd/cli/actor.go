@@ -0,0 +1,18 @@
+package cli
+
+import (
+	"os"
+	"os/user"
+)
+
+// cliActor retourne l'identifiant de l'auteur des opérations lancées depuis la CLI,
+// enregistré dans le journal d'audit (ex: "cli:alice").
+func cliActor() string {
+	if current, err := user.Current(); err == nil && current.Username != "" {
+		return "cli:" + current.Username
+	}
+	if name := os.Getenv("USER"); name != "" {
+		return "cli:" + name
+	}
+	return "cli:inconnu"
+}
@@ -0,0 +1,107 @@
+package cli
+
+import (
+	"errors"
+	"fmt"
+	"os"
+
+	cmd2 "github.com/axellelanca/urlshortener/cmd"
+	"github.com/axellelanca/urlshortener/internal/config"
+	"github.com/axellelanca/urlshortener/internal/database"
+	"github.com/axellelanca/urlshortener/internal/models"
+	"github.com/axellelanca/urlshortener/internal/repository"
+	"github.com/axellelanca/urlshortener/internal/services"
+	"github.com/spf13/cobra"
+	"gorm.io/gorm"
+)
+
+var (
+	adoptShortCode string
+	adoptOwner     uint
+	adoptForce     bool
+)
+
+// AdoptCmd représente la commande 'adopt'
+var AdoptCmd = &cobra.Command{
+	Use:   "adopt",
+	Short: "Attribue un lien court à une clé API, qui pourra le gérer via l'API.",
+	Long: `Cette commande rattache un lien à une clé API. Les liens créés par la CLI sans --owner
+ou avant l'introduction des clés API n'ont pas de propriétaire : l'API refuse de les
+modifier, de les supprimer ou d'afficher leurs statistiques tant qu'ils ne sont pas adoptés.
+Un lien qui appartient déjà à une autre clé n'est transféré qu'avec --force.
+
+Exemple:
+  url-shortener adopt --code="xyz123" --owner=3
+  url-shortener adopt --code="xyz123" --owner=4 --force`,
+	Run: func(cmd *cobra.Command, args []string) {
+		if adoptShortCode == "" {
+			fmt.Fprintf(os.Stderr, "Aucun code d'URL raccourcie n'a été fourni.")
+			os.Exit(1)
+		}
+
+		configs, err := config.LoadConfig()
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Erreur lors du chargement de la configuration : %v\n", err)
+			os.Exit(1)
+		}
+
+		db, err := database.Open(configs.Database)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Erreur lors de l'ouverture de la base de données : %v\n", err)
+			os.Exit(1)
+		}
+
+		sqlDB, err := db.DB()
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "FATAL: Échec de l'obtention de la base de données SQL sous-jacente: %v", err)
+			os.Exit(1)
+		}
+		defer sqlDB.Close()
+
+		key := activeAPIKey(db, adoptOwner)
+		service := services.NewLinkService(repository.NewLinkRepository(db), repository.NewAuditRepository(db), nil)
+
+		link, err := service.AssignOwner(adoptShortCode, key.ID, adoptForce, cliActor())
+		if err != nil {
+			switch {
+			case errors.Is(err, gorm.ErrRecordNotFound):
+				fmt.Fprintf(os.Stderr, "Aucun lien trouvé pour le code: %s\n", adoptShortCode)
+			case errors.Is(err, services.ErrLinkAlreadyOwned):
+				fmt.Fprintf(os.Stderr, "Le lien %s appartient déjà à une autre clé API, utilisez --force pour le transférer.\n", adoptShortCode)
+			default:
+				fmt.Fprintf(os.Stderr, "Erreur lors de l'attribution du lien : %v\n", err)
+			}
+			os.Exit(1)
+		}
+
+		fmt.Printf("Lien attribué avec succès:\n")
+		fmt.Printf("Code: %s\n", link.ShortCode)
+		fmt.Printf("Propriétaire: clé %d (%s)\n", key.ID, key.Name)
+	},
+}
+
+// activeAPIKey retourne la clé API d'ID id et termine la commande si elle n'existe pas ou est révoquée.
+func activeAPIKey(db *gorm.DB, id uint) *models.APIKey {
+	key, err := services.NewAPIKeyService(repository.NewAPIKeyRepository(db)).GetActiveAPIKey(id)
+	if err != nil {
+		switch {
+		case errors.Is(err, gorm.ErrRecordNotFound):
+			fmt.Fprintf(os.Stderr, "Aucune clé API avec l'ID %d.\n", id)
+		case errors.Is(err, services.ErrAPIKeyRevoked):
+			fmt.Fprintf(os.Stderr, "La clé API %d est révoquée.\n", id)
+		default:
+			fmt.Fprintf(os.Stderr, "Erreur lors de la récupération de la clé API : %v\n", err)
+		}
+		os.Exit(1)
+	}
+	return key
+}
+
+func init() {
+	AdoptCmd.Flags().StringVar(&adoptShortCode, "code", "", "Code court du lien à attribuer")
+	AdoptCmd.Flags().UintVar(&adoptOwner, "owner", 0, "ID de la clé API qui devient propriétaire du lien")
+	AdoptCmd.Flags().BoolVar(&adoptForce, "force", false, "Transfère le lien même s'il appartient déjà à une autre clé")
+	AdoptCmd.MarkFlagRequired("code")
+	AdoptCmd.MarkFlagRequired("owner")
+	cmd2.RootCmd.AddCommand(AdoptCmd)
+}
@@ -0,0 +1,154 @@
+package cli
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"text/tabwriter"
+	"time"
+
+	cmd2 "github.com/axellelanca/urlshortener/cmd"
+	"github.com/axellelanca/urlshortener/internal/config"
+	"github.com/axellelanca/urlshortener/internal/database"
+	"github.com/axellelanca/urlshortener/internal/repository"
+	"github.com/axellelanca/urlshortener/internal/services"
+	"github.com/spf13/cobra"
+	"gorm.io/gorm"
+)
+
+var (
+	apiKeyName string
+	apiKeyID   uint
+)
+
+// APIKeyCmd regroupe les sous-commandes de gestion des clés API.
+var APIKeyCmd = &cobra.Command{
+	Use:   "apikey",
+	Short: "Gère les clés d'accès à l'API REST (create, list, revoke).",
+	Long: `Les routes /api/v1/links exigent une clé API, transmise dans l'en-tête
+"Authorization: Bearer <clé>" ou "X-API-Key: <clé>".
+Chaque clé est propriétaire des liens qu'elle crée.`,
+}
+
+// APIKeyCreateCmd représente la commande 'apikey create'
+var APIKeyCreateCmd = &cobra.Command{
+	Use:   "create",
+	Short: "Crée une nouvelle clé API et l'affiche une seule fois.",
+	Long: `Cette commande génère une nouvelle clé API. Seul un hash du secret est stocké :
+la clé complète doit être copiée immédiatement, elle ne pourra plus être affichée.
+
+Exemple:
+  url-shortener apikey create --name="marketing"`,
+	Run: func(cmd *cobra.Command, args []string) {
+		service, closeDB := openAPIKeyService()
+		defer closeDB()
+
+		key, rawKey, err := service.CreateAPIKey(apiKeyName)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Erreur lors de la création de la clé API : %v\n", err)
+			os.Exit(1)
+		}
+
+		fmt.Printf("Clé API créée avec succès:\n")
+		fmt.Printf("ID: %d\n", key.ID)
+		fmt.Printf("Nom: %s\n", key.Name)
+		fmt.Printf("Clé: %s\n", rawKey)
+		fmt.Println("Conservez cette clé : elle ne sera plus affichée.")
+	},
+}
+
+// APIKeyListCmd représente la commande 'apikey list'
+var APIKeyListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "Liste les clés API existantes.",
+	Run: func(cmd *cobra.Command, args []string) {
+		service, closeDB := openAPIKeyService()
+		defer closeDB()
+
+		keys, err := service.ListAPIKeys()
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Erreur lors de la récupération des clés API : %v\n", err)
+			os.Exit(1)
+		}
+		if len(keys) == 0 {
+			fmt.Println("Aucune clé API.")
+			return
+		}
+
+		w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(w, "ID\tNOM\tPRÉFIXE\tCRÉÉE LE\tDERNIÈRE UTILISATION\tSTATUT")
+		for _, key := range keys {
+			lastUsed := "jamais"
+			if key.LastUsedAt != nil {
+				lastUsed = key.LastUsedAt.Format(time.RFC3339)
+			}
+			status := "active"
+			if key.IsRevoked() {
+				status = "révoquée le " + key.RevokedAt.Format(time.RFC3339)
+			}
+			fmt.Fprintf(w, "%d\t%s\tusk_%s_…\t%s\t%s\t%s\n",
+				key.ID, key.Name, key.Prefix, key.CreatedAt.Format(time.RFC3339), lastUsed, status)
+		}
+		w.Flush()
+	},
+}
+
+// APIKeyRevokeCmd représente la commande 'apikey revoke'
+var APIKeyRevokeCmd = &cobra.Command{
+	Use:   "revoke",
+	Short: "Révoque une clé API.",
+	Long: `Cette commande révoque une clé API : elle ne permet plus de s'authentifier.
+Les liens qu'elle possède sont conservés.
+
+Exemple:
+  url-shortener apikey revoke --id=3`,
+	Run: func(cmd *cobra.Command, args []string) {
+		service, closeDB := openAPIKeyService()
+		defer closeDB()
+
+		if err := service.RevokeAPIKey(apiKeyID); err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				fmt.Fprintf(os.Stderr, "Aucune clé API active avec l'ID %d.\n", apiKeyID)
+			} else {
+				fmt.Fprintf(os.Stderr, "Erreur lors de la révocation de la clé API : %v\n", err)
+			}
+			os.Exit(1)
+		}
+		fmt.Printf("Clé API %d révoquée.\n", apiKeyID)
+	},
+}
+
+// openAPIKeyService ouvre la base de données et construit l'APIKeyService.
+// La fonction retournée ferme la connexion.
+func openAPIKeyService() (*services.APIKeyService, func()) {
+	configs, err := config.LoadConfig()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Erreur lors du chargement de la configuration : %v\n", err)
+		os.Exit(1)
+	}
+
+	db, err := database.Open(configs.Database)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Erreur lors de l'ouverture de la base de données : %v\n", err)
+		os.Exit(1)
+	}
+
+	sqlDB, err := db.DB()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "FATAL: Échec de l'obtention de la base de données SQL sous-jacente: %v", err)
+		os.Exit(1)
+	}
+
+	return services.NewAPIKeyService(repository.NewAPIKeyRepository(db)), func() { sqlDB.Close() }
+}
+
+func init() {
+	APIKeyCreateCmd.Flags().StringVar(&apiKeyName, "name", "", "Nom de la clé API")
+	APIKeyCreateCmd.MarkFlagRequired("name")
+
+	APIKeyRevokeCmd.Flags().UintVar(&apiKeyID, "id", 0, "ID de la clé API à révoquer")
+	APIKeyRevokeCmd.MarkFlagRequired("id")
+
+	APIKeyCmd.AddCommand(APIKeyCreateCmd, APIKeyListCmd, APIKeyRevokeCmd)
+	cmd2.RootCmd.AddCommand(APIKeyCmd)
+}
@@ -0,0 +1,106 @@
+package cli
+
+import (
+	"fmt"
+	"os"
+	"time"
+
+	cmd2 "github.com/axellelanca/urlshortener/cmd"
+	"github.com/axellelanca/urlshortener/internal/config"
+	"github.com/axellelanca/urlshortener/internal/database"
+	"github.com/axellelanca/urlshortener/internal/repository"
+	"github.com/axellelanca/urlshortener/internal/retention"
+	"github.com/spf13/cobra"
+)
+
+var (
+	purgeOlderThanDays int
+	purgeMode          string
+	purgeDryRun        bool
+)
+
+// ClicksCmd regroupe les sous-commandes de gestion des clics enregistrés.
+var ClicksCmd = &cobra.Command{
+	Use:   "clicks",
+	Short: "Gère les clics enregistrés (purge).",
+}
+
+// ClicksPurgeCmd représente la commande 'clicks purge'
+var ClicksPurgeCmd = &cobra.Command{
+	Use:   "purge",
+	Short: "Purge ou agrège les clics bruts plus anciens que la durée de conservation.",
+	Long: `Cette commande applique immédiatement la politique de rétention de la section 'privacy' :
+les clics bruts (IP, User-Agent, empreinte...) antérieurs à minuit UTC il y a N jours sont
+supprimés (mode purge) ou remplacés par des totaux journaliers de clics et de visiteurs uniques
+(mode aggregate). Le serveur applique la même politique en tâche de fond.
+
+Exemple:
+  url-shortener clicks purge
+  url-shortener clicks purge --older-than=30 --mode=purge --dry-run`,
+	Run: func(cmd *cobra.Command, args []string) {
+		configs, err := config.LoadConfig()
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Erreur lors du chargement de la configuration : %v\n", err)
+			os.Exit(1)
+		}
+
+		days := configs.Privacy.RetentionDays
+		if cmd.Flags().Changed("older-than") {
+			days = purgeOlderThanDays
+		}
+		mode := configs.Privacy.RetentionMode
+		if cmd.Flags().Changed("mode") {
+			mode = purgeMode
+		}
+		if days <= 0 {
+			fmt.Fprintf(os.Stderr, "Aucune durée de conservation : configurez privacy.retention_days ou utilisez --older-than.\n")
+			os.Exit(1)
+		}
+
+		db, err := database.Open(configs.Database)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Erreur lors de l'ouverture de la base de données : %v\n", err)
+			os.Exit(1)
+		}
+
+		sqlDB, err := db.DB()
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "FATAL: Échec de l'obtention de la base de données SQL sous-jacente: %v", err)
+			os.Exit(1)
+		}
+		defer sqlDB.Close()
+
+		job, err := retention.NewJob(repository.NewClickRepository(db), days, mode, 0)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Paramètres de rétention invalides : %v\n", err)
+			os.Exit(1)
+		}
+
+		now := time.Now()
+		cutoff := job.Cutoff(now).Format(time.DateOnly)
+		if purgeDryRun {
+			count, err := job.CountExpired(now)
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "Erreur lors du comptage des clics : %v\n", err)
+				os.Exit(1)
+			}
+			fmt.Printf("%d clic(s) antérieur(s) au %s seraient traités (mode %s).\n", count, cutoff, job.Mode())
+			return
+		}
+
+		removed, err := job.Run(now)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Erreur lors de la purge des clics : %v\n", err)
+			os.Exit(1)
+		}
+		fmt.Printf("%d clic(s) antérieur(s) au %s traité(s) (mode %s).\n", removed, cutoff, job.Mode())
+	},
+}
+
+func init() {
+	ClicksPurgeCmd.Flags().IntVar(&purgeOlderThanDays, "older-than", 0, "Durée de conservation en jours (par défaut privacy.retention_days)")
+	ClicksPurgeCmd.Flags().StringVar(&purgeMode, "mode", "", "purge ou aggregate (par défaut privacy.retention_mode)")
+	ClicksPurgeCmd.Flags().BoolVar(&purgeDryRun, "dry-run", false, "Affiche le nombre de clics concernés sans rien modifier")
+	ClicksCmd.AddCommand(ClicksPurgeCmd)
+	cmd2.RootCmd.AddCommand(ClicksCmd)
+}
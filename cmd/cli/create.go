@@ -1,22 +1,32 @@
 package cli
 
 import (
+	"errors"
 	"fmt"
 	"github.com/axellelanca/urlshortener/internal/config"
+	"github.com/axellelanca/urlshortener/internal/database"
 	"net/url" // Pour valider le format de l'URL
 	"os"
+	"time"
 
 	cmd2 "github.com/axellelanca/urlshortener/cmd"
 	"github.com/axellelanca/urlshortener/internal/repository"
 	"github.com/axellelanca/urlshortener/internal/services"
+	"github.com/axellelanca/urlshortener/internal/urlpolicy"
 	"github.com/spf13/cobra"
-	"gorm.io/driver/sqlite" // Driver SQLite pour GORM
-	"gorm.io/gorm"
 )
 
 // TODO : Faire une variable longURLFlag qui stockera la valeur du flag --url
 var (
-	inputURL string
+	inputURL       string
+	inputAlias     string
+	inputExpiresAt string
+	inputExpiresIn time.Duration
+	inputMaxClicks int
+	inputPassword  string
+	inputPreview   bool
+	inputRedirect  int
+	inputOwner     uint
 )
 
 // CreateCmd représente la commande 'create'
@@ -26,7 +36,11 @@ var CreateCmd = &cobra.Command{
 	Long: `Cette commande raccourcit une URL longue fournie et affiche le code court généré.
 
 Exemple:
-  url-shortener create --url="https://www.google.com/search?q=go+lang"`,
+  url-shortener create --url="https://www.google.com/search?q=go+lang"
+  url-shortener create --url="https://example.com/promo" --alias="spring-sale"
+  url-shortener create --url="https://example.com/once" --max-clicks=1 --expires-in=24h
+  url-shortener create --url="https://intranet.example.com/doc" --password="s3cret"
+  url-shortener create --url="https://example.com/promo" --owner=3`,
 	Run: func(cmd *cobra.Command, args []string) {
 		// TODO 1: Valider que le flag --url a été fourni.
 		if inputURL == "" {
@@ -41,6 +55,20 @@ Exemple:
 			os.Exit(1)
 		}
 
+		// Calcul de la date d'expiration optionnelle, absolue (--expires-at) ou relative (--expires-in)
+		var expiresAt *time.Time
+		if inputExpiresAt != "" {
+			parsed, errTime := time.Parse(time.RFC3339, inputExpiresAt)
+			if errTime != nil {
+				fmt.Fprintf(os.Stderr, "Date d'expiration invalide (format RFC 3339 attendu) : %v\n", errTime)
+				os.Exit(1)
+			}
+			expiresAt = &parsed
+		} else if inputExpiresIn > 0 {
+			deadline := time.Now().Add(inputExpiresIn)
+			expiresAt = &deadline
+		}
+
 		// TODO : Charger la configuration chargée globalement via cmd.cfg
 		configs, errConfig := config.LoadConfig()
 		if errConfig != nil {
@@ -48,10 +76,10 @@ Exemple:
 			os.Exit(1)
 		}
 
-		// TODO : Initialiser la connexion à la base de données SQLite.
-		db, errDB := gorm.Open(sqlite.Open(configs.Database.Name), &gorm.Config{})
+		// TODO : Initialiser la connexion à la base de données (driver de la section database).
+		db, errDB := database.Open(configs.Database)
 		if errDB != nil {
-			fmt.Fprintf(os.Stderr, "Erreur lors de l'ouverture de la base SQLite : %v", errDB)
+			fmt.Fprintf(os.Stderr, "Erreur lors de l'ouverture de la base de données : %v\n", errDB)
 			os.Exit(1)
 		}
 
@@ -64,12 +92,50 @@ Exemple:
 		defer sqlDB.Close()
 
 		// TODO : Initialiser les repositories et services nécessaires NewLinkRepository & NewLinkService
+		// La politique des URLs de destination est la même que celle de l'API
+		policy, err := urlpolicy.FromConfig(configs.URLPolicy)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Erreur lors du chargement de la politique des URLs : %v\n", err)
+			os.Exit(1)
+		}
 		repo := repository.NewLinkRepository(db)
-		service := services.NewLinkService(repo)
+		service := services.NewLinkService(repo, repository.NewAuditRepository(db), policy)
+
+		// Sans --owner, le lien n'appartient à aucune clé API et ne peut pas être géré via l'API
+		var ownerID *uint
+		if inputOwner != 0 {
+			ownerID = &activeAPIKey(db, inputOwner).ID
+		}
 
 		// TODO : Appeler le LinkService et la fonction CreateLink pour créer le lien court.
-		link, err := service.CreateLink(inputURL)
+		link, err := service.CreateLink(services.CreateLinkInput{
+			LongURL:       inputURL,
+			Alias:         inputAlias,
+			ExpiresAt:     expiresAt,
+			MaxClicks:     inputMaxClicks,
+			Password:      inputPassword,
+			AlwaysPreview: inputPreview,
+			RedirectType:  inputRedirect,
+			OwnerID:       ownerID,
+		}, cliActor())
 		if err != nil {
+			if errors.Is(err, services.ErrAliasTaken) {
+				fmt.Fprintf(os.Stderr, "L'alias '%s' est déjà utilisé.\n", inputAlias)
+				os.Exit(1)
+			}
+			if errors.Is(err, services.ErrInvalidPassword) {
+				fmt.Fprintf(os.Stderr, "Mot de passe refusé : %v\n", err)
+				os.Exit(1)
+			}
+			if errors.Is(err, services.ErrInvalidRedirectType) {
+				fmt.Fprintf(os.Stderr, "Type de redirection refusé : %v\n", err)
+				os.Exit(1)
+			}
+			var violation *urlpolicy.Violation
+			if errors.As(err, &violation) {
+				fmt.Fprintf(os.Stderr, "URL refusée (%s) : %s.\n", violation.Code, violation.Reason)
+				os.Exit(1)
+			}
 			fmt.Fprintf(os.Stderr, "Erreur lors de la création du lien : %v\n", err)
 			os.Exit(1)
 		}
@@ -78,6 +144,15 @@ Exemple:
 		fmt.Printf("URL courte créée avec succès:\n")
 		fmt.Printf("Code: %s\n", link.ShortCode)
 		fmt.Printf("URL complète: %s\n", fullShortURL)
+		if link.ExpiresAt != nil {
+			fmt.Printf("Expire le: %s\n", link.ExpiresAt.Format(time.RFC3339))
+		}
+		if link.MaxClicks > 0 {
+			fmt.Printf("Clics autorisés: %d\n", link.MaxClicks)
+		}
+		if link.OwnerID != nil {
+			fmt.Printf("Propriétaire: clé %d\n", *link.OwnerID)
+		}
 	},
 }
 
@@ -86,6 +161,15 @@ Exemple:
 func init() {
 	// TODO : Définir le flag --url pour la commande create.
 	CreateCmd.Flags().StringVar(&inputURL, "url", "", "L'URL longue à raccourcir")
+	CreateCmd.Flags().StringVar(&inputAlias, "alias", "", "Alias personnalisé à utiliser comme code court (optionnel)")
+	CreateCmd.Flags().StringVar(&inputExpiresAt, "expires-at", "", "Date d'expiration au format RFC 3339, ex: 2025-12-31T23:59:59Z (optionnel)")
+	CreateCmd.Flags().DurationVar(&inputExpiresIn, "expires-in", 0, "Durée de vie du lien, ex: 24h (optionnel)")
+	CreateCmd.Flags().IntVar(&inputMaxClicks, "max-clicks", 0, "Nombre maximal de redirections, 0 pour illimité (optionnel)")
+	CreateCmd.Flags().StringVar(&inputPassword, "password", "", "Mot de passe demandé avant la redirection (optionnel)")
+	CreateCmd.Flags().BoolVar(&inputPreview, "always-preview", false, "Affiche toujours la page d'aperçu avant la redirection (optionnel)")
+	CreateCmd.Flags().IntVar(&inputRedirect, "redirect-type", 0, "Code HTTP de redirection : 301, 302, 307 ou 308 (permanents refusés avec une expiration, un quota, un mot de passe ou l'aperçu), celui du serveur si absent (optionnel)")
+	CreateCmd.Flags().UintVar(&inputOwner, "owner", 0, "ID de la clé API propriétaire du lien, qui pourra le gérer via l'API (optionnel)")
+	CreateCmd.MarkFlagsMutuallyExclusive("expires-at", "expires-in")
 
 	// TODO :  Marquer le flag comme requis
 	CreateCmd.MarkFlagRequired("url")
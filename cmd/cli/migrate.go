@@ -3,58 +3,189 @@ package cli
 import (
 	"fmt"
 	"github.com/axellelanca/urlshortener/internal/config"
+	"github.com/axellelanca/urlshortener/internal/database"
 	"os"
+	"strconv"
+	"text/tabwriter"
+	"time"
 
 	cmd2 "github.com/axellelanca/urlshortener/cmd"
-	"github.com/axellelanca/urlshortener/internal/models"
+	"github.com/axellelanca/urlshortener/internal/migrations"
 	"github.com/spf13/cobra"
-	"gorm.io/driver/sqlite" // Driver SQLite pour GORM
-	"gorm.io/gorm"
 )
 
+var migrateCreateDir string
+
 // MigrateCmd représente la commande 'migrate'
 var MigrateCmd = &cobra.Command{
 	Use:   "migrate",
 	Short: "Exécute les migrations de la base de données pour créer ou mettre à jour les tables.",
-	Long: `Cette commande se connecte à la base de données configurée (SQLite)
-et exécute les migrations automatiques de GORM pour créer les tables 'links' et 'clicks'
-basées sur les modèles Go.`,
+	Long: `Cette commande se connecte à la base de données configurée (SQLite, PostgreSQL ou MySQL)
+et applique les migrations versionnées en attente (équivalent de 'migrate up'). Les migrations
+appliquées sont enregistrées dans la table 'schema_migrations'.
+
+Exemple:
+  url-shortener migrate
+  url-shortener migrate status
+  url-shortener migrate down 1`,
+	Args: cobra.NoArgs,
 	Run: func(cmd *cobra.Command, args []string) {
-		// TODO : Charger la configuration chargée globalement via cmd.cfg
-		configs, err := config.LoadConfig()
-		if err != nil {
-			fmt.Printf("Erreur lors du chargement de la configuration : %v\n", err)
-			os.Exit(1)
+		migrateUp()
+	},
+}
+
+// MigrateUpCmd représente la commande 'migrate up'
+var MigrateUpCmd = &cobra.Command{
+	Use:   "up",
+	Short: "Applique toutes les migrations en attente.",
+	Args:  cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		migrateUp()
+	},
+}
+
+// MigrateDownCmd représente la commande 'migrate down'
+var MigrateDownCmd = &cobra.Command{
+	Use:   "down [N]",
+	Short: "Annule les N dernières migrations appliquées (1 par défaut).",
+	Long: `Cette commande annule les N dernières migrations appliquées, de la plus récente
+à la plus ancienne. Les données des tables ou colonnes supprimées sont perdues.
+
+Exemple:
+  url-shortener migrate down
+  url-shortener migrate down 3`,
+	Args: cobra.MaximumNArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		steps := 1
+		if len(args) == 1 {
+			n, err := strconv.Atoi(args[0])
+			if err != nil || n < 1 {
+				fmt.Fprintf(os.Stderr, "Nombre de migrations invalide : '%s' (entier positif attendu).\n", args[0])
+				os.Exit(1)
+			}
+			steps = n
 		}
 
-		// TODO 2: Initialiser la connexion à la base de données SQLite avec GORM.
-		db, err := gorm.Open(sqlite.Open(configs.Database.Name), &gorm.Config{})
+		migrator, closeDB := openMigrator()
+		defer closeDB()
+
+		reverted, err := migrator.Down(steps)
+		for _, migration := range reverted {
+			fmt.Printf("Annulée : %s_%s\n", migration.Version, migration.Name)
+		}
 		if err != nil {
-			fmt.Fprintf(os.Stderr, "Erreur lors de l'ouverture de la base SQLite : %v", err)
+			fmt.Fprintf(os.Stderr, "Erreur lors de l'annulation des migrations : %v\n", err)
 			os.Exit(1)
 		}
+		if len(reverted) == 0 {
+			fmt.Println("Aucune migration à annuler.")
+		}
+	},
+}
 
-		sqlDB, err := db.DB()
+// MigrateStatusCmd représente la commande 'migrate status'
+var MigrateStatusCmd = &cobra.Command{
+	Use:   "status",
+	Short: "Affiche les migrations appliquées et en attente.",
+	Args:  cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		migrator, closeDB := openMigrator()
+		defer closeDB()
+
+		statuses, err := migrator.Status()
 		if err != nil {
-			fmt.Fprintf(os.Stderr, "FATAL: Échec de l'obtention de la base de données SQL sous-jacente: %v", err)
+			fmt.Fprintf(os.Stderr, "Erreur lors de la lecture de l'état des migrations : %v\n", err)
 			os.Exit(1)
 		}
-		// TODO Assurez-vous que la connexion est fermée après la migration.
-		defer sqlDB.Close()
 
-		// TODO 3: Exécuter les migrations automatiques de GORM.
-		err = db.AutoMigrate(&models.Link{}, &models.Click{})
+		w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(w, "VERSION\tNOM\tÉTAT\tAPPLIQUÉE LE")
+		pending := 0
+		for _, status := range statuses {
+			state, appliedAt := "en attente", "-"
+			if status.AppliedAt != nil {
+				state, appliedAt = "appliquée", status.AppliedAt.Format(time.RFC3339)
+			} else {
+				pending++
+			}
+			if status.Unknown {
+				state = "inconnue de cette version"
+			}
+			fmt.Fprintf(w, "%s\t%s\t%s\t%s\n", status.Version, status.Name, state, appliedAt)
+		}
+		w.Flush()
+		fmt.Printf("\n%d migration(s) en attente.\n", pending)
+	},
+}
+
+// MigrateCreateCmd représente la commande 'migrate create'
+var MigrateCreateCmd = &cobra.Command{
+	Use:   "create <nom>",
+	Short: "Génère le squelette d'une nouvelle migration.",
+	Long: `Cette commande crée un fichier Go versionné par la date courante dans le répertoire
+des migrations, à compléter (Up et Down) puis à compiler avec l'application. Le fichier est écrit
+dans internal/migrations, relatif au répertoire courant : lancer la commande depuis la racine des
+sources, ou indiquer le répertoire avec --dir.
+
+Exemple:
+  url-shortener migrate create add_link_title
+  url-shortener migrate create add_link_title --dir ~/src/urlshortener/internal/migrations`,
+	Args: cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		path, err := migrations.Create(migrateCreateDir, args[0], time.Now())
 		if err != nil {
-			fmt.Fprintf(os.Stderr, "Erreur lors de l'exécution des migrations : %v", err)
+			fmt.Fprintf(os.Stderr, "Erreur lors de la création de la migration : %v\n", err)
 			os.Exit(1)
 		}
-
-		// Pas touche au log
-		fmt.Println("Migrations de la base de données exécutées avec succès.")
+		fmt.Printf("Migration créée : %s\n", path)
 	},
 }
 
+// migrateUp applique les migrations en attente.
+func migrateUp() {
+	migrator, closeDB := openMigrator()
+	defer closeDB()
+
+	applied, err := migrator.Up()
+	for _, migration := range applied {
+		fmt.Printf("Appliquée : %s_%s\n", migration.Version, migration.Name)
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Erreur lors de l'exécution des migrations : %v\n", err)
+		os.Exit(1)
+	}
+
+	// Pas touche au log
+	fmt.Println("Migrations de la base de données exécutées avec succès.")
+}
+
+// openMigrator ouvre la base de données et construit le Migrator.
+// La fonction retournée ferme la connexion.
+func openMigrator() (*migrations.Migrator, func()) {
+	configs, err := config.LoadConfig()
+	if err != nil {
+		fmt.Printf("Erreur lors du chargement de la configuration : %v\n", err)
+		os.Exit(1)
+	}
+
+	db, err := database.Open(configs.Database)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Erreur lors de l'ouverture de la base de données : %v\n", err)
+		os.Exit(1)
+	}
+
+	sqlDB, err := db.DB()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "FATAL: Échec de l'obtention de la base de données SQL sous-jacente: %v", err)
+		os.Exit(1)
+	}
+
+	return migrations.NewMigrator(db), func() { sqlDB.Close() }
+}
+
 func init() {
-	// TODO : Ajouter la commande à RootCmd
+	MigrateCreateCmd.Flags().StringVar(&migrateCreateDir, "dir", "internal/migrations", "Répertoire des migrations où écrire le fichier (sources de internal/migrations)")
+
+	MigrateCmd.AddCommand(MigrateUpCmd, MigrateDownCmd, MigrateStatusCmd, MigrateCreateCmd)
 	cmd2.RootCmd.AddCommand(MigrateCmd)
 }
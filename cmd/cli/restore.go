@@ -0,0 +1,80 @@
+package cli
+
+import (
+	"errors"
+	"fmt"
+	"os"
+
+	cmd2 "github.com/axellelanca/urlshortener/cmd"
+	"github.com/axellelanca/urlshortener/internal/config"
+	"github.com/axellelanca/urlshortener/internal/database"
+	"github.com/axellelanca/urlshortener/internal/repository"
+	"github.com/axellelanca/urlshortener/internal/services"
+	"github.com/spf13/cobra"
+	"gorm.io/gorm"
+)
+
+var (
+	restoreShortCode string
+)
+
+// RestoreCmd représente la commande 'restore'
+var RestoreCmd = &cobra.Command{
+	Use:   "restore",
+	Short: "Restaure un lien court précédemment supprimé.",
+	Long: `Cette commande annule la suppression logique d'un lien : il redirige à nouveau
+et l'historique de ses clics, conservé pendant la suppression, redevient visible.
+
+Exemple:
+  url-shortener restore --code="xyz123"`,
+	Run: func(cmd *cobra.Command, args []string) {
+		if restoreShortCode == "" {
+			fmt.Fprintf(os.Stderr, "Aucun code d'URL raccourcie n'a été fourni.")
+			os.Exit(1)
+		}
+
+		configs, err := config.LoadConfig()
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Erreur lors du chargement de la configuration : %v\n", err)
+			os.Exit(1)
+		}
+
+		db, err := database.Open(configs.Database)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Erreur lors de l'ouverture de la base de données : %v\n", err)
+			os.Exit(1)
+		}
+
+		sqlDB, err := db.DB()
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "FATAL: Échec de l'obtention de la base de données SQL sous-jacente: %v", err)
+			os.Exit(1)
+		}
+		defer sqlDB.Close()
+
+		service := services.NewLinkService(repository.NewLinkRepository(db), repository.NewAuditRepository(db), nil)
+
+		link, err := service.RestoreLink(restoreShortCode, cliActor())
+		if err != nil {
+			switch {
+			case errors.Is(err, gorm.ErrRecordNotFound):
+				fmt.Fprintf(os.Stderr, "Aucun lien trouvé pour le code: %s\n", restoreShortCode)
+			case errors.Is(err, services.ErrLinkNotDeleted):
+				fmt.Fprintf(os.Stderr, "Le lien %s n'est pas supprimé.\n", restoreShortCode)
+			default:
+				fmt.Fprintf(os.Stderr, "Erreur lors de la restauration du lien : %v\n", err)
+			}
+			os.Exit(1)
+		}
+
+		fmt.Printf("Lien restauré avec succès:\n")
+		fmt.Printf("Code: %s\n", link.ShortCode)
+		fmt.Printf("URL longue: %s\n", link.LongURL)
+	},
+}
+
+func init() {
+	RestoreCmd.Flags().StringVar(&restoreShortCode, "code", "", "Code court du lien à restaurer")
+	RestoreCmd.MarkFlagRequired("code")
+	cmd2.RootCmd.AddCommand(RestoreCmd)
+}
@@ -1,23 +1,35 @@
 package cli
 
 import (
+	"errors"
 	"fmt"
 	"github.com/axellelanca/urlshortener/internal/config"
+	"github.com/axellelanca/urlshortener/internal/database"
 	"os"
+	"strconv"
+	"text/tabwriter"
+	"time"
 	//"sync"
 
 	cmd2 "github.com/axellelanca/urlshortener/cmd"
+	"github.com/axellelanca/urlshortener/internal/models"
 	"github.com/axellelanca/urlshortener/internal/repository"
 	"github.com/axellelanca/urlshortener/internal/services"
 	"github.com/spf13/cobra"
 
-	"gorm.io/driver/sqlite" // Driver SQLite pour GORM
 	"gorm.io/gorm"
 )
 
 // TODO : variable shortCodeFlag qui stockera la valeur du flag --code
 var (
 	inputShortenedURL string
+	statsInterval     string
+	statsFrom         string
+	statsTo           string
+	statsBreakdown    bool
+	statsGeo          bool
+	statsIncludeBots  bool
+	statsReferrers    int
 )
 
 // StatsCmd représente la commande 'stats'
@@ -25,10 +37,20 @@ var StatsCmd = &cobra.Command{
 	Use:   "stats",
 	Short: "Affiche les statistiques (nombre de clics) pour un lien court.",
 	Long: `Cette commande permet de récupérer et d'afficher le nombre total de clics
-pour une URL courte spécifique en utilisant son code.
+et de visiteurs uniques pour une URL courte spécifique en utilisant son code.
+
+Avec --interval, la commande affiche aussi l'évolution des clics sous forme de tableau,
+par heure, jour, semaine ou mois, éventuellement sur une période donnée (--from/--to).
+Avec --breakdown, elle affiche la répartition des clics par navigateur, OS et type d'appareil.
+Avec --geo, elle affiche la répartition des clics par pays, région et ville.
+Avec --referrers, elle affiche les domaines de provenance qui ont amené le plus de clics.
+Les clics de robots (aperçus de liens, crawlers) sont exclus, sauf avec --include-bots.
 
 Exemple:
-  url-shortener stats --code="xyz123"`,
+  url-shortener stats --code="xyz123"
+  url-shortener stats --code="xyz123" --interval=day --from=2025-06-01 --to=2025-07-01
+  url-shortener stats --code="xyz123" --breakdown --geo
+  url-shortener stats --code="xyz123" --referrers=20`,
 	Run: func(cmd *cobra.Command, args []string) {
 		// TODO : Valider que le flag --code a été fourni.
 		if inputShortenedURL == "" {
@@ -43,10 +65,10 @@ Exemple:
 			os.Exit(1)
 		}
 
-		// TODO 3: Initialiser la connexion à la base de données SQLite avec GORM.
-		db, err := gorm.Open(sqlite.Open(configs.Database.Name), &gorm.Config{})
+		// TODO 3: Initialiser la connexion à la base de données avec GORM.
+		db, err := database.Open(configs.Database)
 		if err != nil {
-			fmt.Fprintf(os.Stderr, "Erreur lors de l'ouverture de la base SQLite : %v", err)
+			fmt.Fprintf(os.Stderr, "Erreur lors de l'ouverture de la base de données : %v\n", err)
 			os.Exit(1)
 		}
 
@@ -60,13 +82,16 @@ Exemple:
 
 		// TODO : Initialiser les repositories et services nécessaires NewLinkRepository & NewLinkService
 		repo := repository.NewLinkRepository(db)
-		service := services.NewLinkService(repo)
+		service := services.NewLinkService(repo, repository.NewAuditRepository(db), nil)
+		clickService := services.NewClickService(repository.NewClickRepository(db))
 
 		// TODO 5: Appeler GetLinkStats pour récupérer le lien et ses statistiques.
-		link, totalClicks, err := service.GetLinkStats(inputShortenedURL)
+		link, totalClicks, err := service.GetLinkStats(inputShortenedURL, statsIncludeBots)
 		if err != nil {
-			if err == gorm.ErrRecordNotFound {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
 				fmt.Fprintf(os.Stderr, "Aucun lien trouvé pour le code: %s\n", inputShortenedURL)
+			} else if errors.Is(err, services.ErrLinkDeleted) {
+				fmt.Fprintf(os.Stderr, "Le lien %s a été supprimé (utilisez 'restore' pour le restaurer).\n", inputShortenedURL)
 			} else {
 				fmt.Fprintf(os.Stderr, "Erreur lors de la récupération des statistiques : %v\n", err)
 			}
@@ -75,7 +100,44 @@ Exemple:
 
 		fmt.Printf("Statistiques pour le code court: %s\n", link.ShortCode)
 		fmt.Printf("URL longue: %s\n", link.LongURL)
-		fmt.Printf("Total de clics: %d\n", totalClicks)
+		uniqueVisitors, err := clickService.GetUniqueVisitorsByLinkID(link.ID, statsIncludeBots)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Erreur lors du comptage des visiteurs uniques : %v\n", err)
+			os.Exit(1)
+		}
+		if statsIncludeBots {
+			fmt.Printf("Total de clics (robots inclus): %d\n", totalClicks)
+		} else {
+			fmt.Printf("Total de clics: %d\n", totalClicks)
+		}
+		fmt.Printf("Visiteurs uniques (par jour): %d\n", uniqueVisitors)
+		if link.ExpiresAt != nil {
+			fmt.Printf("Expire le: %s\n", link.ExpiresAt.Format(time.RFC3339))
+		}
+		if left := link.ClicksLeft(); left != nil {
+			fmt.Printf("Clics restants: %d/%d\n", *left, link.MaxClicks)
+		}
+		if link.IsProtected() {
+			fmt.Printf("Protégé par mot de passe, échecs de déverrouillage: %d\n", link.UnlockFailures)
+		}
+		if link.IsExpired(time.Now()) {
+			fmt.Println("Statut: expiré")
+		} else {
+			fmt.Println("Statut: actif")
+		}
+
+		if statsInterval != "" || statsFrom != "" || statsTo != "" {
+			printTimeSeries(clickService, link.ID)
+		}
+		if statsBreakdown {
+			printBreakdowns(clickService, link.ID, userAgentDimensions)
+		}
+		if statsGeo {
+			printBreakdowns(clickService, link.ID, geoDimensions)
+		}
+		if cmd.Flags().Changed("referrers") {
+			printReferrers(clickService, link.ID)
+		}
 	},
 }
 
@@ -89,5 +151,92 @@ func init() {
 	// TODO Marquer le flag comme requis
 
 	StatsCmd.MarkFlagRequired("code")
+	StatsCmd.Flags().StringVar(&statsInterval, "interval", "", "Affiche les clics par tranche : hour, day, week ou month")
+	StatsCmd.Flags().StringVar(&statsFrom, "from", "", "Début de la période (RFC 3339 ou AAAA-MM-JJ)")
+	StatsCmd.Flags().StringVar(&statsTo, "to", "", "Fin de la période (RFC 3339 ou AAAA-MM-JJ)")
+	StatsCmd.Flags().BoolVar(&statsBreakdown, "breakdown", false, "Affiche la répartition des clics par navigateur, OS et type d'appareil")
+	StatsCmd.Flags().BoolVar(&statsGeo, "geo", false, "Affiche la répartition des clics par pays, région et ville")
+	StatsCmd.Flags().IntVar(&statsReferrers, "referrers", services.DefaultTopReferrers, "Affiche les N principaux domaines de provenance des clics")
+	StatsCmd.Flags().Lookup("referrers").NoOptDefVal = strconv.Itoa(services.DefaultTopReferrers)
+	StatsCmd.Flags().BoolVar(&statsIncludeBots, "include-bots", false, "Inclut les clics de robots dans les statistiques")
 	cmd2.RootCmd.AddCommand(StatsCmd)
 }
+
+// printTimeSeries affiche l'évolution des clics d'un lien sous forme de tableau.
+func printTimeSeries(clickService *services.ClickService, linkID uint) {
+	from, errFrom := services.ParseTimeParam(statsFrom)
+	to, errTo := services.ParseTimeParam(statsTo)
+	if err := errors.Join(errFrom, errTo); err != nil {
+		fmt.Fprintf(os.Stderr, "Période invalide : %v\n", err)
+		os.Exit(1)
+	}
+
+	series, err := clickService.GetClickTimeSeries(linkID, from, to, statsInterval, statsIncludeBots)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Erreur lors de la récupération de la série temporelle : %v\n", err)
+		os.Exit(1)
+	}
+
+	layout := time.DateOnly
+	if statsInterval == models.IntervalHour {
+		layout = "2006-01-02 15:00"
+	}
+
+	fmt.Println()
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', tabwriter.AlignRight)
+	fmt.Fprintln(w, "DÉBUT\tCLICS\tUNIQUES\t")
+	for _, bucket := range series {
+		fmt.Fprintf(w, "%s\t%d\t%d\t\n", bucket.Start.Format(layout), bucket.Clicks, bucket.UniqueVisitors)
+	}
+	w.Flush()
+}
+
+// breakdownTitle associe une dimension de répartition au titre de sa colonne dans le tableau affiché.
+type breakdownTitle struct{ dimension, title string }
+
+// Dimensions affichées par --breakdown et par --geo.
+var (
+	userAgentDimensions = []breakdownTitle{
+		{services.DimensionBrowser, "NAVIGATEUR"},
+		{services.DimensionOS, "SYSTÈME"},
+		{services.DimensionDevice, "APPAREIL"},
+	}
+	geoDimensions = []breakdownTitle{
+		{services.DimensionCountry, "PAYS"},
+		{services.DimensionRegion, "RÉGION"},
+		{services.DimensionCity, "VILLE"},
+	}
+)
+
+// printBreakdowns affiche la répartition des clics selon chacune des dimensions données.
+func printBreakdowns(clickService *services.ClickService, linkID uint, titles []breakdownTitle) {
+	for _, t := range titles {
+		counts, err := clickService.GetClickBreakdown(linkID, t.dimension, statsIncludeBots)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Erreur lors de la récupération de la répartition des clics : %v\n", err)
+			os.Exit(1)
+		}
+		printDimensionCounts(t.title, counts)
+	}
+}
+
+// printReferrers affiche les principaux domaines de provenance des clics d'un lien.
+func printReferrers(clickService *services.ClickService, linkID uint) {
+	counts, err := clickService.GetTopReferrers(linkID, statsReferrers, statsIncludeBots)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Erreur lors du classement des référents : %v\n", err)
+		os.Exit(1)
+	}
+	printDimensionCounts("RÉFÉRENT", counts)
+}
+
+// printDimensionCounts affiche une répartition de clics sous forme de tableau.
+func printDimensionCounts(title string, counts []models.DimensionCount) {
+	fmt.Println()
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintf(w, "%s\tCLICS\n", title)
+	for _, count := range counts {
+		fmt.Fprintf(w, "%s\t%d\n", count.Value, count.Clicks)
+	}
+	w.Flush()
+}
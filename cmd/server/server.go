@@ -1,6 +1,7 @@
 package server
 
 import (
+	"context"
 	"errors"
 	"fmt"
 	"log"
@@ -11,18 +12,26 @@ import (
 	"time"
 
 	"github.com/axellelanca/urlshortener/internal/config"
+	"github.com/axellelanca/urlshortener/internal/database"
 
 	cmd2 "github.com/axellelanca/urlshortener/cmd"
+	"github.com/axellelanca/urlshortener/internal/anonymize"
 	"github.com/axellelanca/urlshortener/internal/api"
+	"github.com/axellelanca/urlshortener/internal/botdetect"
+	"github.com/axellelanca/urlshortener/internal/fingerprint"
+	"github.com/axellelanca/urlshortener/internal/geoip"
+	"github.com/axellelanca/urlshortener/internal/health"
+	"github.com/axellelanca/urlshortener/internal/migrations"
 	"github.com/axellelanca/urlshortener/internal/models"
 	"github.com/axellelanca/urlshortener/internal/monitor"
+	"github.com/axellelanca/urlshortener/internal/ratelimit"
 	"github.com/axellelanca/urlshortener/internal/repository"
+	"github.com/axellelanca/urlshortener/internal/retention"
 	"github.com/axellelanca/urlshortener/internal/services"
+	"github.com/axellelanca/urlshortener/internal/spool"
+	"github.com/axellelanca/urlshortener/internal/urlpolicy"
 	"github.com/axellelanca/urlshortener/internal/workers"
-	"github.com/gin-gonic/gin"
 	"github.com/spf13/cobra"
-	"gorm.io/driver/sqlite" // Driver SQLite pour GORM
-	"gorm.io/gorm"
 )
 
 // RunServerCmd représente la commande 'run-server' de Cobra.
@@ -42,32 +51,125 @@ puis lance le serveur HTTP.`,
 			os.Exit(1)
 		}
 
-		// TODO : Initialiser la connexion à la base de données SQLite avec GORM.
-		db, err := gorm.Open(sqlite.Open(configs.Database.Name), &gorm.Config{})
+		if err := services.ValidateRedirectType(configs.Server.DefaultRedirectType); err != nil {
+			fmt.Fprintf(os.Stderr, "Configuration invalide pour server.default_redirect_type : %v\n", err)
+			os.Exit(1)
+		}
+
+		// TODO : Initialiser la connexion à la base de données avec GORM (driver de la section database).
+		db, err := database.Open(configs.Database)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Erreur lors de l'ouverture de la base de données : %v\n", err)
+			os.Exit(1)
+		}
+
+		// Le serveur refuse de démarrer sur un schéma qui n'est pas à jour
+		pending, err := migrations.NewMigrator(db).Pending()
 		if err != nil {
-			fmt.Fprintf(os.Stderr, "Erreur lors de la base SQLite : %v", err)
+			fmt.Fprintf(os.Stderr, "Impossible de vérifier l'état des migrations : %v\n", err)
+			os.Exit(1)
+		}
+		if len(pending) > 0 {
+			fmt.Fprintf(os.Stderr, "Le schéma de la base n'est pas à jour : %d migration(s) en attente (dont %s_%s). Exécutez 'url-shortener migrate up'.\n",
+				len(pending), pending[0].Version, pending[0].Name)
 			os.Exit(1)
 		}
 
 		// TODO : Initialiser les repositories.
 		linkRepository := repository.NewLinkRepository(db)
 		clickRepository := repository.NewClickRepository(db)
+		auditRepository := repository.NewAuditRepository(db)
+		apiKeyRepository := repository.NewAPIKeyRepository(db)
 		log.Println("Repositories initialisés.")
 
 		// TODO : Initialiser les services métiers.
-		linkService := services.NewLinkService(linkRepository)
-		//clickService := services.NewClickService(clickRepository)
+		// Cache LRU de la résolution des codes courts, devant le repository de liens
+		var serviceLinkRepository repository.LinkRepository = linkRepository
+		if configs.Cache.LinkSize > 0 {
+			serviceLinkRepository = repository.NewCachedLinkRepository(
+				linkRepository,
+				configs.Cache.LinkSize,
+				time.Duration(configs.Cache.LinkTTLSeconds)*time.Second,
+				time.Duration(configs.Cache.NegativeTTLSeconds)*time.Second,
+			)
+			log.Printf("Cache des liens activé (%d entrées, TTL %ds).", configs.Cache.LinkSize, configs.Cache.LinkTTLSeconds)
+		}
+		// Politique des URLs de destination ; ses listes de domaines sont rechargées à chaud
+		urlPolicy, err := urlpolicy.FromConfig(configs.URLPolicy)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Politique des URLs invalide : %v\n", err)
+			os.Exit(1)
+		}
+		go urlPolicy.Start()
+		linkService := services.NewLinkService(serviceLinkRepository, auditRepository, urlPolicy)
+		apiKeyService := services.NewAPIKeyService(apiKeyRepository)
+		clickService := services.NewClickService(clickRepository)
 		log.Println("Services métiers initialisés.")
 
 		// TODO : Initialiser le channel ClickEventsChannel (api/handlers) des événements de clic et lancer les workers (StartClickWorkers).
 		clickEventsChannel := make(chan *models.ClickEvent, cmd2.Cfg.Analytics.BufferSize)
 		api.ClickEventsChannel = clickEventsChannel
 
-		workers.StartClickWorkers(
+		// Détection des robots configurée via la section 'bots'
+		botDetector, err := botdetect.NewDetector(configs.Bots.Enabled, configs.Bots.UserAgentPatterns, configs.Bots.IPRanges)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Configuration de détection des robots invalide : %v\n", err)
+			os.Exit(1)
+		}
+		api.BotDetector = botDetector
+
+		// Géolocalisation des clics : une base absente désactive simplement l'enrichissement
+		geoResolver, err := geoip.Open(configs.GeoIP.DatabasePath)
+		switch {
+		case errors.Is(err, os.ErrNotExist):
+			log.Printf("Base GeoIP '%s' introuvable, les clics ne seront pas géolocalisés.", configs.GeoIP.DatabasePath)
+		case err != nil:
+			fmt.Fprintf(os.Stderr, "Base GeoIP invalide : %v\n", err)
+			os.Exit(1)
+		case geoResolver != nil:
+			defer geoResolver.Close()
+			log.Printf("Base GeoIP '%s' chargée.", configs.GeoIP.DatabasePath)
+		}
+
+		// Anonymisation des IP : le mode hash réutilise les sels journaliers des empreintes
+		hasher := fingerprint.NewHasher(repository.NewVisitorSaltRepository(db))
+		anonymizer, err := anonymize.NewAnonymizer(configs.Privacy.IPMode, hasher)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Configuration d'anonymisation invalide : %v\n", err)
+			os.Exit(1)
+		}
+
+		// Spool sur disque optionnel : les redirections y écrivent chaque événement avant de l'envoyer dans
+		// le channel, les workers l'acquittent une fois persisté. Un lecteur transmet les événements que
+		// le channel, plein, n'a pas acceptés et rejoue ceux non persistés lors de l'exécution précédente.
+		var clickSpool *spool.Spool
+		var acker workers.Acknowledger
+		if configs.Analytics.SpoolDir != "" {
+			clickSpool, err = spool.Open(configs.Analytics.SpoolDir)
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "Impossible d'ouvrir le spool des clics : %v\n", err)
+				os.Exit(1)
+			}
+			api.ClickEventSpool = clickSpool
+			acker = clickSpool
+			log.Printf("Spool des clics ouvert dans '%s' (%d segment(s) à rejouer).", configs.Analytics.SpoolDir, clickSpool.Recovered())
+		}
+
+		enricher := workers.NewClickEnricher(botDetector, hasher, geoResolver, anonymizer)
+		workerPool := workers.StartClickWorkers(
 			cmd2.Cfg.Analytics.WorkerCount,
 			clickEventsChannel,
 			clickRepository,
+			enricher,
+			workers.BatchConfig{
+				Size:          configs.Analytics.BatchSize,
+				FlushInterval: time.Duration(configs.Analytics.FlushIntervalMs) * time.Millisecond,
+			},
+			acker,
 		)
+		if clickSpool != nil {
+			go clickSpool.Feed(clickEventsChannel)
+		}
 		log.Printf(
 			"Channel de clics initialisé (buffer=%d) et %d worker(s) démarré(s).",
 			cmd2.Cfg.Analytics.BufferSize, cmd2.Cfg.Analytics.WorkerCount,
@@ -75,15 +177,65 @@ puis lance le serveur HTTP.`,
 
 		// TODO : Initialiser et lancer le moniteur d'URLs.
 		monitorInterval := time.Duration(configs.Monitor.IntervalMinutes) * time.Minute
-		urlMonitor := monitor.NewUrlMonitor(linkRepository, monitorInterval) // Le moniteur a besoin du linkRepo et de l'interval
+		urlMonitor := monitor.NewUrlMonitor(linkRepository, monitorInterval, urlPolicy.HTTPClient(monitor.CheckTimeout)) // Le client refuse les adresses internes à la connexion
 		go urlMonitor.Start()
 		log.Printf("Moniteur d'URLs démarré avec un intervalle de %v.", monitorInterval)
 
+		// Rétention des clics bruts (purge ou agrégation au-delà de privacy.retention_days)
+		retentionJob, err := retention.NewJob(
+			clickRepository,
+			configs.Privacy.RetentionDays,
+			configs.Privacy.RetentionMode,
+			time.Duration(configs.Privacy.RetentionIntervalMinutes)*time.Minute,
+		)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Configuration de rétention invalide : %v\n", err)
+			os.Exit(1)
+		}
+		if retentionJob.Enabled() {
+			go retentionJob.Start()
+		} else {
+			log.Println("Rétention des clics désactivée (privacy.retention_days = 0).")
+		}
+
 		// TODO : Configurer le routeur Gin et les handlers API.
-		router := gin.Default()
-		api.SetupRoutes(router, linkService)
+		router, err := api.NewRouter(configs.Server.TrustedProxies)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Configuration invalide pour server.trusted_proxies : %v\n", err)
+			os.Exit(1)
+		}
+		healthChecker := health.NewChecker(db, clickEventsChannel, workerPool, urlMonitor, health.Options{
+			DBTimeout:              time.Duration(configs.Health.DBTimeoutMs) * time.Millisecond,
+			QueueSaturationPercent: configs.Health.QueueSaturationPercent,
+			WorkerStallTimeout:     time.Duration(configs.Health.WorkerStallSeconds) * time.Second,
+			Spooled:                clickSpool != nil,
+		})
+		var limiters api.RateLimiters
+		if configs.RateLimit.Enabled {
+			limiters = api.RateLimiters{
+				API:        newRateLimiter(configs.RateLimit.API),
+				Create:     newRateLimiter(configs.RateLimit.Create),
+				Stats:      newRateLimiter(configs.RateLimit.Stats),
+				Redirect:   newRateLimiter(configs.RateLimit.Redirect),
+				Unlock:     newRateLimiter(configs.RateLimit.Unlock),
+				UnlockLink: newRateLimiter(configs.RateLimit.UnlockLink),
+			}
+		}
+		api.SetupRoutes(router, linkService, clickService, apiKeyService, healthChecker, limiters)
 		log.Println("Routes API configurées.")
 
+		// Les métriques sont servies sur leur propre adresse, ou sur le port principal derrière un jeton
+		var metricsSrv *http.Server
+		switch {
+		case configs.Server.MetricsAddr != "":
+			metricsSrv = &http.Server{Addr: configs.Server.MetricsAddr, Handler: api.NewMetricsRouter(configs.Server.MetricsToken)}
+		case configs.Server.MetricsToken != "":
+			api.SetupMetricsRoutes(router, configs.Server.MetricsToken)
+			log.Println("Métriques exposées sur /metrics du port principal, protégées par server.metrics_token.")
+		default:
+			log.Println("Métriques non exposées (server.metrics_addr et server.metrics_token vides).")
+		}
+
 		// Créer le serveur HTTP Gin
 		serverAddr := fmt.Sprintf(":%d", configs.Server.Port)
 		srv := &http.Server{
@@ -99,6 +251,15 @@ puis lance le serveur HTTP.`,
 				os.Exit(1)
 			}
 		}()
+		if metricsSrv != nil {
+			go func() {
+				log.Printf("Métriques exposées sur %s/metrics", metricsSrv.Addr)
+				if err := metricsSrv.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+					fmt.Fprintf(os.Stderr, "Erreur lors du démarrage du serveur de métriques : %v", err)
+					os.Exit(1)
+				}
+			}()
+		}
 
 		// Gére l'arrêt propre du serveur (graceful shutdown).
 		// Créez un channel pour les signaux OS (SIGINT, SIGTERM).
@@ -109,14 +270,86 @@ puis lance le serveur HTTP.`,
 		<-quit
 		log.Println("Signal d'arrêt reçu. Arrêt du serveur...")
 
-		// Arrêt propre du serveur HTTP avec un timeout.
-		log.Println("Arrêt en cours... Donnez un peu de temps aux workers pour finir.")
-		time.Sleep(5 * time.Second)
+		// Toutes les étapes de l'arrêt partagent le même délai.
+		shutdownTimeout := time.Duration(configs.Server.ShutdownTimeoutSeconds) * time.Second
+		if shutdownTimeout <= 0 {
+			shutdownTimeout = 10 * time.Second
+		}
+		deadline := time.Now().Add(shutdownTimeout)
+		ctx, cancel := context.WithDeadline(context.Background(), deadline)
+		defer cancel()
+
+		// 1. Arrêt du serveur HTTP : plus de nouvelles connexions, attente des requêtes en cours.
+		if err := srv.Shutdown(ctx); err != nil {
+			log.Printf("Arrêt du serveur HTTP incomplet : %v", err)
+		} else {
+			log.Println("Serveur HTTP arrêté.")
+		}
+		if metricsSrv != nil {
+			if err := metricsSrv.Shutdown(ctx); err != nil {
+				log.Printf("Arrêt du serveur de métriques incomplet : %v", err)
+			}
+		}
+
+		// 2. Fermeture du channel de clics puis attente des workers, qui écrivent les clics restants.
+		// Le lecteur du spool est arrêté d'abord, les événements non transmis restant sur disque.
+		// Les redirections encore en cours n'écrivent plus dans le channel une fois fermé ; avec le spool,
+		// leur événement y reste et sera rejoué.
+		if clickSpool != nil {
+			clickSpool.StopFeeding()
+		}
+		backlog := workerPool.Backlog()
+		api.CloseClickEventsChannel()
+		log.Printf("Écriture des %d clic(s) en attente...", backlog)
+		finished := workerPool.Wait(time.Until(deadline))
+		unwritten := workerPool.Backlog()
+		if !finished {
+			log.Println("Délai d'arrêt dépassé avant la fin des workers.")
+			// Les événements restés dans le channel sont conservés dans le spool plutôt que perdus ;
+			// ceux des lots en cours d'écriture y sont déjà, non acquittés.
+			if clickSpool != nil {
+				log.Printf("%d événement(s) du channel conservé(s) dans le spool.", clickSpool.Drain(clickEventsChannel))
+			}
+		}
+		if clickSpool != nil {
+			log.Printf("Clics en attente à l'arrêt : %d écrit(s), %d conservé(s) dans le spool.", backlog-unwritten, unwritten)
+		} else {
+			log.Printf("Clics en attente à l'arrêt : %d écrit(s), %d perdu(s).", backlog-unwritten, unwritten)
+		}
+		if clickSpool != nil {
+			if err := clickSpool.Close(); err != nil {
+				log.Printf("Erreur lors de la fermeture du spool des clics : %v", err)
+			}
+			if pending := clickSpool.Pending(); pending > 0 {
+				log.Printf("%d segment(s) du spool conservé(s), rejoué(s) au prochain démarrage.", pending)
+			}
+		}
+
+		// 3. Arrêt des tâches de fond, puis fermeture de la base de données.
+		urlMonitor.Stop()
+		if retentionJob.Enabled() {
+			retentionJob.Stop()
+		}
+		urlPolicy.Stop()
+		if sqlDB, err := db.DB(); err == nil {
+			if err := sqlDB.Close(); err != nil {
+				log.Printf("Erreur lors de la fermeture de la base de données : %v", err)
+			}
+		}
 
 		log.Println("Serveur arrêté proprement.")
 	},
 }
 
+// newRateLimiter crée le limiteur en mémoire d'une règle, nil si la règle est désactivée.
+// Le retour est une interface : une règle désactivée doit donner un limiteur nil, pas un pointeur nil typé.
+func newRateLimiter(rule config.RateLimitRule) ratelimit.Limiter {
+	if rule.RequestsPerMinute <= 0 {
+		return nil
+	}
+	return ratelimit.NewMemoryLimiter(rule.RequestsPerMinute, rule.Burst)
+}
+
 func init() {
 	// TODO : ajouter la commande
 	cmd2.RootCmd.AddCommand(RunServerCmd)
@@ -0,0 +1,79 @@
+package anonymize
+
+import (
+	"fmt"
+	"net"
+	"time"
+
+	"github.com/axellelanca/urlshortener/internal/fingerprint"
+)
+
+// Modes d'anonymisation des adresses IP enregistrées avec les clics.
+const (
+	ModeFull     = "full"     // Adresse complète, sans anonymisation
+	ModeTruncate = "truncate" // Réseau /24 en IPv4 et /48 en IPv6 (ex: 81.2.69.0)
+	ModeHash     = "hash"     // Condensé salé avec le sel journalier des empreintes
+	ModeNone     = "none"     // Aucune adresse conservée
+)
+
+// Tailles des préfixes conservés par le mode truncate.
+var (
+	ipv4Mask = net.CIDRMask(24, 32)
+	ipv6Mask = net.CIDRMask(48, 128)
+)
+
+// Anonymizer transforme l'adresse IP d'un clic avant sa persistance, selon le mode configuré.
+// Il est appelé en dernier par le worker, une fois les enrichissements qui ont besoin de l'adresse
+// complète (détection des robots, empreinte, géolocalisation) effectués.
+type Anonymizer struct {
+	mode   string
+	hasher *fingerprint.Hasher
+}
+
+// NewAnonymizer crée un Anonymizer pour le mode donné (truncate si vide).
+// Le hasher n'est utilisé qu'en mode hash.
+func NewAnonymizer(mode string, hasher *fingerprint.Hasher) (*Anonymizer, error) {
+	switch mode {
+	case "":
+		mode = ModeTruncate
+	case ModeFull, ModeTruncate, ModeHash, ModeNone:
+	default:
+		return nil, fmt.Errorf("[Anonymize] mode '%s' inconnu (full, truncate, hash ou none)", mode)
+	}
+	return &Anonymizer{mode: mode, hasher: hasher}, nil
+}
+
+// Mode retourne le mode d'anonymisation appliqué.
+func (a *Anonymizer) Mode() string {
+	return a.mode
+}
+
+// Anonymize retourne la valeur à enregistrer pour l'adresse ip d'un clic survenu à at.
+func (a *Anonymizer) Anonymize(ip string, at time.Time) (string, error) {
+	switch a.mode {
+	case ModeFull:
+		return ip, nil
+	case ModeHash:
+		if ip == "" {
+			return "", nil
+		}
+		return a.hasher.HashIP(ip, at)
+	case ModeNone:
+		return "", nil
+	default:
+		return TruncateIP(ip), nil
+	}
+}
+
+// TruncateIP masque la partie hôte d'une adresse : /24 en IPv4, /48 en IPv6.
+// Une adresse invalide retourne une chaîne vide plutôt que d'être conservée telle quelle.
+func TruncateIP(ip string) string {
+	parsed := net.ParseIP(ip)
+	if parsed == nil {
+		return ""
+	}
+	if v4 := parsed.To4(); v4 != nil {
+		return v4.Mask(ipv4Mask).String()
+	}
+	return parsed.Mask(ipv6Mask).String()
+}
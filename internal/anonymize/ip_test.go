@@ -0,0 +1,100 @@
+package anonymize
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/axellelanca/urlshortener/internal/fingerprint"
+)
+
+// memorySaltStore est un fingerprint.SaltStore en mémoire pour les tests.
+type memorySaltStore map[string]string
+
+func (m memorySaltStore) GetOrCreateSalt(day, candidate string) (string, error) {
+	if salt, ok := m[day]; ok {
+		return salt, nil
+	}
+	m[day] = candidate
+	return candidate, nil
+}
+
+func (m memorySaltStore) DeleteSaltsBefore(string) error { return nil }
+
+func TestAnonymize(t *testing.T) {
+	at := time.Now()
+	tests := []struct {
+		mode string
+		ip   string
+		want string
+	}{
+		{ModeTruncate, "81.2.69.160", "81.2.69.0"},
+		{ModeTruncate, "::ffff:81.2.69.160", "81.2.69.0"},
+		{ModeTruncate, "2001:db8:abcd:1234:5678::1", "2001:db8:abcd::"},
+		{ModeTruncate, "::1", "::"},
+		{ModeTruncate, "not-an-ip", ""},
+		{ModeTruncate, "81.2.69", ""},
+		{ModeTruncate, "", ""},
+		{"", "81.2.69.160", "81.2.69.0"}, // truncate par défaut
+		{ModeFull, "81.2.69.160", "81.2.69.160"},
+		{ModeFull, "2001:db8::1", "2001:db8::1"},
+		{ModeNone, "81.2.69.160", ""},
+		{ModeNone, "2001:db8::1", ""},
+		{ModeHash, "", ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.mode+" "+tt.ip, func(t *testing.T) {
+			anonymizer, err := NewAnonymizer(tt.mode, fingerprint.NewHasher(memorySaltStore{}))
+			if err != nil {
+				t.Fatal(err)
+			}
+			got, err := anonymizer.Anonymize(tt.ip, at)
+			if err != nil {
+				t.Fatalf("Anonymize: %v", err)
+			}
+			if got != tt.want {
+				t.Fatalf("Anonymize(%q) en mode %q = %q, attendu %q", tt.ip, tt.mode, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestAnonymizeHash(t *testing.T) {
+	anonymizer, err := NewAnonymizer(ModeHash, fingerprint.NewHasher(memorySaltStore{}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Seuls les sels de la veille et du jour sont disponibles
+	yesterday := time.Now().UTC().AddDate(0, 0, -1).Truncate(24 * time.Hour)
+	morning, evening := yesterday.Add(9*time.Hour), yesterday.Add(21*time.Hour)
+	nextDay := morning.AddDate(0, 0, 1)
+
+	hash := func(ip string, at time.Time) string {
+		t.Helper()
+		value, err := anonymizer.Anonymize(ip, at)
+		if err != nil {
+			t.Fatalf("Anonymize: %v", err)
+		}
+		return value
+	}
+
+	first := hash("81.2.69.160", morning)
+	if len(first) != 32 || strings.Contains(first, "81.2.69") {
+		t.Fatalf("condensé %q : attendu 32 caractères hexadécimaux sans l'adresse", first)
+	}
+	if again := hash("81.2.69.160", evening); again != first {
+		t.Fatalf("même IP le même jour : %q puis %q", first, again)
+	}
+	if other := hash("81.2.69.161", morning); other == first {
+		t.Fatal("deux adresses différentes ont le même condensé")
+	}
+	if tomorrow := hash("81.2.69.160", nextDay); tomorrow == first {
+		t.Fatal("le condensé devrait changer avec le sel du jour suivant")
+	}
+}
+
+func TestNewAnonymizerRejectsUnknownMode(t *testing.T) {
+	if _, err := NewAnonymizer("mask", nil); err == nil {
+		t.Fatal("NewAnonymizer devrait refuser un mode inconnu")
+	}
+}
@@ -0,0 +1,126 @@
+package api
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/axellelanca/urlshortener/cmd"
+	"github.com/axellelanca/urlshortener/internal/config"
+	"github.com/axellelanca/urlshortener/internal/database"
+	"github.com/axellelanca/urlshortener/internal/migrations"
+	"github.com/axellelanca/urlshortener/internal/models"
+	"github.com/axellelanca/urlshortener/internal/repository"
+	"github.com/axellelanca/urlshortener/internal/services"
+	"github.com/gin-gonic/gin"
+)
+
+// testEnv regroupe un routeur complet sur une base SQLite temporaire.
+type testEnv struct {
+	router        *gin.Engine
+	linkService   *services.LinkService
+	apiKeyService *services.APIKeyService
+	clicks        chan *models.ClickEvent
+}
+
+// newTestEnv crée une base SQLite migrée dans un répertoire temporaire et configure toutes les routes.
+func newTestEnv(t *testing.T, limiters RateLimiters) *testEnv {
+	t.Helper()
+	gin.SetMode(gin.TestMode)
+	cmd.Cfg = &config.Config{Server: config.ServerConfig{BaseURL: "http://short.test"}}
+
+	db, err := database.Open(config.DatabaseConfig{Driver: "sqlite", Name: filepath.Join(t.TempDir(), "test.db")})
+	if err != nil {
+		t.Fatalf("ouverture de la base : %v", err)
+	}
+	sqlDB, err := db.DB()
+	if err != nil {
+		t.Fatalf("base SQL sous-jacente : %v", err)
+	}
+	t.Cleanup(func() { sqlDB.Close() })
+	if _, err := migrations.NewMigrator(db).Up(); err != nil {
+		t.Fatalf("migrations : %v", err)
+	}
+
+	env := &testEnv{
+		linkService:   services.NewLinkService(repository.NewLinkRepository(db), repository.NewAuditRepository(db), nil),
+		apiKeyService: services.NewAPIKeyService(repository.NewAPIKeyRepository(db)),
+		clicks:        make(chan *models.ClickEvent, 100),
+	}
+	ClickEventsChannel = env.clicks
+	ClickEventSpool = nil
+	t.Cleanup(func() { ClickEventsChannel = nil })
+
+	router, err := NewRouter(nil)
+	if err != nil {
+		t.Fatalf("routeur : %v", err)
+	}
+	SetupRoutes(router, env.linkService,
+		services.NewClickService(repository.NewClickRepository(db)),
+		env.apiKeyService, nil, limiters)
+	env.router = router
+	return env
+}
+
+// createLink crée un lien via le service, en échouant le test en cas d'erreur.
+func (e *testEnv) createLink(t *testing.T, input services.CreateLinkInput) *models.Link {
+	t.Helper()
+	link, err := e.linkService.CreateLink(input, "test")
+	if err != nil {
+		t.Fatalf("création du lien : %v", err)
+	}
+	return link
+}
+
+// createAPIKey crée une clé API et retourne la valeur à envoyer dans l'en-tête X-API-Key.
+func (e *testEnv) createAPIKey(t *testing.T, name string) (*models.APIKey, string) {
+	t.Helper()
+	key, rawKey, err := e.apiKeyService.CreateAPIKey(name)
+	if err != nil {
+		t.Fatalf("création de la clé API : %v", err)
+	}
+	return key, rawKey
+}
+
+// doJSON exécute une requête avec un corps JSON, authentifiée par la clé API rawKey.
+func (e *testEnv) doJSON(t *testing.T, method, target, rawKey string, body any) *httptest.ResponseRecorder {
+	t.Helper()
+	payload, err := json.Marshal(body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	req := httptest.NewRequest(method, target, bytes.NewReader(payload))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("X-API-Key", rawKey)
+	w := httptest.NewRecorder()
+	e.router.ServeHTTP(w, req)
+	return w
+}
+
+// do exécute une requête sur le routeur depuis l'adresse remoteAddr.
+func (e *testEnv) do(method, target, remoteAddr string, form url.Values, headers map[string]string) *httptest.ResponseRecorder {
+	var req *http.Request
+	if form != nil {
+		req = httptest.NewRequest(method, target, strings.NewReader(form.Encode()))
+		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	} else {
+		req = httptest.NewRequest(method, target, nil)
+	}
+	req.RemoteAddr = remoteAddr
+	for name, value := range headers {
+		req.Header.Set(name, value)
+	}
+	w := httptest.NewRecorder()
+	e.router.ServeHTTP(w, req)
+	return w
+}
+
+// pendingClicks retourne le nombre d'événements de clic envoyés aux workers.
+func (e *testEnv) pendingClicks() int {
+	return len(e.clicks)
+}
@@ -0,0 +1,107 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+)
+
+// auditActions retourne les actions de l'historique d'un lien, dans l'ordre, lues via l'API.
+func (e *testEnv) auditActions(t *testing.T, shortCode, rawKey string) []string {
+	t.Helper()
+	w := e.doJSON(t, http.MethodGet, "/api/v1/links/"+shortCode+"/audit", rawKey, nil)
+	if w.Code != http.StatusOK {
+		t.Fatalf("GET audit : statut %d (%s)", w.Code, w.Body)
+	}
+	var body struct {
+		Entries []struct {
+			Action string `json:"action"`
+			Actor  string `json:"actor"`
+		} `json:"entries"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &body); err != nil {
+		t.Fatal(err)
+	}
+	actions := make([]string, 0, len(body.Entries))
+	for _, entry := range body.Entries {
+		if entry.Actor == "" {
+			t.Errorf("entrée %s sans auteur", entry.Action)
+		}
+		actions = append(actions, entry.Action)
+	}
+	return actions
+}
+
+func TestDeleteAndRestoreRoundTrip(t *testing.T) {
+	env := newTestEnv(t, RateLimiters{})
+	_, rawKey := env.createAPIKey(t, "test")
+
+	create := map[string]any{"long_url": "https://example.com/promo", "alias": "promo"}
+	if w := env.doJSON(t, http.MethodPost, "/api/v1/links", rawKey, create); w.Code != http.StatusCreated {
+		t.Fatalf("création : statut %d (%s)", w.Code, w.Body)
+	}
+	if w := env.doJSON(t, http.MethodDelete, "/api/v1/links/promo", rawKey, nil); w.Code != http.StatusNoContent {
+		t.Fatalf("suppression : statut %d (%s)", w.Code, w.Body)
+	}
+
+	// Le lien supprimé ne redirige plus et n'est plus consultable, mais son historique reste accessible
+	if w := env.do(http.MethodGet, "/promo", "203.0.113.7:4321", nil, nil); w.Code != http.StatusGone {
+		t.Fatalf("redirection d'un lien supprimé : statut %d, attendu 410", w.Code)
+	}
+	if env.pendingClicks() != 0 {
+		t.Fatalf("%d clic(s) enregistré(s) pour un lien supprimé", env.pendingClicks())
+	}
+	if w := env.doJSON(t, http.MethodGet, "/api/v1/links/promo", rawKey, nil); w.Code != http.StatusGone {
+		t.Fatalf("consultation d'un lien supprimé : statut %d, attendu 410", w.Code)
+	}
+	if w := env.doJSON(t, http.MethodDelete, "/api/v1/links/promo", rawKey, nil); w.Code != http.StatusGone {
+		t.Fatalf("double suppression : statut %d, attendu 410", w.Code)
+	}
+
+	// L'alias reste réservé tant que le lien supprimé peut être restauré
+	if w := env.doJSON(t, http.MethodPost, "/api/v1/links", rawKey, create); w.Code != http.StatusConflict {
+		t.Fatalf("réutilisation de l'alias : statut %d, attendu 409 (%s)", w.Code, w.Body)
+	}
+
+	if w := env.doJSON(t, http.MethodPost, "/api/v1/links/promo/restore", rawKey, nil); w.Code != http.StatusOK {
+		t.Fatalf("restauration : statut %d (%s)", w.Code, w.Body)
+	}
+	if w := env.doJSON(t, http.MethodPost, "/api/v1/links/promo/restore", rawKey, nil); w.Code != http.StatusConflict {
+		t.Fatalf("restauration d'un lien actif : statut %d, attendu 409", w.Code)
+	}
+	w := env.do(http.MethodGet, "/promo", "203.0.113.7:4321", nil, nil)
+	if w.Code != http.StatusFound || w.Header().Get("Location") != "https://example.com/promo" {
+		t.Fatalf("redirection après restauration : statut %d vers %q", w.Code, w.Header().Get("Location"))
+	}
+
+	// Une entrée d'audit par action, les échecs n'en écrivent pas
+	actions := env.auditActions(t, "promo", rawKey)
+	want := []string{"create", "delete", "restore"}
+	if len(actions) != len(want) {
+		t.Fatalf("historique %v, attendu %v", actions, want)
+	}
+	for i := range want {
+		if actions[i] != want[i] {
+			t.Fatalf("historique %v, attendu %v", actions, want)
+		}
+	}
+}
+
+func TestDeletedLinkIsNotManageableByOtherKeys(t *testing.T) {
+	env := newTestEnv(t, RateLimiters{})
+	_, ownerKey := env.createAPIKey(t, "owner")
+	_, otherKey := env.createAPIKey(t, "other")
+
+	if w := env.doJSON(t, http.MethodPost, "/api/v1/links", ownerKey, map[string]any{"long_url": "https://example.com/", "alias": "owned"}); w.Code != http.StatusCreated {
+		t.Fatalf("création : statut %d (%s)", w.Code, w.Body)
+	}
+	if w := env.doJSON(t, http.MethodDelete, "/api/v1/links/owned", ownerKey, nil); w.Code != http.StatusNoContent {
+		t.Fatalf("suppression : statut %d", w.Code)
+	}
+	if w := env.doJSON(t, http.MethodPost, "/api/v1/links/owned/restore", otherKey, nil); w.Code != http.StatusForbidden {
+		t.Fatalf("restauration par une autre clé : statut %d, attendu 403", w.Code)
+	}
+	if w := env.doJSON(t, http.MethodPost, "/api/v1/links/absent/restore", ownerKey, nil); w.Code != http.StatusNotFound {
+		t.Fatalf("restauration d'un code inconnu : statut %d, attendu 404", w.Code)
+	}
+}
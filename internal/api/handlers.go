@@ -1,44 +1,171 @@
 package api
 
 import (
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
 	"errors"
+	"fmt"
 	"github.com/axellelanca/urlshortener/cmd"
+	"github.com/axellelanca/urlshortener/internal/botdetect"
+	"github.com/axellelanca/urlshortener/internal/health"
+	"github.com/axellelanca/urlshortener/internal/metrics"
 	"github.com/axellelanca/urlshortener/internal/models"
 	"github.com/axellelanca/urlshortener/internal/services"
+	"github.com/axellelanca/urlshortener/internal/spool"
+	"github.com/axellelanca/urlshortener/internal/urlpolicy"
+	"github.com/axellelanca/urlshortener/internal/useragent"
 	"github.com/gin-gonic/gin"
 	"gorm.io/gorm"
 	"log"
 	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
 	"time"
 )
 
 // ClickEventsChannel est le channel global (ou injecté) utilisé pour envoyer les événements de clic
 var ClickEventsChannel chan *models.ClickEvent
 
+// ClickEventSpool est le spool sur disque des événements de clic, nil s'il est désactivé.
+// Lorsqu'il est configuré, chaque événement y est écrit avant d'entrer dans ClickEventsChannel.
+var ClickEventSpool *spool.Spool
+
+// BotDetector identifie les requêtes des robots, dont les redirections ne consomment pas le quota de clics
+// des liens limités. nil : aucune requête n'est considérée comme venant d'un robot.
+var BotDetector *botdetect.Detector
+
+// clickEventsMu protège les envois des redirections dans ClickEventsChannel contre sa fermeture à l'arrêt.
+var clickEventsMu sync.RWMutex
+
+// CloseClickEventsChannel ferme ClickEventsChannel pour que les workers terminent leurs derniers lots.
+// Les redirections encore en cours n'y écrivent plus : leur événement reste dans le spool s'il est activé,
+// il est perdu sinon.
+func CloseClickEventsChannel() {
+	clickEventsMu.Lock()
+	defer clickEventsMu.Unlock()
+	if ClickEventsChannel != nil {
+		close(ClickEventsChannel)
+		ClickEventsChannel = nil
+	}
+}
+
+// sendClickEvent envoie un événement dans ClickEventsChannel sans bloquer et indique s'il a été accepté.
+func sendClickEvent(event *models.ClickEvent) bool {
+	clickEventsMu.RLock()
+	defer clickEventsMu.RUnlock()
+	// Un channel nil (fermé à l'arrêt) n'est jamais prêt : le default est choisi
+	select {
+	case ClickEventsChannel <- event:
+		return true
+	default:
+		return false
+	}
+}
+
+// NewRouter crée le routeur Gin du service. Seuls les proxys de trustedProxies (adresses ou plages CIDR)
+// peuvent fixer l'adresse du client via X-Forwarded-For ou X-Real-IP : sans eux, c.ClientIP() est l'adresse
+// de la connexion. Gin fait par défaut confiance à tous les proxys, ce qui laisserait n'importe quel client
+// choisir l'adresse utilisée par la limitation de débit, la géolocalisation et la détection des robots.
+func NewRouter(trustedProxies []string) (*gin.Engine, error) {
+	router := gin.Default()
+	if len(trustedProxies) == 0 {
+		trustedProxies = nil
+	}
+	if err := router.SetTrustedProxies(trustedProxies); err != nil {
+		return nil, err
+	}
+	return router, nil
+}
+
 // SetupRoutes configure toutes les routes de l'API Gin et injecte les dépendances nécessaires
-func SetupRoutes(router *gin.Engine, linkService *services.LinkService) {
+// Les routes /links exigent une clé API, la santé et la redirection restent publiques.
+func SetupRoutes(router *gin.Engine, linkService *services.LinkService, clickService *services.ClickService, apiKeyService *services.APIKeyService, healthChecker *health.Checker, limiters RateLimiters) {
 	if ClickEventsChannel == nil {
 		ClickEventsChannel = make(chan *models.ClickEvent, cmd.Cfg.Analytics.BufferSize)
 	}
+	metrics.ObserveClickQueue(ClickEventsChannel)
 
 	v1 := router.Group("/api/v1")
-	v1.GET("/health", HealthCheckHandler)
-	v1.POST("/links", CreateShortLinkHandler(linkService))
-	v1.GET("/links/:shortCode/stats", GetLinkStatsHandler(linkService))
+	v1.GET("/health", LivenessHandler) // Alias historique de /health/live, qui ne vérifiait pas les dépendances
+	v1.GET("/health/live", LivenessHandler)
+	v1.GET("/health/ready", ReadinessHandler(healthChecker))
+
+	// La limite par adresse IP précède l'authentification : elle borne aussi les essais de clés API
+	links := v1.Group("/links", RateLimit(limiters.API), AuthMiddleware(apiKeyService))
+	links.POST("", RateLimit(limiters.Create), CreateShortLinkHandler(linkService))
+	links.GET("", ListLinksHandler(linkService))
+
+	// Routes propres à un lien : réservées à la clé API qui l'a créé
+	owned := links.Group("/:shortCode", RequireLinkOwner(linkService))
+	owned.GET("", GetLinkHandler(linkService))
+	owned.PATCH("", UpdateLinkHandler(linkService))
+	owned.DELETE("", DeleteLinkHandler(linkService))
+	owned.POST("/restore", RestoreLinkHandler(linkService))
+	owned.GET("/audit", GetLinkAuditHandler(linkService))
 
-	router.GET("/:shortCode", RedirectHandler(linkService))
+	stats := owned.Group("/stats", RateLimit(limiters.Stats))
+	stats.GET("", GetLinkStatsHandler(linkService, clickService))
+	stats.GET("/timeseries", GetLinkTimeSeriesHandler(linkService, clickService))
+	stats.GET("/breakdown", GetLinkBreakdownHandler(linkService, clickService))
+	stats.GET("/geo", GetLinkGeoHandler(linkService, clickService))
+	stats.GET("/referrers", GetLinkReferrersHandler(linkService, clickService))
+
+	router.GET("/:shortCode", RedirectMetrics(), RateLimit(limiters.Redirect), RedirectHandler(linkService))
+	router.POST("/:shortCode", RedirectMetrics(), RateLimit(limiters.Redirect), UnlockHandler(linkService, limiters))
+}
+
+// SetupMetricsRoutes expose les métriques au format Prometheus sur GET /metrics ("metrics" est un alias réservé).
+// Si token n'est pas vide, les requêtes doivent le présenter dans l'en-tête "Authorization: Bearer <jeton>".
+func SetupMetricsRoutes(router *gin.Engine, token string) {
+	router.GET("/metrics", RequireBearerToken(token), gin.WrapH(metrics.Handler()))
+}
+
+// NewMetricsRouter crée le routeur du listener dédié aux métriques, qui ne sert que GET /metrics.
+func NewMetricsRouter(token string) *gin.Engine {
+	router := gin.New()
+	router.Use(gin.Recovery())
+	SetupMetricsRoutes(router, token)
+	return router
 }
 
-// HealthCheckHandler gère la route /health pour vérifier l'état du service.
-func HealthCheckHandler(c *gin.Context) {
+// LivenessHandler gère la route /health/live : le processus répond, sans vérifier ses dépendances.
+// Un échec signifie que l'instance doit être redémarrée.
+func LivenessHandler(c *gin.Context) {
 	c.JSON(http.StatusOK, gin.H{
 		"status": "ok",
 	})
 }
 
+// ReadinessHandler gère la route /health/ready : elle vérifie la base de données, le channel des clics,
+// les workers et le moniteur, et répond 503 si l'instance ne doit plus recevoir de trafic.
+func ReadinessHandler(checker *health.Checker) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		report := checker.Check(c.Request.Context())
+		status := http.StatusOK
+		if !report.Ready() {
+			status = http.StatusServiceUnavailable
+		}
+		c.JSON(status, report)
+	}
+}
+
 // CreateLinkRequest représente le corps de la requête JSON pour la création d'un lien.
 type CreateLinkRequest struct {
 	LongURL string `json:"long_url" binding:"required,url"` // 'binding:required' pour validation, 'url' pour format URL
+	Alias   string `json:"alias"`                           // Optionnel : code court personnalisé (ex: "spring-sale")
+	// Optionnel : date d'expiration au format RFC 3339 (ex: "2025-12-31T23:59:59Z")
+	ExpiresAt *time.Time `json:"expires_at"`
+	// Optionnel : nombre maximal de redirections, 0 pour illimité
+	MaxClicks int `json:"max_clicks" binding:"min=0"`
+	// Optionnel : mot de passe demandé avant la redirection
+	Password string `json:"password"`
+	// Optionnel : affiche toujours la page d'aperçu avant la redirection
+	AlwaysPreview bool `json:"always_preview"`
+	// Optionnel : code HTTP de redirection (301, 302, 307 ou 308), celui du serveur par défaut
+	RedirectType int `json:"redirect_type"`
 }
 
 // CreateShortLinkHandler gère la création d'une URL courte.
@@ -50,84 +177,615 @@ func CreateShortLinkHandler(linkService *services.LinkService) gin.HandlerFunc {
 			return
 		}
 
-		link, err := linkService.CreateLink(req.LongURL)
+		link, err := linkService.CreateLink(services.CreateLinkInput{
+			LongURL:       req.LongURL,
+			Alias:         req.Alias,
+			ExpiresAt:     req.ExpiresAt,
+			MaxClicks:     req.MaxClicks,
+			OwnerID:       ownerID(c),
+			Password:      req.Password,
+			AlwaysPreview: req.AlwaysPreview,
+			RedirectType:  req.RedirectType,
+		}, requestActor(c))
 		if err != nil {
+			switch {
+			case errors.Is(err, services.ErrURLRejected):
+				respondURLRejected(c, err)
+				return
+			case errors.Is(err, services.ErrInvalidAlias), errors.Is(err, services.ErrReservedAlias),
+				errors.Is(err, services.ErrInvalidExpiration), errors.Is(err, services.ErrInvalidPassword),
+				errors.Is(err, services.ErrInvalidRedirectType):
+				c.JSON(http.StatusBadRequest, gin.H{"error": errorMessage(err)})
+				return
+			case errors.Is(err, services.ErrAliasTaken):
+				c.JSON(http.StatusConflict, gin.H{"error": "Cet alias est déjà utilisé"})
+				return
+			}
 			log.Printf("[Handlers::CreateLink] Erreur lors de la création du lien : %v", err)
 			c.JSON(http.StatusInternalServerError, gin.H{"error": "Erreur Serveur"})
 			return
 		}
 
-		c.JSON(http.StatusCreated, gin.H{
-			"short_code":     link.ShortCode,
-			"long_url":       link.LongURL,
-			"full_short_url": cmd.Cfg.Server.BaseURL + "/" + link.ShortCode,
+		c.JSON(http.StatusCreated, linkResponse(link))
+	}
+}
+
+// linkResponse construit la représentation JSON d'un lien commune à toutes les routes /links.
+func linkResponse(link *models.Link) gin.H {
+	return gin.H{
+		"short_code":     link.ShortCode,
+		"long_url":       link.LongURL,
+		"full_short_url": cmd.Cfg.Server.BaseURL + "/" + link.ShortCode,
+		"created_at":     link.CreatedAt,
+		"expires_at":     link.ExpiresAt,
+		"expired":        link.IsExpired(time.Now()),
+		"max_clicks":     link.MaxClicks,
+		"clicks_left":    link.ClicksLeft(),
+		// Le hash n'est jamais exposé, seulement la présence d'une protection
+		"password_protected": link.IsProtected(),
+		"always_preview":     link.AlwaysPreview,
+		// Code enregistré (0 : celui du serveur) et code réellement utilisé par la redirection
+		"redirect_type":           link.RedirectType,
+		"effective_redirect_type": redirectStatus(link),
+	}
+}
+
+// ListLinksRequest représente les paramètres de requête acceptés par GET /links.
+type ListLinksRequest struct {
+	Page     int    `form:"page"`
+	PageSize int    `form:"page_size"`
+	Search   string `form:"q"`      // Recherche partielle sur le code court ou l'URL longue
+	Status   string `form:"status"` // "active" ou "expired"
+	Sort     string `form:"sort"`   // ex: "created_at" ou "-created_at" pour un tri décroissant
+}
+
+// ListLinksHandler gère la liste paginée, filtrable et triable des liens.
+func ListLinksHandler(linkService *services.LinkService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var req ListLinksRequest
+		if err := c.ShouldBindQuery(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Paramètres de requête invalides"})
+			return
+		}
+
+		links, total, err := linkService.ListLinks(services.ListLinksInput{
+			Page:     req.Page,
+			PageSize: req.PageSize,
+			Search:   req.Search,
+			Status:   req.Status,
+			Sort:     req.Sort,
+			OwnerID:  ownerID(c),
+		})
+		if err != nil {
+			if errors.Is(err, services.ErrInvalidListQuery) {
+				c.JSON(http.StatusBadRequest, gin.H{"error": errorMessage(err)})
+				return
+			}
+			log.Printf("[Handlers::ListLinksHandler] Erreur lors de la récupération des liens : %v", err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Erreur serveur"})
+			return
+		}
+
+		items := make([]gin.H, 0, len(links))
+		for i := range links {
+			items = append(items, linkResponse(&links[i]))
+		}
+
+		page, pageSize := req.Page, req.PageSize
+		if page == 0 {
+			page = 1
+		}
+		if pageSize == 0 {
+			pageSize = services.DefaultPageSize
+		}
+		c.JSON(http.StatusOK, gin.H{
+			"links":     items,
+			"page":      page,
+			"page_size": pageSize,
+			"total":     total,
 		})
 	}
 }
 
+// GetLinkHandler gère la récupération des informations d'un lien sans déclencher de redirection.
+func GetLinkHandler(linkService *services.LinkService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		link, err := linkService.GetLinkByShortCode(c.Param("shortCode"))
+		if err != nil {
+			respondLinkError(c, "GetLinkHandler", err)
+			return
+		}
+		c.JSON(http.StatusOK, linkResponse(link))
+	}
+}
+
+// UpdateLinkRequest représente le corps JSON de PATCH /links/:shortCode.
+// Seuls les champs présents sont modifiés.
+type UpdateLinkRequest struct {
+	LongURL   *string    `json:"long_url" binding:"omitempty,url"`
+	ExpiresAt *time.Time `json:"expires_at"`
+	MaxClicks *int       `json:"max_clicks" binding:"omitempty,min=0"`
+	Password  *string    `json:"password"` // Chaîne vide pour retirer la protection
+	// Active ou désactive la page d'aperçu systématique
+	AlwaysPreview *bool `json:"always_preview"`
+	// Code HTTP de redirection, 0 pour revenir à celui du serveur
+	RedirectType *int `json:"redirect_type"`
+}
+
+// UpdateLinkHandler gère la modification de la destination ou de l'expiration d'un lien.
+func UpdateLinkHandler(linkService *services.LinkService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var req UpdateLinkRequest
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Requête invalide ou URL incorrecte"})
+			return
+		}
+
+		link, err := linkService.UpdateLink(c.Param("shortCode"), services.UpdateLinkInput{
+			LongURL:       req.LongURL,
+			ExpiresAt:     req.ExpiresAt,
+			MaxClicks:     req.MaxClicks,
+			Password:      req.Password,
+			AlwaysPreview: req.AlwaysPreview,
+			RedirectType:  req.RedirectType,
+		}, requestActor(c))
+		if err != nil {
+			if errors.Is(err, services.ErrInvalidExpiration) || errors.Is(err, services.ErrInvalidPassword) ||
+				errors.Is(err, services.ErrInvalidRedirectType) {
+				c.JSON(http.StatusBadRequest, gin.H{"error": errorMessage(err)})
+				return
+			}
+			if errors.Is(err, services.ErrURLRejected) {
+				respondURLRejected(c, err)
+				return
+			}
+			respondLinkError(c, "UpdateLinkHandler", err)
+			return
+		}
+		c.JSON(http.StatusOK, linkResponse(link))
+	}
+}
+
+// DeleteLinkHandler gère la suppression d'un lien.
+func DeleteLinkHandler(linkService *services.LinkService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if err := linkService.DeleteLink(c.Param("shortCode"), requestActor(c)); err != nil {
+			respondLinkError(c, "DeleteLinkHandler", err)
+			return
+		}
+		c.Status(http.StatusNoContent)
+	}
+}
+
+// RestoreLinkHandler gère la restauration d'un lien supprimé.
+func RestoreLinkHandler(linkService *services.LinkService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		link, err := linkService.RestoreLink(c.Param("shortCode"), requestActor(c))
+		if err != nil {
+			if errors.Is(err, services.ErrLinkNotDeleted) {
+				c.JSON(http.StatusConflict, gin.H{"error": "Ce lien n'est pas supprimé"})
+				return
+			}
+			respondLinkError(c, "RestoreLinkHandler", err)
+			return
+		}
+		c.JSON(http.StatusOK, linkResponse(link))
+	}
+}
+
+// GetLinkAuditHandler gère la récupération du journal d'audit d'un lien, y compris supprimé.
+func GetLinkAuditHandler(linkService *services.LinkService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		entries, err := linkService.GetAuditLog(c.Param("shortCode"))
+		if err != nil {
+			respondLinkError(c, "GetLinkAuditHandler", err)
+			return
+		}
+
+		items := make([]gin.H, 0, len(entries))
+		for _, entry := range entries {
+			items = append(items, gin.H{
+				"action":     entry.Action,
+				"actor":      entry.Actor,
+				"created_at": entry.CreatedAt,
+				"before":     rawJSON(entry.Before),
+				"after":      rawJSON(entry.After),
+			})
+		}
+		c.JSON(http.StatusOK, gin.H{"short_code": c.Param("shortCode"), "entries": items})
+	}
+}
+
+// rawJSON permet d'inclure tel quel un instantané JSON stocké en base (nil s'il est vide).
+func rawJSON(snapshot string) any {
+	if snapshot == "" {
+		return nil
+	}
+	return json.RawMessage(snapshot)
+}
+
+// requestActor identifie l'auteur d'une requête API pour le journal d'audit.
+func requestActor(c *gin.Context) string {
+	if key := currentAPIKey(c); key != nil {
+		return fmt.Sprintf("apikey:%s#%d", key.Name, key.ID)
+	}
+	return "api:" + c.ClientIP()
+}
+
+// ownerID retourne l'identifiant de la clé API authentifiée, utilisé comme propriétaire des liens.
+func ownerID(c *gin.Context) *uint {
+	if key := currentAPIKey(c); key != nil {
+		return &key.ID
+	}
+	return nil
+}
+
+// respondLinkError traduit les erreurs de récupération d'un lien en réponse HTTP (404, 410 ou 500).
+func respondLinkError(c *gin.Context, handler string, err error) {
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Lien non trouvé"})
+		return
+	}
+	if errors.Is(err, services.ErrLinkDeleted) {
+		c.JSON(http.StatusGone, gin.H{"error": "Ce lien a été supprimé"})
+		return
+	}
+	log.Printf("[Handlers::%s] Erreur lors du traitement du lien : %v", handler, err)
+	c.JSON(http.StatusInternalServerError, gin.H{"error": "Erreur serveur"})
+}
+
+// errorMessage retire le préfixe de contexte "[Service::...]" d'une erreur métier
+// pour n'exposer au client que la partie lisible du message.
+func errorMessage(err error) string {
+	msg := err.Error()
+	if strings.HasPrefix(msg, "[") {
+		if idx := strings.Index(msg, "] "); idx != -1 {
+			return msg[idx+2:]
+		}
+	}
+	return msg
+}
+
+// respondURLRejected répond 400 à une URL de destination refusée par la politique,
+// avec le code stable du refus lorsqu'il est connu.
+func respondURLRejected(c *gin.Context, err error) {
+	var violation *urlpolicy.Violation
+	if errors.As(err, &violation) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "URL refusée : " + violation.Reason, "code": violation.Code})
+		return
+	}
+	c.JSON(http.StatusBadRequest, gin.H{"error": errorMessage(err)})
+}
+
 // RedirectHandler gère la redirection d'une URL courte vers l'URL longue et l'enregistrement asynchrone des clics.
+// Avec le suffixe "+" (/:shortCode+) ou ?preview=1, il affiche la page d'aperçu du lien sans rediriger ;
+// les liens marqués AlwaysPreview l'affichent tant que le visiteur ne l'a pas validée (?continue=1).
+// Pour un lien protégé, il affiche le formulaire de mot de passe traité par UnlockHandler.
 func RedirectHandler(linkService *services.LinkService) gin.HandlerFunc {
 	return func(c *gin.Context) {
-		shortCode := c.Param("shortCode")
+		shortCode, preview := strings.CutSuffix(c.Param("shortCode"), "+")
+		if preview || queryBool(c, "preview") {
+			renderPreviewPage(c, linkService, shortCode)
+			return
+		}
 
 		// TODO 2: Récupérer l'URL longue associée au shortCode depuis le linkService (GetLinkByShortCode)
-		link, err := linkService.GetLinkByShortCode(shortCode)
+		// ResolveLink vérifie en plus l'expiration et consomme le quota de clics du lien, sauf pour un robot.
+		link, err := linkService.ResolveLink(shortCode, queryBool(c, "continue"), isBotRequest(c))
 		if err != nil {
-			if errors.Is(err, gorm.ErrRecordNotFound) {
-				// Si le lien n'est pas trouvé, retourner HTTP 404 Not Found.
-				// Utiliser errors.Is et l'erreur Gorm
-				c.JSON(http.StatusNotFound, gin.H{"error": "Lien non trouvé"})
+			if errors.Is(err, services.ErrPreviewRequired) {
+				renderPreviewPage(c, linkService, shortCode)
 				return
 			}
-			// Gérer d'autres erreurs potentielles de la base de données ou du service
-			log.Printf("Error retrieving link for %s: %v", shortCode, err)
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "Internal server error"})
-			return
-		}
-		// TODO 3: Créer un ClickEvent avec les informations pertinentes.
-		clickEvent := &models.ClickEvent{
-			LinkID:    link.ID,
-			ShortCode: link.ShortCode,
-			Timestamp: time.Now(),
-			UserAgent: c.Request.UserAgent(),
-			IPAddress: c.ClientIP(),
-		}
-
-		// TODO 4: Envoyer le ClickEvent dans le ClickEventsChannel avec le Multiplexage.
-		// Utilise un `select` avec un `default` pour éviter de bloquer si le channel est plein.
-		// Pour le default, juste un message à afficher :
-		// log.Printf("Warning: ClickEventsChannel is full, dropping click event for %s.", shortCode)
-		select {
-		case ClickEventsChannel <- clickEvent:
+			if errors.Is(err, services.ErrPasswordRequired) {
+				renderUnlockPage(c, http.StatusOK, shortCode, "")
+				return
+			}
+			respondRedirectError(c, shortCode, err)
+			return
+		}
+
+		recordClick(c, link)
+		c.Redirect(redirectStatus(link), link.LongURL)
+	}
+}
+
+// isBotRequest indique si la requête provient d'un robot selon BotDetector. Les workers refont la même
+// détection pour marquer le clic enregistré.
+func isBotRequest(c *gin.Context) bool {
+	if BotDetector == nil {
+		return false
+	}
+	userAgent := c.Request.UserAgent()
+	return BotDetector.IsBot(userAgent, useragent.Parse(userAgent), c.ClientIP())
+}
+
+// redirectStatus retourne le code HTTP de redirection d'un lien : le sien s'il en a un,
+// sinon celui de la configuration du serveur (302 par défaut), jamais permanent pour un lien restreint.
+func redirectStatus(link *models.Link) int {
+	return services.EffectiveRedirectType(link, cmd.Cfg.Server.DefaultRedirectType)
+}
+
+// respondRedirectError répond à une erreur de résolution d'un lien lors d'une redirection.
+func respondRedirectError(c *gin.Context, shortCode string, err error) {
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		// Si le lien n'est pas trouvé, retourner HTTP 404 Not Found.
+		// Utiliser errors.Is et l'erreur Gorm
+		c.JSON(http.StatusNotFound, gin.H{"error": "Lien non trouvé"})
+		return
+	}
+	if errors.Is(err, services.ErrLinkExpired) {
+		c.JSON(http.StatusGone, gin.H{"error": "Ce lien a expiré"})
+		return
+	}
+	if errors.Is(err, services.ErrLinkDeleted) {
+		c.JSON(http.StatusGone, gin.H{"error": "Ce lien a été supprimé"})
+		return
+	}
+	// Gérer d'autres erreurs potentielles de la base de données ou du service
+	log.Printf("Error retrieving link for %s: %v", shortCode, err)
+	c.JSON(http.StatusInternalServerError, gin.H{"error": "Internal server error"})
+}
+
+// recordClick transmet de façon asynchrone l'événement de clic d'une redirection aux workers.
+func recordClick(c *gin.Context, link *models.Link) {
+	shortCode := link.ShortCode
+
+	// TODO 3: Créer un ClickEvent avec les informations pertinentes.
+	clickEvent := &models.ClickEvent{
+		EventID:   newEventID(),
+		LinkID:    link.ID,
+		ShortCode: link.ShortCode,
+		Timestamp: time.Now(),
+		UserAgent: c.Request.UserAgent(),
+		IPAddress: c.ClientIP(),
+		Referrer:  c.Request.Referer(),
+	}
+
+	// TODO 4: Envoyer le ClickEvent dans le ClickEventsChannel avec le Multiplexage.
+	// Utilise un `select` avec un `default` pour éviter de bloquer si le channel est plein.
+	// Pour le default, juste un message à afficher :
+	// log.Printf("Warning: ClickEventsChannel is full, dropping click event for %s.", shortCode)
+	// Avec le spool, l'événement est écrit sur disque avant d'entrer dans le channel et n'est acquitté
+	// qu'une fois persisté : si le channel est plein, il y est transmis dès qu'il se libère.
+	if ClickEventSpool != nil {
+		sent, err := ClickEventSpool.Append(clickEvent, sendClickEvent)
+		if err == nil {
+			if sent {
+				log.Printf("Click event for %s sent to channel.", shortCode)
+			} else {
+				log.Printf("ClickEventsChannel is full, click event for %s kept in spool.", shortCode)
+			}
+			return
+		}
+		// Spool indisponible (disque plein...) : l'événement est transmis sans garantie de durabilité
+		log.Printf("Warning: failed to spool click event for %s: %v", shortCode, err)
+		if sendClickEvent(clickEvent) {
 			log.Printf("Click event for %s sent to channel.", shortCode)
-		default:
-			log.Printf("Warning: ClickEventsChannel is full, dropping click event for %s.", shortCode)
+			return
 		}
+		log.Printf("Warning: ClickEventsChannel is full, dropping click event for %s.", shortCode)
+		metrics.ClickEventsDroppedTotal.WithLabelValues(metrics.DropSpoolError).Inc()
+		return
+	}
+	if sendClickEvent(clickEvent) {
+		log.Printf("Click event for %s sent to channel.", shortCode)
+		return
+	}
+	log.Printf("Warning: ClickEventsChannel is full, dropping click event for %s.", shortCode)
+	metrics.ClickEventsDroppedTotal.WithLabelValues(metrics.DropQueueFull).Inc()
+}
 
-		c.Redirect(http.StatusFound, link.LongURL)
+// newEventID génère l'identifiant aléatoire d'un événement de clic (128 bits en hexadécimal).
+func newEventID() string {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		// Sans identifiant, le clic est simplement enregistré sans protection contre les doublons
+		return ""
 	}
+	return hex.EncodeToString(b)
 }
 
 // GetLinkStatsHandler gère la récupération des statistiques pour un lien spécifique.
-func GetLinkStatsHandler(linkService *services.LinkService) gin.HandlerFunc {
+func GetLinkStatsHandler(linkService *services.LinkService, clickService *services.ClickService) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		shortCode := c.Param("shortCode")
 
-		link, totalClicks, err := linkService.GetLinkStats(shortCode)
+		link, totalClicks, err := linkService.GetLinkStats(shortCode, includeBots(c))
 		if err != nil {
 			if errors.Is(err, gorm.ErrRecordNotFound) {
 				c.JSON(http.StatusNotFound, gin.H{"error": "Lien non trouvé"})
 				return
 			}
+			if errors.Is(err, services.ErrLinkDeleted) {
+				c.JSON(http.StatusGone, gin.H{"error": "Ce lien a été supprimé"})
+				return
+			}
 			log.Printf("[Handlers::GetLinkStatsHandler] Erreur lors de la récupération des stats : %v", err)
 			c.JSON(http.StatusInternalServerError, gin.H{"error": "Erreur serveur"})
 			return
 		}
+		uniqueVisitors, err := clickService.GetUniqueVisitorsByLinkID(link.ID, includeBots(c))
+		if err != nil {
+			log.Printf("[Handlers::GetLinkStatsHandler] Erreur lors du comptage des visiteurs uniques : %v", err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Erreur serveur"})
+			return
+		}
+
+		c.JSON(http.StatusOK, gin.H{
+			"short_code":      link.ShortCode,
+			"long_url":        link.LongURL,
+			"total_clicks":    totalClicks,
+			"unique_visitors": uniqueVisitors,
+			"include_bots":    includeBots(c),
+			"expires_at":      link.ExpiresAt,
+			"expired":         link.IsExpired(time.Now()),
+			"max_clicks":      link.MaxClicks,
+			"clicks_left":     link.ClicksLeft(),
+			// Mots de passe erronés, comptés à part : ils ne donnent lieu à aucun clic
+			"password_protected": link.IsProtected(),
+			"unlock_failures":    link.UnlockFailures,
+		})
+	}
+}
+
+// TimeSeriesRequest représente les paramètres de requête de GET /links/:shortCode/stats/timeseries.
+type TimeSeriesRequest struct {
+	From     string `form:"from"`     // RFC 3339 ou AAAA-MM-JJ, par défaut selon la granularité
+	To       string `form:"to"`       // RFC 3339 ou AAAA-MM-JJ, par défaut maintenant
+	Interval string `form:"interval"` // hour, day (défaut), week ou month
+}
+
+// includeBots indique si la requête demande d'inclure les clics de robots (?include_bots=true).
+func includeBots(c *gin.Context) bool {
+	return queryBool(c, "include_bots")
+}
+
+// queryBool indique si le paramètre de requête name est un booléen vrai ("1", "true"...).
+func queryBool(c *gin.Context, name string) bool {
+	value, err := strconv.ParseBool(c.Query(name))
+	return err == nil && value
+}
+
+// GetLinkTimeSeriesHandler gère la récupération de l'évolution des clics d'un lien dans le temps.
+func GetLinkTimeSeriesHandler(linkService *services.LinkService, clickService *services.ClickService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var req TimeSeriesRequest
+		if err := c.ShouldBindQuery(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Paramètres de requête invalides"})
+			return
+		}
+		from, errFrom := services.ParseTimeParam(req.From)
+		to, errTo := services.ParseTimeParam(req.To)
+		if err := errors.Join(errFrom, errTo); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": errorMessage(err)})
+			return
+		}
+
+		link, err := linkService.GetLinkByShortCode(c.Param("shortCode"))
+		if err != nil {
+			respondLinkError(c, "GetLinkTimeSeriesHandler", err)
+			return
+		}
+
+		series, err := clickService.GetClickTimeSeries(link.ID, from, to, req.Interval, includeBots(c))
+		if err != nil {
+			if errors.Is(err, services.ErrInvalidTimeRange) {
+				c.JSON(http.StatusBadRequest, gin.H{"error": errorMessage(err)})
+				return
+			}
+			log.Printf("[Handlers::GetLinkTimeSeriesHandler] Erreur lors de la récupération de la série : %v", err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Erreur serveur"})
+			return
+		}
+
+		points := make([]gin.H, 0, len(series))
+		for _, bucket := range series {
+			points = append(points, gin.H{
+				"start":           bucket.Start,
+				"clicks":          bucket.Clicks,
+				"unique_visitors": bucket.UniqueVisitors,
+			})
+		}
+		interval := req.Interval
+		if interval == "" {
+			interval = models.IntervalDay
+		}
+		c.JSON(http.StatusOK, gin.H{
+			"short_code": link.ShortCode,
+			"interval":   interval,
+			"series":     points,
+		})
+	}
+}
+
+// GetLinkBreakdownHandler gère la répartition des clics d'un lien par navigateur, OS et type d'appareil.
+// Le paramètre optionnel "by" (browser, os ou device) limite la réponse à une seule dimension.
+func GetLinkBreakdownHandler(linkService *services.LinkService, clickService *services.ClickService) gin.HandlerFunc {
+	return breakdownHandler("GetLinkBreakdownHandler", linkService, clickService,
+		services.DimensionBrowser, services.DimensionOS, services.DimensionDevice)
+}
+
+// GetLinkGeoHandler gère la répartition géographique des clics d'un lien par pays, région et ville.
+// Le paramètre optionnel "by" (country, region ou city) limite la réponse à une seule dimension.
+func GetLinkGeoHandler(linkService *services.LinkService, clickService *services.ClickService) gin.HandlerFunc {
+	return breakdownHandler("GetLinkGeoHandler", linkService, clickService,
+		services.DimensionCountry, services.DimensionRegion, services.DimensionCity)
+}
+
+// breakdownHandler construit un handler qui répond la répartition des clics d'un lien selon les dimensions données,
+// ou selon la seule dimension demandée par le paramètre "by" si elle fait partie de la liste.
+func breakdownHandler(handler string, linkService *services.LinkService, clickService *services.ClickService, dimensions ...string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		requested := dimensions
+		if by := c.Query("by"); by != "" {
+			if !slices.Contains(dimensions, by) {
+				c.JSON(http.StatusBadRequest, gin.H{
+					"error": fmt.Sprintf("%s: '%s' (%s)", services.ErrInvalidDimension, by, strings.Join(dimensions, ", ")),
+				})
+				return
+			}
+			requested = []string{by}
+		}
+
+		link, err := linkService.GetLinkByShortCode(c.Param("shortCode"))
+		if err != nil {
+			respondLinkError(c, handler, err)
+			return
+		}
 
+		response := gin.H{"short_code": link.ShortCode}
+		for _, dimension := range requested {
+			counts, err := clickService.GetClickBreakdown(link.ID, dimension, includeBots(c))
+			if err != nil {
+				log.Printf("[Handlers::%s] Erreur lors de la répartition des clics : %v", handler, err)
+				c.JSON(http.StatusInternalServerError, gin.H{"error": "Erreur serveur"})
+				return
+			}
+			response[dimension] = dimensionCountsResponse(counts)
+		}
+		c.JSON(http.StatusOK, response)
+	}
+}
+
+// ReferrersRequest représente les paramètres de requête de GET /links/:shortCode/stats/referrers.
+type ReferrersRequest struct {
+	Limit int `form:"limit" binding:"omitempty,min=1"` // Nombre de sources retournées, 10 par défaut et 100 au plus
+}
+
+// GetLinkReferrersHandler gère le classement des domaines de provenance des clics d'un lien.
+func GetLinkReferrersHandler(linkService *services.LinkService, clickService *services.ClickService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var req ReferrersRequest
+		if err := c.ShouldBindQuery(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Paramètres de requête invalides"})
+			return
+		}
+
+		link, err := linkService.GetLinkByShortCode(c.Param("shortCode"))
+		if err != nil {
+			respondLinkError(c, "GetLinkReferrersHandler", err)
+			return
+		}
+
+		counts, err := clickService.GetTopReferrers(link.ID, req.Limit, includeBots(c))
+		if err != nil {
+			log.Printf("[Handlers::GetLinkReferrersHandler] Erreur lors du classement des référents : %v", err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Erreur serveur"})
+			return
+		}
 		c.JSON(http.StatusOK, gin.H{
-			"short_code":   link.ShortCode,
-			"long_url":     link.LongURL,
-			"total_clicks": totalClicks,
+			"short_code": link.ShortCode,
+			"referrers":  dimensionCountsResponse(counts),
 		})
 	}
 }
+
+// dimensionCountsResponse construit la représentation JSON d'une répartition de clics.
+func dimensionCountsResponse(counts []models.DimensionCount) []gin.H {
+	items := make([]gin.H, 0, len(counts))
+	for _, count := range counts {
+		items = append(items, gin.H{"value": count.Value, "clicks": count.Clicks})
+	}
+	return items
+}
@@ -0,0 +1,158 @@
+package api
+
+import (
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/axellelanca/urlshortener/internal/botdetect"
+	"github.com/axellelanca/urlshortener/internal/models"
+	"github.com/axellelanca/urlshortener/internal/services"
+	"github.com/axellelanca/urlshortener/internal/spool"
+)
+
+// openTestSpool ouvre un spool dans dir comme ClickEventSpool.
+func openTestSpool(t *testing.T, dir string) *spool.Spool {
+	t.Helper()
+	clickSpool, err := spool.Open(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ClickEventSpool = clickSpool
+	t.Cleanup(func() {
+		ClickEventSpool = nil
+		clickSpool.Close()
+	})
+	return clickSpool
+}
+
+// spooledEvents relit les événements ajoutés au spool.
+func spooledEvents(t *testing.T, clickSpool *spool.Spool, want int) []*models.ClickEvent {
+	t.Helper()
+	out := make(chan *models.ClickEvent, want+1)
+	go clickSpool.Feed(out)
+	t.Cleanup(clickSpool.StopFeeding)
+	var events []*models.ClickEvent
+	timeout := time.After(2 * time.Second)
+	for len(events) < want {
+		select {
+		case event := <-out:
+			events = append(events, event)
+		case <-timeout:
+			t.Fatalf("%d événement(s) relu(s) dans le spool, attendu %d", len(events), want)
+		}
+	}
+	select {
+	case event := <-out:
+		t.Fatalf("événement inattendu dans le spool : %+v", event)
+	case <-time.After(50 * time.Millisecond):
+	}
+	return events
+}
+
+func TestRecordClickWritesEveryEventToSpoolFirst(t *testing.T) {
+	env := newTestEnv(t, RateLimiters{})
+	env.clicks = make(chan *models.ClickEvent, 2)
+	ClickEventsChannel = env.clicks
+	dir := t.TempDir()
+	clickSpool := openTestSpool(t, dir)
+	env.createLink(t, services.CreateLinkInput{LongURL: "https://example.com/sale", Alias: "spring-sale"})
+
+	for i := 0; i < 3; i++ {
+		if w := env.do(http.MethodGet, "/spring-sale", "203.0.113.7:1000", nil, nil); w.Code != http.StatusFound {
+			t.Fatalf("redirection %d : statut %d, attendu 302", i+1, w.Code)
+		}
+	}
+
+	if env.pendingClicks() != 2 {
+		t.Fatalf("%d événement(s) dans le channel, attendu 2", env.pendingClicks())
+	}
+	direct := []*models.ClickEvent{<-env.clicks, <-env.clicks}
+	for _, event := range direct {
+		if event.SpoolSegment == 0 {
+			t.Fatal("un événement a été envoyé dans le channel sans être écrit dans le spool")
+		}
+	}
+	// Le lecteur ne transmet que l'événement que le channel plein n'a pas accepté
+	overflow := spooledEvents(t, clickSpool, 1)
+	for _, event := range direct {
+		if event.EventID == overflow[0].EventID {
+			t.Fatal("un événement envoyé dans le channel a été transmis une seconde fois")
+		}
+	}
+
+	// Aucun événement n'est acquitté : après un crash, les trois sont rejoués
+	clickSpool.StopFeeding()
+	restarted, err := spool.Open(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { restarted.Close() })
+	spooledEvents(t, restarted, 3)
+}
+
+func TestRecordClickAfterChannelClosed(t *testing.T) {
+	env := newTestEnv(t, RateLimiters{})
+	clickSpool := openTestSpool(t, t.TempDir())
+	env.createLink(t, services.CreateLinkInput{LongURL: "https://example.com/sale", Alias: "spring-sale"})
+
+	CloseClickEventsChannel()
+	// Une redirection tardive ne doit pas écrire dans le channel fermé
+	if w := env.do(http.MethodGet, "/spring-sale", "203.0.113.7:1000", nil, nil); w.Code != http.StatusFound {
+		t.Fatalf("redirection après la fermeture du channel : statut %d, attendu 302", w.Code)
+	}
+	if _, ok := <-env.clicks; ok {
+		t.Fatal("le channel fermé a reçu un événement")
+	}
+	spooledEvents(t, clickSpool, 1)
+}
+
+func TestBotRedirectsDoNotConsumeQuota(t *testing.T) {
+	env := newTestEnv(t, RateLimiters{})
+	detector, err := botdetect.NewDetector(true, nil, []string{"192.0.2.0/24"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	BotDetector = detector
+	t.Cleanup(func() { BotDetector = nil })
+
+	link := env.createLink(t, services.CreateLinkInput{LongURL: "https://example.com/", MaxClicks: 1})
+	bots := []struct {
+		remoteAddr string
+		userAgent  string
+	}{
+		{"198.51.100.1:1234", "Mozilla/5.0 (compatible; Googlebot/2.1; +http://www.google.com/bot.html)"},
+		{"198.51.100.1:1234", "Slackbot-LinkExpanding 1.0 (+https://api.slack.com/robots)"},
+		{"192.0.2.10:1234", "Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/126.0 Safari/537.36"},
+	}
+	for _, bot := range bots {
+		w := env.do(http.MethodGet, "/"+link.ShortCode, bot.remoteAddr, nil, map[string]string{"User-Agent": bot.userAgent})
+		if w.Code != http.StatusFound {
+			t.Fatalf("robot %q depuis %s : statut %d, attendu 302", bot.userAgent, bot.remoteAddr, w.Code)
+		}
+	}
+
+	browser := map[string]string{"User-Agent": "Mozilla/5.0 (X11; Linux x86_64; rv:128.0) Gecko/20100101 Firefox/128.0"}
+	if w := env.do(http.MethodGet, "/"+link.ShortCode, "198.51.100.1:1234", nil, browser); w.Code != http.StatusFound {
+		t.Fatalf("premier visiteur : statut %d, attendu 302", w.Code)
+	}
+	if w := env.do(http.MethodGet, "/"+link.ShortCode, "198.51.100.1:1234", nil, browser); w.Code != http.StatusGone {
+		t.Fatalf("quota épuisé : statut %d, attendu 410", w.Code)
+	}
+	// Les clics des robots restent enregistrés, marqués comme tels par les workers
+	if got := env.pendingClicks(); got != len(bots)+1 {
+		t.Fatalf("%d clic(s) transmis, attendu %d", got, len(bots)+1)
+	}
+}
+
+func TestLegacyHealthIsLiveness(t *testing.T) {
+	env := newTestEnv(t, RateLimiters{})
+
+	// Sans Checker, seule la vivacité peut répondre : l'alias ne doit pas vérifier les dépendances
+	for _, target := range []string{"/api/v1/health", "/api/v1/health/live"} {
+		w := env.do(http.MethodGet, target, "198.51.100.1:1234", nil, nil)
+		if w.Code != http.StatusOK || w.Body.String() != `{"status":"ok"}` {
+			t.Fatalf("%s : %d %s, attendu 200 {\"status\":\"ok\"}", target, w.Code, w.Body.String())
+		}
+	}
+}
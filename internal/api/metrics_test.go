@@ -0,0 +1,84 @@
+package api
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/axellelanca/urlshortener/internal/metrics"
+	"github.com/axellelanca/urlshortener/internal/services"
+	dto "github.com/prometheus/client_model/go"
+	"github.com/prometheus/common/expfmt"
+)
+
+// scrapeMetrics interroge metrics.Handler comme le ferait Prometheus et retourne les familles de métriques.
+func scrapeMetrics(t *testing.T) map[string]*dto.MetricFamily {
+	t.Helper()
+	w := httptest.NewRecorder()
+	metrics.Handler().ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	if w.Code != http.StatusOK {
+		t.Fatalf("GET /metrics : statut %d", w.Code)
+	}
+	var parser expfmt.TextParser
+	families, err := parser.TextToMetricFamilies(w.Body)
+	if err != nil {
+		t.Fatalf("lecture des métriques : %v", err)
+	}
+	return families
+}
+
+// findMetric retourne la série de la famille name portant le label status, ou nil.
+func findMetric(families map[string]*dto.MetricFamily, name, status string) *dto.Metric {
+	family, ok := families[name]
+	if !ok {
+		return nil
+	}
+	for _, metric := range family.GetMetric() {
+		for _, label := range metric.GetLabel() {
+			if label.GetName() == "status" && label.GetValue() == status {
+				return metric
+			}
+		}
+	}
+	return nil
+}
+
+func redirectCount(families map[string]*dto.MetricFamily, status string) float64 {
+	return findMetric(families, "urlshortener_redirects_total", status).GetCounter().GetValue()
+}
+
+func redirectSamples(families map[string]*dto.MetricFamily, status string) uint64 {
+	return findMetric(families, "urlshortener_redirect_duration_seconds", status).GetHistogram().GetSampleCount()
+}
+
+func TestRedirectMetricsAreExposed(t *testing.T) {
+	env := newTestEnv(t, RateLimiters{})
+	env.createLink(t, services.CreateLinkInput{LongURL: "https://example.com/sale", Alias: "spring-sale"})
+
+	// Les métriques sont globales au processus : le test compare les valeurs avant et après ses requêtes
+	before := scrapeMetrics(t)
+	if w := env.do(http.MethodGet, "/spring-sale", "203.0.113.7:1000", nil, nil); w.Code != http.StatusFound {
+		t.Fatalf("redirection : statut %d, attendu 302", w.Code)
+	}
+	if w := env.do(http.MethodGet, "/unknown-code", "203.0.113.7:1000", nil, nil); w.Code != http.StatusNotFound {
+		t.Fatalf("code inconnu : statut %d, attendu 404", w.Code)
+	}
+	after := scrapeMetrics(t)
+
+	for _, status := range []string{"302", "404"} {
+		if delta := redirectCount(after, status) - redirectCount(before, status); delta != 1 {
+			t.Errorf("urlshortener_redirects_total{status=%q} a augmenté de %v, attendu 1", status, delta)
+		}
+		if delta := redirectSamples(after, status) - redirectSamples(before, status); delta != 1 {
+			t.Errorf("urlshortener_redirect_duration_seconds{status=%q} : %d observation(s), attendu 1", status, delta)
+		}
+	}
+
+	histogram := findMetric(after, "urlshortener_redirect_duration_seconds", "302").GetHistogram()
+	if len(histogram.GetBucket()) == 0 || histogram.GetSampleSum() <= 0 {
+		t.Errorf("histogramme de latence sans buckets ou sans durée : %v", histogram)
+	}
+	if _, ok := after["urlshortener_click_queue_depth"]; !ok {
+		t.Error("la jauge urlshortener_click_queue_depth n'est pas exposée")
+	}
+}
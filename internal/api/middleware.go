@@ -0,0 +1,179 @@
+package api
+
+import (
+	"crypto/subtle"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/axellelanca/urlshortener/internal/metrics"
+	"github.com/axellelanca/urlshortener/internal/models"
+	"github.com/axellelanca/urlshortener/internal/ratelimit"
+	"github.com/axellelanca/urlshortener/internal/services"
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// apiKeyContextKey est la clé sous laquelle la clé API authentifiée est stockée dans le contexte Gin.
+const apiKeyContextKey = "apiKey"
+
+// AuthMiddleware authentifie les requêtes à l'aide d'une clé API transmise dans l'en-tête
+// "Authorization: Bearer <clé>" ou "X-API-Key: <clé>". Les requêtes sans clé valide reçoivent un 401.
+func AuthMiddleware(apiKeyService *services.APIKeyService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		rawKey := c.GetHeader("X-API-Key")
+		if rawKey == "" {
+			if auth := c.GetHeader("Authorization"); strings.HasPrefix(auth, "Bearer ") {
+				rawKey = strings.TrimSpace(strings.TrimPrefix(auth, "Bearer "))
+			}
+		}
+		if rawKey == "" {
+			c.Header("WWW-Authenticate", `Bearer realm="url-shortener"`)
+			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "Clé API manquante"})
+			return
+		}
+
+		key, err := apiKeyService.Authenticate(rawKey)
+		if err != nil {
+			if errors.Is(err, services.ErrInvalidAPIKey) || errors.Is(err, services.ErrAPIKeyRevoked) {
+				c.Header("WWW-Authenticate", `Bearer realm="url-shortener", error="invalid_token"`)
+				c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "Clé API invalide ou révoquée"})
+				return
+			}
+			log.Printf("[Middleware::Auth] Erreur lors de la vérification de la clé API : %v", err)
+			c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"error": "Erreur serveur"})
+			return
+		}
+
+		c.Set(apiKeyContextKey, key)
+		c.Next()
+	}
+}
+
+// RequireBearerToken réserve une route aux requêtes portant l'en-tête "Authorization: Bearer <token>".
+// Un token vide laisse passer toutes les requêtes.
+func RequireBearerToken(token string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if token == "" {
+			c.Next()
+			return
+		}
+		auth := c.GetHeader("Authorization")
+		if !strings.HasPrefix(auth, "Bearer ") ||
+			subtle.ConstantTimeCompare([]byte(strings.TrimSpace(strings.TrimPrefix(auth, "Bearer "))), []byte(token)) != 1 {
+			c.Header("WWW-Authenticate", `Bearer realm="url-shortener"`)
+			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "Jeton d'accès manquant ou invalide"})
+			return
+		}
+		c.Next()
+	}
+}
+
+// RequireLinkOwner limite l'accès aux routes /links/:shortCode au propriétaire du lien.
+// Il doit être placé après AuthMiddleware.
+func RequireLinkOwner(linkService *services.LinkService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		key := currentAPIKey(c)
+		if key == nil {
+			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "Clé API manquante"})
+			return
+		}
+
+		if err := linkService.CheckOwnership(c.Param("shortCode"), key.ID); err != nil {
+			switch {
+			case errors.Is(err, gorm.ErrRecordNotFound):
+				c.AbortWithStatusJSON(http.StatusNotFound, gin.H{"error": "Lien non trouvé"})
+			case errors.Is(err, services.ErrNotLinkOwner):
+				c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": "Accès refusé : ce lien appartient à une autre clé API"})
+			default:
+				log.Printf("[Middleware::RequireLinkOwner] Erreur lors de la vérification du propriétaire : %v", err)
+				c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"error": "Erreur serveur"})
+			}
+			return
+		}
+		c.Next()
+	}
+}
+
+// currentAPIKey retourne la clé API authentifiée pour la requête, ou nil.
+func currentAPIKey(c *gin.Context) *models.APIKey {
+	value, exists := c.Get(apiKeyContextKey)
+	if !exists {
+		return nil
+	}
+	key, _ := value.(*models.APIKey)
+	return key
+}
+
+// RedirectMetrics mesure le nombre et la latence des requêtes de redirection, par code de statut HTTP.
+func RedirectMetrics() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		start := time.Now()
+		c.Next()
+
+		status := strconv.Itoa(c.Writer.Status())
+		metrics.RedirectsTotal.WithLabelValues(status).Inc()
+		metrics.RedirectDuration.WithLabelValues(status).Observe(time.Since(start).Seconds())
+	}
+}
+
+// RateLimiters regroupe les limiteurs de débit appliqués par SetupRoutes. Un limiteur nil désactive la limite.
+type RateLimiters struct {
+	API        ratelimit.Limiter // /api/v1/links... par adresse IP, avant l'authentification
+	Create     ratelimit.Limiter // POST /api/v1/links
+	Stats      ratelimit.Limiter // /api/v1/links/:shortCode/stats...
+	Redirect   ratelimit.Limiter // GET /:shortCode
+	Unlock     ratelimit.Limiter // POST /:shortCode : mots de passe erronés, par client et par lien
+	UnlockLink ratelimit.Limiter // POST /:shortCode : mots de passe erronés, par lien tous clients confondus
+}
+
+// RateLimit limite le débit des requêtes par client : la clé API authentifiée si elle est connue
+// (le middleware doit alors être placé après AuthMiddleware), l'adresse IP sinon.
+// Les requêtes refusées reçoivent un 429 avec l'en-tête Retry-After. Si le limiteur est en erreur,
+// la requête est laissée passer : une panne du stockage partagé ne doit pas interrompre le service.
+func RateLimit(limiter ratelimit.Limiter) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if limiter == nil {
+			c.Next()
+			return
+		}
+
+		result, err := limiter.Allow(c.Request.Context(), clientKey(c))
+		if err != nil {
+			log.Printf("[Middleware::RateLimit] Limiteur indisponible, requête acceptée : %v", err)
+			c.Next()
+			return
+		}
+
+		c.Header("X-RateLimit-Limit", strconv.Itoa(result.Limit))
+		c.Header("X-RateLimit-Remaining", strconv.Itoa(result.Remaining))
+		c.Header("X-RateLimit-Reset", strconv.Itoa(ceilSeconds(result.ResetAfter)))
+		if !result.Allowed {
+			retryAfter := ceilSeconds(result.RetryAfter)
+			c.Header("Retry-After", strconv.Itoa(retryAfter))
+			c.AbortWithStatusJSON(http.StatusTooManyRequests, gin.H{
+				"error": fmt.Sprintf("Trop de requêtes, réessayez dans %d seconde(s)", retryAfter),
+			})
+			return
+		}
+		c.Next()
+	}
+}
+
+// clientKey identifie le client d'une requête pour la limitation de débit : sa clé API si elle est connue,
+// son adresse IP sinon.
+func clientKey(c *gin.Context) string {
+	if key := currentAPIKey(c); key != nil {
+		return "apikey:" + strconv.FormatUint(uint64(key.ID), 10)
+	}
+	return "ip:" + c.ClientIP()
+}
+
+// ceilSeconds arrondit une durée à la seconde supérieure.
+func ceilSeconds(d time.Duration) int {
+	return int((d + time.Second - 1) / time.Second)
+}
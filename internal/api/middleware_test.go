@@ -0,0 +1,128 @@
+package api
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/axellelanca/urlshortener/internal/ratelimit"
+	"github.com/axellelanca/urlshortener/internal/services"
+	"github.com/gin-gonic/gin"
+)
+
+func TestRateLimitIgnoresForgedForwardedFor(t *testing.T) {
+	env := newTestEnv(t, RateLimiters{Redirect: ratelimit.NewMemoryLimiter(60, 2)})
+	env.createLink(t, services.CreateLinkInput{LongURL: "https://example.com/", Alias: "xff"})
+
+	// Sans proxy de confiance, chaque X-Forwarded-For différent ne doit pas donner un nouveau seau
+	forged := []string{"1.1.1.1", "2.2.2.2", "3.3.3.3"}
+	var statuses []int
+	for _, ip := range forged {
+		w := env.do(http.MethodGet, "/xff", "203.0.113.7:4321", nil, map[string]string{"X-Forwarded-For": ip})
+		statuses = append(statuses, w.Code)
+	}
+	if statuses[0] != http.StatusFound || statuses[1] != http.StatusFound || statuses[2] != http.StatusTooManyRequests {
+		t.Fatalf("statuts = %v, attendu [302 302 429]", statuses)
+	}
+
+	// L'adresse enregistrée pour les clics est celle de la connexion
+	event := <-env.clicks
+	if event.IPAddress != "203.0.113.7" {
+		t.Errorf("IP du clic = %q, attendu 203.0.113.7", event.IPAddress)
+	}
+}
+
+func TestNewRouterTrustsConfiguredProxies(t *testing.T) {
+	router, err := NewRouter([]string{"10.0.0.0/8"})
+	if err != nil {
+		t.Fatalf("NewRouter : %v", err)
+	}
+	var clientIP string
+	router.GET("/ip", func(c *gin.Context) { clientIP = c.ClientIP() })
+
+	req := httptest.NewRequest(http.MethodGet, "/ip", nil)
+	req.RemoteAddr = "10.1.2.3:5555"
+	req.Header.Set("X-Forwarded-For", "198.51.100.9")
+	router.ServeHTTP(httptest.NewRecorder(), req)
+	if clientIP != "198.51.100.9" {
+		t.Errorf("ClientIP derrière un proxy de confiance = %q, attendu 198.51.100.9", clientIP)
+	}
+
+	if _, err := NewRouter([]string{"pas-une-ip"}); err == nil {
+		t.Error("NewRouter doit refuser une adresse de proxy invalide")
+	}
+}
+
+func TestAPIRateLimitAppliesBeforeAuthentication(t *testing.T) {
+	env := newTestEnv(t, RateLimiters{API: ratelimit.NewMemoryLimiter(60, 2)})
+	_, rawKey := env.createAPIKey(t, "client")
+
+	// Les essais de clés invalides sont décomptés par adresse IP, avant la vérification de la clé
+	var statuses []int
+	for _, key := range []string{"invalide-1", "invalide-2", "invalide-3"} {
+		w := env.do(http.MethodGet, "/api/v1/links", "203.0.113.7:4321", nil, map[string]string{"X-API-Key": key})
+		statuses = append(statuses, w.Code)
+	}
+	if statuses[0] != http.StatusUnauthorized || statuses[1] != http.StatusUnauthorized || statuses[2] != http.StatusTooManyRequests {
+		t.Fatalf("statuts = %v, attendu [401 401 429]", statuses)
+	}
+
+	// Une clé valide ne donne pas un nouveau seau à la même adresse
+	if w := env.do(http.MethodGet, "/api/v1/links", "203.0.113.7:4321", nil, map[string]string{"X-API-Key": rawKey}); w.Code != http.StatusTooManyRequests {
+		t.Fatalf("clé valide depuis l'adresse limitée : statut %d, attendu 429", w.Code)
+	}
+	if w := env.do(http.MethodGet, "/api/v1/links", "198.51.100.9:4321", nil, map[string]string{"X-API-Key": rawKey}); w.Code != http.StatusOK {
+		t.Fatalf("clé valide depuis une autre adresse : statut %d, attendu 200", w.Code)
+	}
+}
+
+func TestMetricsAreNotExposedOnMainRouterByDefault(t *testing.T) {
+	env := newTestEnv(t, RateLimiters{})
+	if w := env.do(http.MethodGet, "/metrics", "203.0.113.7:4321", nil, nil); w.Code != http.StatusNotFound {
+		t.Fatalf("GET /metrics sur le port principal : statut %d, attendu 404", w.Code)
+	}
+
+	// Exposées sur le port principal, les métriques exigent le jeton
+	SetupMetricsRoutes(env.router, "secret")
+	if w := env.do(http.MethodGet, "/metrics", "203.0.113.7:4321", nil, nil); w.Code != http.StatusUnauthorized {
+		t.Fatalf("GET /metrics sans jeton : statut %d, attendu 401", w.Code)
+	}
+	if w := env.do(http.MethodGet, "/metrics", "203.0.113.7:4321", nil, map[string]string{"Authorization": "Bearer secret"}); w.Code != http.StatusOK {
+		t.Fatalf("GET /metrics avec le jeton : statut %d, attendu 200", w.Code)
+	}
+}
+
+func TestMetricsRouter(t *testing.T) {
+	tests := []struct {
+		name          string
+		token         string
+		authorization string
+		want          int
+	}{
+		{"sans jeton configuré", "", "", http.StatusOK},
+		{"jeton absent", "secret", "", http.StatusUnauthorized},
+		{"jeton erroné", "secret", "Bearer secrets", http.StatusUnauthorized},
+		{"autre schéma", "secret", "Basic secret", http.StatusUnauthorized},
+		{"jeton correct", "secret", "Bearer secret", http.StatusOK},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/metrics", nil)
+			if tt.authorization != "" {
+				req.Header.Set("Authorization", tt.authorization)
+			}
+			w := httptest.NewRecorder()
+			NewMetricsRouter(tt.token).ServeHTTP(w, req)
+			if w.Code != tt.want {
+				t.Fatalf("statut %d, attendu %d", w.Code, tt.want)
+			}
+		})
+	}
+
+	// Le listener dédié ne sert que les métriques
+	w := httptest.NewRecorder()
+	NewMetricsRouter("").ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/api/v1/links", nil))
+	if w.Code != http.StatusNotFound {
+		t.Fatalf("GET /api/v1/links sur le routeur des métriques : statut %d, attendu 404", w.Code)
+	}
+}
@@ -0,0 +1,119 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"testing"
+
+	"github.com/axellelanca/urlshortener/internal/models"
+	"github.com/axellelanca/urlshortener/internal/services"
+	"gorm.io/gorm"
+)
+
+func TestOwnerlessLinkIsManageableOnceAdopted(t *testing.T) {
+	env := newTestEnv(t, RateLimiters{})
+	key, rawKey := env.createAPIKey(t, "test")
+	link := env.createLink(t, services.CreateLinkInput{LongURL: "https://example.com/legacy"})
+
+	if w := env.doJSON(t, http.MethodGet, "/api/v1/links/"+link.ShortCode, rawKey, nil); w.Code != http.StatusForbidden {
+		t.Fatalf("lien sans propriétaire : statut %d, attendu %d", w.Code, http.StatusForbidden)
+	}
+
+	if _, err := env.linkService.AssignOwner(link.ShortCode, key.ID, false, "cli:test"); err != nil {
+		t.Fatalf("AssignOwner : %v", err)
+	}
+	if w := env.doJSON(t, http.MethodGet, "/api/v1/links/"+link.ShortCode, rawKey, nil); w.Code != http.StatusOK {
+		t.Fatalf("lien adopté : statut %d, attendu %d (%s)", w.Code, http.StatusOK, w.Body)
+	}
+
+	entries, err := env.linkService.GetAuditLog(link.ShortCode)
+	if err != nil {
+		t.Fatalf("GetAuditLog : %v", err)
+	}
+	last := entries[len(entries)-1]
+	if last.Action != models.AuditActionAssignOwner || last.Actor != "cli:test" {
+		t.Errorf("dernière entrée d'audit = %s par %s, attendu %s par cli:test", last.Action, last.Actor, models.AuditActionAssignOwner)
+	}
+}
+
+func TestListLinksOnlyReturnsOwnLinks(t *testing.T) {
+	env := newTestEnv(t, RateLimiters{})
+	alice, aliceKey := env.createAPIKey(t, "alice")
+	bob, _ := env.createAPIKey(t, "bob")
+	env.createLink(t, services.CreateLinkInput{LongURL: "https://example.com/a", Alias: "alice-1", OwnerID: &alice.ID})
+	env.createLink(t, services.CreateLinkInput{LongURL: "https://example.com/b", Alias: "bob-1", OwnerID: &bob.ID})
+	env.createLink(t, services.CreateLinkInput{LongURL: "https://example.com/cli", Alias: "cli-1"})
+
+	w := env.doJSON(t, http.MethodGet, "/api/v1/links?page_size=100", aliceKey, nil)
+	if w.Code != http.StatusOK {
+		t.Fatalf("statut %d, attendu 200 (%s)", w.Code, w.Body)
+	}
+	var body struct {
+		Links []struct {
+			ShortCode string `json:"short_code"`
+		} `json:"links"`
+		Total int `json:"total"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &body); err != nil {
+		t.Fatal(err)
+	}
+	if body.Total != 1 || len(body.Links) != 1 || body.Links[0].ShortCode != "alice-1" {
+		t.Fatalf("liste %+v, attendu seulement alice-1", body)
+	}
+
+	if w := env.doJSON(t, http.MethodGet, "/api/v1/links?page_size=101", aliceKey, nil); w.Code != http.StatusBadRequest {
+		t.Fatalf("page_size=101 : statut %d, attendu 400", w.Code)
+	}
+}
+
+func TestAssignOwnerTransfersOnlyWithForce(t *testing.T) {
+	env := newTestEnv(t, RateLimiters{})
+	owner, ownerKey := env.createAPIKey(t, "owner")
+	other, otherKey := env.createAPIKey(t, "other")
+	link := env.createLink(t, services.CreateLinkInput{LongURL: "https://example.com/owned", OwnerID: &owner.ID})
+
+	// Réattribuer un lien à son propriétaire actuel ne change rien
+	if _, err := env.linkService.AssignOwner(link.ShortCode, owner.ID, false, "test"); err != nil {
+		t.Fatalf("AssignOwner au même propriétaire : %v", err)
+	}
+
+	if _, err := env.linkService.AssignOwner(link.ShortCode, other.ID, false, "test"); !errors.Is(err, services.ErrLinkAlreadyOwned) {
+		t.Fatalf("AssignOwner sans force : erreur %v, attendu ErrLinkAlreadyOwned", err)
+	}
+	if w := env.doJSON(t, http.MethodGet, "/api/v1/links/"+link.ShortCode, ownerKey, nil); w.Code != http.StatusOK {
+		t.Fatalf("transfert refusé : le propriétaire obtient le statut %d", w.Code)
+	}
+
+	if _, err := env.linkService.AssignOwner(link.ShortCode, other.ID, true, "test"); err != nil {
+		t.Fatalf("AssignOwner avec force : %v", err)
+	}
+	if w := env.doJSON(t, http.MethodGet, "/api/v1/links/"+link.ShortCode, ownerKey, nil); w.Code != http.StatusForbidden {
+		t.Errorf("ancien propriétaire : statut %d, attendu %d", w.Code, http.StatusForbidden)
+	}
+	if w := env.doJSON(t, http.MethodGet, "/api/v1/links/"+link.ShortCode, otherKey, nil); w.Code != http.StatusOK {
+		t.Errorf("nouveau propriétaire : statut %d, attendu %d", w.Code, http.StatusOK)
+	}
+
+	if _, err := env.linkService.AssignOwner("inconnu", owner.ID, false, "test"); !errors.Is(err, gorm.ErrRecordNotFound) {
+		t.Errorf("AssignOwner sur un code inconnu : erreur %v, attendu ErrRecordNotFound", err)
+	}
+}
+
+func TestGetActiveAPIKey(t *testing.T) {
+	env := newTestEnv(t, RateLimiters{})
+	key, _ := env.createAPIKey(t, "test")
+
+	if got, err := env.apiKeyService.GetActiveAPIKey(key.ID); err != nil || got.ID != key.ID {
+		t.Fatalf("GetActiveAPIKey(%d) = %v, %v", key.ID, got, err)
+	}
+	if _, err := env.apiKeyService.GetActiveAPIKey(key.ID + 100); !errors.Is(err, gorm.ErrRecordNotFound) {
+		t.Errorf("clé inconnue : erreur %v, attendu ErrRecordNotFound", err)
+	}
+	if err := env.apiKeyService.RevokeAPIKey(key.ID); err != nil {
+		t.Fatalf("RevokeAPIKey : %v", err)
+	}
+	if _, err := env.apiKeyService.GetActiveAPIKey(key.ID); !errors.Is(err, services.ErrAPIKeyRevoked) {
+		t.Errorf("clé révoquée : erreur %v, attendu ErrAPIKeyRevoked", err)
+	}
+}
@@ -0,0 +1,78 @@
+package api
+
+import (
+	"html/template"
+	"net/http"
+	"time"
+
+	"github.com/axellelanca/urlshortener/cmd"
+	"github.com/axellelanca/urlshortener/internal/services"
+	"github.com/gin-gonic/gin"
+)
+
+// previewDateFormat est le format des dates affichées sur la page d'aperçu.
+const previewDateFormat = "02/01/2006 15:04 MST"
+
+// previewPage présente un lien sans rediriger : destination, date de création et nombre de clics.
+// Le bouton Continuer passe par l'URL courte, pour que la redirection soit comptée comme un clic.
+var previewPage = template.Must(template.New("preview").Parse(`<!DOCTYPE html>
+<html lang="fr">
+<head>
+<meta charset="utf-8">
+<meta name="viewport" content="width=device-width, initial-scale=1">
+<meta name="robots" content="noindex">
+<title>Aperçu du lien {{.ShortURL}}</title>
+<style>
+body { font-family: system-ui, sans-serif; max-width: 36rem; margin: 4rem auto; padding: 0 1rem; }
+code { word-break: break-all; }
+dt { font-weight: bold; margin-top: .6rem; }
+.button { display: inline-block; margin-top: 1.5rem; padding: .5rem 1rem; border: 1px solid; text-decoration: none; }
+</style>
+</head>
+<body>
+<h1>Aperçu du lien</h1>
+<dl>
+<dt>Lien court</dt><dd><code>{{.ShortURL}}</code></dd>
+<dt>Destination</dt>
+{{if .Protected}}<dd>Protégée par un mot de passe, elle ne sera affichée qu'une fois le lien déverrouillé.</dd>
+{{else}}<dd><code>{{.LongURL}}</code></dd>
+{{end}}<dt>Créé le</dt><dd>{{.CreatedAt}}</dd>
+<dt>Clics</dt><dd>{{.TotalClicks}}</dd>
+{{if .ExpiresAt}}<dt>Expire le</dt><dd>{{.ExpiresAt}}</dd>
+{{end}}</dl>
+{{if .Expired}}<p>Ce lien a expiré et ne redirige plus.</p>
+{{else}}<a class="button" href="{{.ContinueURL}}" rel="nofollow">Continuer vers la destination</a>
+{{end}}</body>
+</html>
+`))
+
+// renderPreviewPage affiche la page d'aperçu d'un lien, construite à partir de ses statistiques
+// (clics de robots exclus). L'affichage de l'aperçu n'est pas compté comme un clic.
+func renderPreviewPage(c *gin.Context, linkService *services.LinkService, shortCode string) {
+	link, totalClicks, err := linkService.GetLinkStats(shortCode, false)
+	if err != nil {
+		respondRedirectError(c, shortCode, err)
+		return
+	}
+
+	// URL relative : /:shortCode, validée pour les liens qui exigent l'aperçu
+	continueURL := link.ShortCode
+	if link.AlwaysPreview {
+		continueURL += "?continue=1"
+	}
+	data := gin.H{
+		"ShortURL":    cmd.Cfg.Server.BaseURL + "/" + link.ShortCode,
+		"Protected":   link.IsProtected(),
+		"CreatedAt":   link.CreatedAt.UTC().Format(previewDateFormat),
+		"TotalClicks": totalClicks,
+		"Expired":     link.IsExpired(time.Now()),
+		"ContinueURL": continueURL,
+	}
+	if !link.IsProtected() {
+		data["LongURL"] = link.LongURL
+	}
+	if link.ExpiresAt != nil {
+		data["ExpiresAt"] = link.ExpiresAt.UTC().Format(previewDateFormat)
+	}
+	renderHTML(c, http.StatusOK, previewPage, data)
+}
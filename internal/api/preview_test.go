@@ -0,0 +1,87 @@
+package api
+
+import (
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/axellelanca/urlshortener/internal/services"
+)
+
+// consumedClicks relit le nombre de redirections consommées sur le quota d'un lien.
+func (e *testEnv) consumedClicks(t *testing.T, shortCode string) int {
+	t.Helper()
+	link, err := e.linkService.GetLinkByShortCode(shortCode)
+	if err != nil {
+		t.Fatalf("GetLinkByShortCode(%q) : %v", shortCode, err)
+	}
+	return link.ConsumedClicks
+}
+
+func TestPreviewDoesNotConsumeClicks(t *testing.T) {
+	env := newTestEnv(t, RateLimiters{})
+	env.createLink(t, services.CreateLinkInput{LongURL: "https://example.com/once", Alias: "once", MaxClicks: 1})
+
+	for _, target := range []string{"/once+", "/once?preview=1", "/once?preview=true"} {
+		w := env.do(http.MethodGet, target, "203.0.113.7:4321", nil, nil)
+		if w.Code != http.StatusOK || !strings.Contains(w.Body.String(), "https://example.com/once") {
+			t.Fatalf("GET %s : statut %d, attendu l'aperçu avec la destination (%s)", target, w.Code, w.Body)
+		}
+		if w.Header().Get("Location") != "" {
+			t.Fatalf("GET %s : redirection vers %q", target, w.Header().Get("Location"))
+		}
+	}
+	if env.pendingClicks() != 0 || env.consumedClicks(t, "once") != 0 {
+		t.Fatalf("aperçus : %d clic(s) enregistré(s), %d consommé(s), attendu aucun", env.pendingClicks(), env.consumedClicks(t, "once"))
+	}
+
+	// ?preview=0 est une redirection ordinaire, qui épuise le quota
+	if w := env.do(http.MethodGet, "/once?preview=0", "203.0.113.7:4321", nil, nil); w.Code != http.StatusFound {
+		t.Fatalf("GET /once?preview=0 : statut %d, attendu 302", w.Code)
+	}
+	if env.pendingClicks() != 1 || env.consumedClicks(t, "once") != 1 {
+		t.Fatalf("redirection : %d clic(s) enregistré(s), %d consommé(s), attendu 1", env.pendingClicks(), env.consumedClicks(t, "once"))
+	}
+
+	// L'aperçu d'un lien épuisé reste consultable, sans bouton pour continuer
+	w := env.do(http.MethodGet, "/once+", "203.0.113.7:4321", nil, nil)
+	if w.Code != http.StatusOK || !strings.Contains(w.Body.String(), "a expiré") || strings.Contains(w.Body.String(), "Continuer") {
+		t.Fatalf("aperçu d'un lien épuisé : statut %d (%s)", w.Code, w.Body)
+	}
+	if w := env.do(http.MethodGet, "/absent+", "203.0.113.7:4321", nil, nil); w.Code != http.StatusNotFound {
+		t.Fatalf("aperçu d'un code inconnu : statut %d, attendu 404", w.Code)
+	}
+}
+
+func TestAlwaysPreviewRequiresContinue(t *testing.T) {
+	env := newTestEnv(t, RateLimiters{})
+	env.createLink(t, services.CreateLinkInput{LongURL: "https://example.com/risky", Alias: "risky", AlwaysPreview: true, MaxClicks: 5})
+
+	for _, target := range []string{"/risky", "/risky?continue=0", "/risky?continue=oui"} {
+		w := env.do(http.MethodGet, target, "203.0.113.7:4321", nil, nil)
+		if w.Code != http.StatusOK || !strings.Contains(w.Body.String(), `href="risky?continue=1"`) {
+			t.Fatalf("GET %s : statut %d, attendu l'aperçu avec le lien de confirmation (%s)", target, w.Code, w.Body)
+		}
+	}
+	if env.pendingClicks() != 0 || env.consumedClicks(t, "risky") != 0 {
+		t.Fatalf("aperçus : %d clic(s) enregistré(s), %d consommé(s), attendu aucun", env.pendingClicks(), env.consumedClicks(t, "risky"))
+	}
+
+	w := env.do(http.MethodGet, "/risky?continue=1", "203.0.113.7:4321", nil, nil)
+	if w.Code != http.StatusFound || w.Header().Get("Location") != "https://example.com/risky" {
+		t.Fatalf("GET /risky?continue=1 : statut %d vers %q, attendu 302", w.Code, w.Header().Get("Location"))
+	}
+	if env.pendingClicks() != 1 || env.consumedClicks(t, "risky") != 1 {
+		t.Fatalf("confirmation : %d clic(s) enregistré(s), %d consommé(s), attendu 1", env.pendingClicks(), env.consumedClicks(t, "risky"))
+	}
+}
+
+func TestPreviewHidesProtectedDestination(t *testing.T) {
+	env := newTestEnv(t, RateLimiters{})
+	env.createLink(t, services.CreateLinkInput{LongURL: "https://example.com/secret", Alias: "secret", Password: "motdepasse"})
+
+	w := env.do(http.MethodGet, "/secret+", "203.0.113.7:4321", nil, nil)
+	if w.Code != http.StatusOK || strings.Contains(w.Body.String(), "example.com/secret") {
+		t.Fatalf("aperçu d'un lien protégé : statut %d, la destination ne doit pas apparaître (%s)", w.Code, w.Body)
+	}
+}
@@ -0,0 +1,96 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"github.com/axellelanca/urlshortener/cmd"
+	"github.com/axellelanca/urlshortener/internal/services"
+)
+
+// decodeLink décode la représentation JSON d'un lien retournée par les routes /links.
+func decodeLink(t *testing.T, body []byte) map[string]any {
+	t.Helper()
+	var link map[string]any
+	if err := json.Unmarshal(body, &link); err != nil {
+		t.Fatalf("réponse JSON invalide : %v (%s)", err, body)
+	}
+	return link
+}
+
+func TestPermanentRedirectRejectedForRestrictedLinks(t *testing.T) {
+	env := newTestEnv(t, RateLimiters{})
+	_, rawKey := env.createAPIKey(t, "test")
+
+	restricted := []map[string]any{
+		{"max_clicks": 5},
+		{"expires_at": "2099-01-01T00:00:00Z"},
+		{"password": "hunter22"},
+		{"always_preview": true},
+	}
+	for _, restriction := range restricted {
+		for _, code := range []int{http.StatusMovedPermanently, http.StatusPermanentRedirect} {
+			body := map[string]any{"long_url": "https://example.com/", "redirect_type": code}
+			for field, value := range restriction {
+				body[field] = value
+			}
+			if w := env.doJSON(t, http.MethodPost, "/api/v1/links", rawKey, body); w.Code != http.StatusBadRequest {
+				t.Errorf("création %v : statut %d, attendu 400", body, w.Code)
+			}
+		}
+	}
+
+	// Un lien permanent sans restriction est accepté, mais ne peut plus en recevoir ensuite
+	w := env.doJSON(t, http.MethodPost, "/api/v1/links", rawKey, map[string]any{
+		"long_url": "https://example.com/", "alias": "permanent", "redirect_type": http.StatusPermanentRedirect,
+	})
+	if w.Code != http.StatusCreated {
+		t.Fatalf("création d'un lien permanent : statut %d (%s)", w.Code, w.Body)
+	}
+	w = env.doJSON(t, http.MethodPatch, "/api/v1/links/permanent", rawKey, map[string]any{"max_clicks": 3})
+	if w.Code != http.StatusBadRequest {
+		t.Fatalf("ajout d'un quota à un lien permanent : statut %d, attendu 400", w.Code)
+	}
+	w = env.doJSON(t, http.MethodPatch, "/api/v1/links/permanent", rawKey, map[string]any{"max_clicks": 3, "redirect_type": http.StatusTemporaryRedirect})
+	if w.Code != http.StatusOK {
+		t.Fatalf("ajout d'un quota avec passage en 307 : statut %d (%s)", w.Code, w.Body)
+	}
+}
+
+func TestPermanentServerDefaultDowngradedForRestrictedLinks(t *testing.T) {
+	env := newTestEnv(t, RateLimiters{})
+	key, rawKey := env.createAPIKey(t, "test")
+	cmd.Cfg.Server.DefaultRedirectType = http.StatusMovedPermanently
+
+	tests := []struct {
+		alias         string
+		input         services.CreateLinkInput
+		wantStored    float64
+		wantEffective int
+	}{
+		{"plain", services.CreateLinkInput{LongURL: "https://example.com/"}, 0, http.StatusMovedPermanently},
+		{"quota", services.CreateLinkInput{LongURL: "https://example.com/", MaxClicks: 10}, 0, http.StatusFound},
+		{"explicit", services.CreateLinkInput{LongURL: "https://example.com/", MaxClicks: 10, RedirectType: http.StatusTemporaryRedirect}, 307, http.StatusTemporaryRedirect},
+	}
+	for _, tt := range tests {
+		t.Run(tt.alias, func(t *testing.T) {
+			tt.input.Alias = tt.alias
+			tt.input.OwnerID = &key.ID
+			env.createLink(t, tt.input)
+
+			if w := env.do(http.MethodGet, "/"+tt.alias, "203.0.113.7:1000", nil, nil); w.Code != tt.wantEffective {
+				t.Errorf("redirection : statut %d, attendu %d", w.Code, tt.wantEffective)
+			}
+			w := env.doJSON(t, http.MethodGet, "/api/v1/links/"+tt.alias, rawKey, nil)
+			if w.Code != http.StatusOK {
+				t.Fatalf("GET du lien : statut %d (%s)", w.Code, w.Body)
+			}
+			link := decodeLink(t, w.Body.Bytes())
+			if link["redirect_type"] != tt.wantStored || link["effective_redirect_type"] != float64(tt.wantEffective) {
+				t.Errorf("redirect_type = %v, effective_redirect_type = %v ; attendu %v et %d",
+					link["redirect_type"], link["effective_redirect_type"], tt.wantStored, tt.wantEffective)
+			}
+		})
+	}
+}
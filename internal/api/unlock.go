@@ -0,0 +1,147 @@
+package api
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"html/template"
+	"log"
+	"net/http"
+	"strconv"
+
+	"github.com/axellelanca/urlshortener/internal/ratelimit"
+	"github.com/axellelanca/urlshortener/internal/services"
+	"github.com/gin-gonic/gin"
+)
+
+// unlockPage est le formulaire de mot de passe d'un lien protégé. Il ne révèle pas la destination.
+// L'action relative renvoie le formulaire sur l'URL courte elle-même (POST /:shortCode).
+var unlockPage = template.Must(template.New("unlock").Parse(`<!DOCTYPE html>
+<html lang="fr">
+<head>
+<meta charset="utf-8">
+<meta name="viewport" content="width=device-width, initial-scale=1">
+<meta name="robots" content="noindex">
+<title>Lien protégé</title>
+<style>
+body { font-family: system-ui, sans-serif; max-width: 24rem; margin: 4rem auto; padding: 0 1rem; }
+input, button { font: inherit; padding: .4rem; }
+.error { color: #b00020; }
+</style>
+</head>
+<body>
+<h1>Lien protégé</h1>
+<p>Ce lien est protégé par un mot de passe.</p>
+{{if .Error}}<p class="error" role="alert">{{.Error}}</p>{{end}}
+<form method="post" action="{{.ShortCode}}">
+<label for="password">Mot de passe</label>
+<input id="password" name="password" type="password" required autofocus autocomplete="off">
+<button type="submit">Continuer</button>
+</form>
+</body>
+</html>
+`))
+
+// renderUnlockPage affiche le formulaire de mot de passe d'un lien, avec un éventuel message d'erreur.
+func renderUnlockPage(c *gin.Context, status int, shortCode, message string) {
+	renderHTML(c, status, unlockPage, gin.H{"ShortCode": shortCode, "Error": message})
+}
+
+// renderHTML affiche une page HTML du service (formulaire de mot de passe, aperçu). Ces pages ne doivent
+// être ni mises en cache ni transmises comme référent à la destination.
+func renderHTML(c *gin.Context, status int, page *template.Template, data any) {
+	var body bytes.Buffer
+	if err := page.Execute(&body, data); err != nil {
+		log.Printf("[Handlers::renderHTML] Erreur lors du rendu de la page '%s' : %v", page.Name(), err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Erreur serveur"})
+		return
+	}
+	c.Header("Cache-Control", "no-store")
+	c.Header("Referrer-Policy", "no-referrer")
+	c.Data(status, "text/html; charset=utf-8", body.Bytes())
+}
+
+// UnlockHandler traite le formulaire de mot de passe d'un lien protégé : un mot de passe correct
+// redirige vers la destination et compte un clic, un mot de passe erroné réaffiche le formulaire
+// et est compté parmi les échecs de déverrouillage du lien.
+// Seuls les échecs sont décomptés, par client et par lien (limiters.Unlock) et par lien tous clients
+// confondus (limiters.UnlockLink) : les limiteurs sont consultés avant la vérification et débités
+// après un échec, pour qu'un utilisateur légitime ne soit pas bloqué par ses propres succès.
+func UnlockHandler(linkService *services.LinkService, limiters RateLimiters) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		shortCode := c.Param("shortCode")
+		checks := unlockLimits(c, shortCode, limiters)
+
+		if retryAfter, limited := peekUnlockLimits(c, checks); limited {
+			c.Header("Retry-After", strconv.Itoa(retryAfter))
+			renderUnlockPage(c, http.StatusTooManyRequests, shortCode,
+				fmt.Sprintf("Trop de tentatives, réessayez dans %d seconde(s).", retryAfter))
+			return
+		}
+
+		link, err := linkService.UnlockLink(shortCode, c.PostForm("password"), isBotRequest(c))
+		if err != nil {
+			if errors.Is(err, services.ErrWrongPassword) {
+				chargeUnlockLimits(c, checks)
+				renderUnlockPage(c, http.StatusUnauthorized, shortCode, "Mot de passe incorrect.")
+				return
+			}
+			if errors.Is(err, services.ErrLinkNotProtected) {
+				// Seuls les liens protégés acceptent POST : les autres se résolvent par GET
+				c.Header("Allow", "GET")
+				c.JSON(http.StatusMethodNotAllowed, gin.H{"error": "Ce lien n'est pas protégé par un mot de passe"})
+				return
+			}
+			respondRedirectError(c, shortCode, err)
+			return
+		}
+
+		recordClick(c, link)
+		// 303 : le navigateur suit la redirection en GET, sans renvoyer le formulaire à la destination
+		c.Redirect(http.StatusSeeOther, link.LongURL)
+	}
+}
+
+// unlockLimit est un limiteur des échecs de déverrouillage et la clé à laquelle il s'applique.
+type unlockLimit struct {
+	limiter ratelimit.Limiter
+	key     string
+}
+
+// unlockLimits retourne les limites configurées pour une tentative de déverrouillage de shortCode.
+func unlockLimits(c *gin.Context, shortCode string, limiters RateLimiters) []unlockLimit {
+	var checks []unlockLimit
+	if limiters.Unlock != nil {
+		checks = append(checks, unlockLimit{limiters.Unlock, "unlock:" + shortCode + ":" + clientKey(c)})
+	}
+	if limiters.UnlockLink != nil {
+		checks = append(checks, unlockLimit{limiters.UnlockLink, "unlock:" + shortCode})
+	}
+	return checks
+}
+
+// peekUnlockLimits indique si l'une des limites est atteinte, et dans combien de secondes réessayer.
+// Un limiteur en erreur laisse passer la tentative, comme le middleware RateLimit.
+func peekUnlockLimits(c *gin.Context, checks []unlockLimit) (retryAfter int, limited bool) {
+	for _, check := range checks {
+		result, err := check.limiter.Peek(c.Request.Context(), check.key)
+		if err != nil {
+			log.Printf("[Handlers::UnlockHandler] Limiteur indisponible, tentative acceptée : %v", err)
+			continue
+		}
+		if !result.Allowed {
+			limited = true
+			retryAfter = max(retryAfter, ceilSeconds(result.RetryAfter))
+		}
+	}
+	return retryAfter, limited
+}
+
+// chargeUnlockLimits décompte un échec de déverrouillage sur chaque limite.
+func chargeUnlockLimits(c *gin.Context, checks []unlockLimit) {
+	for _, check := range checks {
+		if _, err := check.limiter.Allow(c.Request.Context(), check.key); err != nil {
+			log.Printf("[Handlers::UnlockHandler] Limiteur indisponible, échec non décompté : %v", err)
+		}
+	}
+}
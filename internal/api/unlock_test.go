@@ -0,0 +1,103 @@
+package api
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"testing"
+
+	"github.com/axellelanca/urlshortener/internal/ratelimit"
+	"github.com/axellelanca/urlshortener/internal/services"
+)
+
+func TestUnlockHandlerRejectsUnprotectedLink(t *testing.T) {
+	env := newTestEnv(t, RateLimiters{})
+	env.createLink(t, services.CreateLinkInput{LongURL: "https://example.com/promo", Alias: "spring-sale"})
+	env.createLink(t, services.CreateLinkInput{LongURL: "https://example.com/risky", Alias: "risky", AlwaysPreview: true})
+
+	for _, code := range []string{"spring-sale", "risky"} {
+		w := env.do(http.MethodPost, "/"+code, "203.0.113.7:4321", url.Values{"password": {"x"}}, nil)
+		if w.Code != http.StatusMethodNotAllowed {
+			t.Errorf("POST /%s : statut %d, attendu 405", code, w.Code)
+		}
+		if location := w.Header().Get("Location"); location != "" {
+			t.Errorf("POST /%s : redirection inattendue vers %s", code, location)
+		}
+	}
+	if n := env.pendingClicks(); n != 0 {
+		t.Errorf("%d clic(s) enregistré(s), attendu aucun", n)
+	}
+}
+
+func TestUnlockHandlerRedirectsWithCorrectPassword(t *testing.T) {
+	env := newTestEnv(t, RateLimiters{})
+	env.createLink(t, services.CreateLinkInput{LongURL: "https://example.com/doc", Alias: "doc", Password: "hunter22"})
+
+	if w := env.do(http.MethodGet, "/doc", "203.0.113.7:4321", nil, nil); w.Code != http.StatusOK || w.Header().Get("Location") != "" {
+		t.Fatalf("GET /doc : statut %d, attendu le formulaire (200)", w.Code)
+	}
+	if w := env.do(http.MethodPost, "/doc", "203.0.113.7:4321", url.Values{"password": {"wrong"}}, nil); w.Code != http.StatusUnauthorized {
+		t.Fatalf("mauvais mot de passe : statut %d, attendu 401", w.Code)
+	}
+	w := env.do(http.MethodPost, "/doc", "203.0.113.7:4321", url.Values{"password": {"hunter22"}}, nil)
+	if w.Code != http.StatusSeeOther || w.Header().Get("Location") != "https://example.com/doc" {
+		t.Fatalf("bon mot de passe : statut %d vers %q, attendu 303 vers la destination", w.Code, w.Header().Get("Location"))
+	}
+	if n := env.pendingClicks(); n != 1 {
+		t.Errorf("%d clic(s) enregistré(s), attendu 1", n)
+	}
+}
+
+func TestUnlockRateLimitOnlyChargesFailuresPerClient(t *testing.T) {
+	env := newTestEnv(t, RateLimiters{Unlock: ratelimit.NewMemoryLimiter(1, 3)})
+	env.createLink(t, services.CreateLinkInput{LongURL: "https://example.com/doc", Alias: "doc", Password: "hunter22"})
+	const attacker, user = "198.51.100.1:1000", "203.0.113.7:2000"
+	wrong, right := url.Values{"password": {"wrong"}}, url.Values{"password": {"hunter22"}}
+
+	// Les bons mots de passe ne consomment pas la limite
+	for i := 0; i < 5; i++ {
+		if w := env.do(http.MethodPost, "/doc", user, right, nil); w.Code != http.StatusSeeOther {
+			t.Fatalf("tentative correcte %d : statut %d, attendu 303", i+1, w.Code)
+		}
+	}
+
+	for i := 0; i < 3; i++ {
+		if w := env.do(http.MethodPost, "/doc", attacker, wrong, nil); w.Code != http.StatusUnauthorized {
+			t.Fatalf("échec %d : statut %d, attendu 401", i+1, w.Code)
+		}
+	}
+	w := env.do(http.MethodPost, "/doc", attacker, wrong, nil)
+	if w.Code != http.StatusTooManyRequests || w.Header().Get("Retry-After") == "" {
+		t.Fatalf("échec au-delà de la limite : statut %d, attendu 429 avec Retry-After", w.Code)
+	}
+
+	// Les échecs d'un client ne bloquent pas les autres
+	if w := env.do(http.MethodPost, "/doc", user, right, nil); w.Code != http.StatusSeeOther {
+		t.Fatalf("utilisateur légitime après les échecs d'un autre client : statut %d, attendu 303", w.Code)
+	}
+}
+
+func TestUnlockRateLimitCapsFailuresPerLink(t *testing.T) {
+	env := newTestEnv(t, RateLimiters{
+		Unlock:     ratelimit.NewMemoryLimiter(1, 3),
+		UnlockLink: ratelimit.NewMemoryLimiter(1, 4),
+	})
+	env.createLink(t, services.CreateLinkInput{LongURL: "https://example.com/doc", Alias: "doc", Password: "hunter22"})
+	env.createLink(t, services.CreateLinkInput{LongURL: "https://example.com/other", Alias: "other", Password: "hunter22"})
+	wrong := url.Values{"password": {"wrong"}}
+
+	// Une attaque répartie sur plusieurs adresses atteint le plafond du lien
+	for i := 0; i < 4; i++ {
+		addr := fmt.Sprintf("198.51.100.%d:1000", i+1)
+		if w := env.do(http.MethodPost, "/doc", addr, wrong, nil); w.Code != http.StatusUnauthorized {
+			t.Fatalf("échec %d : statut %d, attendu 401", i+1, w.Code)
+		}
+	}
+	if w := env.do(http.MethodPost, "/doc", "198.51.100.99:1000", wrong, nil); w.Code != http.StatusTooManyRequests {
+		t.Fatalf("échec au-delà du plafond du lien : statut %d, attendu 429", w.Code)
+	}
+	// Le plafond est propre au lien
+	if w := env.do(http.MethodPost, "/other", "198.51.100.99:1000", wrong, nil); w.Code != http.StatusUnauthorized {
+		t.Fatalf("autre lien : statut %d, attendu 401", w.Code)
+	}
+}
@@ -0,0 +1,65 @@
+package botdetect
+
+import (
+	"fmt"
+	"net"
+	"regexp"
+
+	"github.com/axellelanca/urlshortener/internal/useragent"
+)
+
+// Detector identifie les clics émis par des robots à partir du User-Agent et de l'adresse IP.
+// Il combine les règles intégrées du package useragent, des expressions régulières
+// supplémentaires et des plages d'adresses IP configurées.
+type Detector struct {
+	enabled  bool
+	patterns []*regexp.Regexp
+	networks []*net.IPNet
+}
+
+// NewDetector crée un Detector à partir des motifs User-Agent (expressions régulières,
+// insensibles à la casse) et des plages CIDR fournies. Un détecteur désactivé ne signale aucun robot.
+func NewDetector(enabled bool, userAgentPatterns []string, ipRanges []string) (*Detector, error) {
+	d := &Detector{enabled: enabled}
+
+	for _, pattern := range userAgentPatterns {
+		re, err := regexp.Compile("(?i)" + pattern)
+		if err != nil {
+			return nil, fmt.Errorf("[BotDetect] motif User-Agent invalide '%s': %w", pattern, err)
+		}
+		d.patterns = append(d.patterns, re)
+	}
+
+	for _, cidr := range ipRanges {
+		_, network, err := net.ParseCIDR(cidr)
+		if err != nil {
+			return nil, fmt.Errorf("[BotDetect] plage IP invalide '%s': %w", cidr, err)
+		}
+		d.networks = append(d.networks, network)
+	}
+
+	return d, nil
+}
+
+// IsBot indique si un clic provient d'un robot. agent est le résultat de useragent.Parse pour userAgent.
+func (d *Detector) IsBot(userAgent string, agent useragent.Info, ipAddress string) bool {
+	if d == nil || !d.enabled {
+		return false
+	}
+	if agent.DeviceType == useragent.DeviceBot {
+		return true
+	}
+	for _, re := range d.patterns {
+		if re.MatchString(userAgent) {
+			return true
+		}
+	}
+	if ip := net.ParseIP(ipAddress); ip != nil {
+		for _, network := range d.networks {
+			if network.Contains(ip) {
+				return true
+			}
+		}
+	}
+	return false
+}
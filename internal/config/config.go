@@ -15,26 +15,118 @@ type Config struct {
 	Database  DatabaseConfig  `mapstructure:"database"`
 	Analytics AnalyticsConfig `mapstructure:"analytics"`
 	Monitor   MonitorConfig   `mapstructure:"monitor"`
+	Bots      BotConfig       `mapstructure:"bots"`
+	GeoIP     GeoIPConfig     `mapstructure:"geoip"`
+	Privacy   PrivacyConfig   `mapstructure:"privacy"`
+	Health    HealthConfig    `mapstructure:"health"`
+	Cache     CacheConfig     `mapstructure:"cache"`
+	RateLimit RateLimitConfig `mapstructure:"rate_limit"`
+	URLPolicy URLPolicyConfig `mapstructure:"url_policy"`
 }
 
 type ServerConfig struct {
-	Port    int    `mapstructure:"port"`
-	BaseURL string `mapstructure:"base_url"`
+	Port                   int    `mapstructure:"port"`
+	BaseURL                string `mapstructure:"base_url"`
+	ShutdownTimeoutSeconds int    `mapstructure:"shutdown_timeout_seconds"` // Délai accordé à l'arrêt propre (HTTP puis workers)
+	DefaultRedirectType    int    `mapstructure:"default_redirect_type"`    // Code de redirection des liens sans redirect_type (302 si 0)
+	// Adresses ou plages CIDR des proxys dont les en-têtes X-Forwarded-For / X-Real-IP sont crus.
+	// Vide : aucun, l'adresse du client est celle de la connexion.
+	TrustedProxies []string `mapstructure:"trusted_proxies"`
+	// Adresse d'écoute dédiée à /metrics (ex: "127.0.0.1:9090"). Vide : /metrics est servi sur le port
+	// principal, seulement si MetricsToken est renseigné.
+	MetricsAddr  string `mapstructure:"metrics_addr"`
+	MetricsToken string `mapstructure:"metrics_token"` // Jeton exigé par /metrics (Authorization: Bearer), facultatif sur MetricsAddr
 }
 
 type DatabaseConfig struct {
-	Name string `mapstructure:"name"`
+	Driver                 string `mapstructure:"driver"`                    // sqlite (par défaut), postgres ou mysql
+	DSN                    string `mapstructure:"dsn"`                       // Chaîne de connexion ; pour SQLite, Name est utilisé si vide
+	Name                   string `mapstructure:"name"`                      // Fichier de la base SQLite
+	MaxOpenConns           int    `mapstructure:"max_open_conns"`            // 0 : illimité
+	MaxIdleConns           int    `mapstructure:"max_idle_conns"`            // 0 : valeur par défaut de database/sql (2)
+	ConnMaxLifetimeSeconds int    `mapstructure:"conn_max_lifetime_seconds"` // 0 : connexions réutilisées indéfiniment
 }
 
 type AnalyticsConfig struct {
-	BufferSize  int `mapstructure:"buffer_size"`
-	WorkerCount int `mapstructure:"worker_count"`
+	BufferSize      int    `mapstructure:"buffer_size"`
+	WorkerCount     int    `mapstructure:"worker_count"`
+	BatchSize       int    `mapstructure:"batch_size"`        // Nombre maximal de clics insérés en une requête par worker
+	FlushIntervalMs int    `mapstructure:"flush_interval_ms"` // Délai maximal avant l'écriture d'un lot incomplet
+	SpoolDir        string `mapstructure:"spool_dir"`         // Répertoire du spool des événements de clic, désactivé si vide
 }
 
 type MonitorConfig struct {
 	IntervalMinutes int `mapstructure:"interval_minutes"`
 }
 
+// BotConfig configure la détection des robots (aperçus de liens, crawlers, sondes de disponibilité).
+// Les clics détectés sont marqués is_bot et exclus des statistiques par défaut.
+type BotConfig struct {
+	Enabled           bool     `mapstructure:"enabled"`
+	UserAgentPatterns []string `mapstructure:"user_agent_patterns"` // Expressions régulières ajoutées aux règles intégrées
+	IPRanges          []string `mapstructure:"ip_ranges"`           // Plages CIDR dont tous les clics sont considérés comme des robots
+}
+
+// GeoIPConfig configure la géolocalisation hors ligne des clics à partir d'une base MaxMind (MMDB).
+// Si le fichier est absent, les clics sont enregistrés sans pays, région ni ville.
+type GeoIPConfig struct {
+	DatabasePath string `mapstructure:"database_path"`
+}
+
+// PrivacyConfig configure l'anonymisation des adresses IP et la durée de conservation des clics bruts.
+type PrivacyConfig struct {
+	IPMode                   string `mapstructure:"ip_mode"`                    // full, truncate, hash ou none
+	RetentionDays            int    `mapstructure:"retention_days"`             // 0 conserve les clics indéfiniment
+	RetentionMode            string `mapstructure:"retention_mode"`             // purge ou aggregate
+	RetentionIntervalMinutes int    `mapstructure:"retention_interval_minutes"` // Intervalle de la tâche de rétention
+}
+
+// HealthConfig règle les seuils de la vérification de disponibilité (/api/v1/health/ready).
+type HealthConfig struct {
+	DBTimeoutMs            int `mapstructure:"db_timeout_ms"`            // Délai maximal du ping de la base de données
+	QueueSaturationPercent int `mapstructure:"queue_saturation_percent"` // Remplissage du channel de clics jugé saturé
+	WorkerStallSeconds     int `mapstructure:"worker_stall_seconds"`     // Inactivité des workers jugée anormale
+}
+
+// CacheConfig règle le cache en mémoire de la résolution des codes courts utilisé par le serveur.
+type CacheConfig struct {
+	LinkSize           int `mapstructure:"link_size"`            // Nombre maximal d'entrées, 0 désactive le cache
+	LinkTTLSeconds     int `mapstructure:"link_ttl_seconds"`     // Durée de vie d'un lien en cache
+	NegativeTTLSeconds int `mapstructure:"negative_ttl_seconds"` // Durée de vie d'un code inconnu en cache, 0 pour ne pas les garder
+}
+
+// RateLimitConfig configure la limitation de débit par client (clé API ou adresse IP).
+type RateLimitConfig struct {
+	Enabled  bool          `mapstructure:"enabled"`
+	API      RateLimitRule `mapstructure:"api"`      // Toutes les routes /api/v1/links, par adresse IP avant l'authentification
+	Create   RateLimitRule `mapstructure:"create"`   // Création de liens
+	Stats    RateLimitRule `mapstructure:"stats"`    // Consultation des statistiques
+	Redirect RateLimitRule `mapstructure:"redirect"` // Redirections
+	// Mots de passe erronés sur un lien protégé : Unlock par client et par lien, UnlockLink par lien
+	// tous clients confondus (plus large, borne les attaques réparties sur de nombreuses adresses)
+	Unlock     RateLimitRule `mapstructure:"unlock"`
+	UnlockLink RateLimitRule `mapstructure:"unlock_link"`
+}
+
+// RateLimitRule est la limite d'un groupe de routes : un seau de Burst requêtes, regagnées
+// au rythme de RequestsPerMinute. Une règle à 0 requête par minute n'est pas appliquée.
+type RateLimitRule struct {
+	RequestsPerMinute int `mapstructure:"requests_per_minute"`
+	Burst             int `mapstructure:"burst"`
+}
+
+// URLPolicyConfig configure les vérifications appliquées aux URLs de destination des liens.
+type URLPolicyConfig struct {
+	AllowedSchemes        []string `mapstructure:"allowed_schemes"`         // http et https si vide
+	MaxLength             int      `mapstructure:"max_length"`              // Longueur maximale d'une URL
+	BlockPrivateIPs       bool     `mapstructure:"block_private_ips"`       // Refuse localhost et les adresses privées
+	ResolveHosts          bool     `mapstructure:"resolve_hosts"`           // Vérifie aussi les adresses DNS des noms d'hôte
+	ResolveFailOpen       bool     `mapstructure:"resolve_fail_open"`       // Accepte les noms dont la résolution échoue
+	BlocklistFile         string   `mapstructure:"blocklist_file"`          // Domaines refusés, un par ligne
+	AllowlistFile         string   `mapstructure:"allowlist_file"`          // Seuls domaines acceptés s'il est renseigné
+	ReloadIntervalSeconds int      `mapstructure:"reload_interval_seconds"` // Vérification des modifications des listes
+}
+
 func LoadConfig() (*Config, error) {
 	// Load config from 'configs' directory
 	viper.SetConfigName("config")
@@ -44,6 +136,16 @@ func LoadConfig() (*Config, error) {
 	// DONE : Définir les valeurs par défaut pour toutes les options de configuration.
 	// DONE : Lire le fichier de configuration.
 
+	// Les protections de la politique des URLs s'appliquent aussi à un fichier de configuration
+	// qui ne mentionne pas la section url_policy (ex: écrit avant son introduction) :
+	// seule une valeur explicite du fichier peut les désactiver.
+	viper.SetDefault("url_policy.allowed_schemes", []string{"http", "https"})
+	viper.SetDefault("url_policy.max_length", 2048)
+	viper.SetDefault("url_policy.block_private_ips", true)
+	viper.SetDefault("url_policy.reload_interval_seconds", 30)
+	// De même, les métriques ne sont exposées sur le port public que si la configuration le demande
+	viper.SetDefault("server.metrics_addr", "127.0.0.1:9090")
+
 	if err := viper.ReadInConfig(); err != nil {
 		// If error in config, switch to default values
 		var configFileNotFoundError viper.ConfigFileNotFoundError
@@ -52,10 +154,40 @@ func LoadConfig() (*Config, error) {
 			// Load default values
 			viper.SetDefault("server.port", 8080)
 			viper.SetDefault("server.base_url", "http://localhost")
+			viper.SetDefault("server.shutdown_timeout_seconds", 10)
+			viper.SetDefault("server.default_redirect_type", 302)
+			viper.SetDefault("database.driver", "sqlite")
 			viper.SetDefault("database.name", "url_shortener.db")
 			viper.SetDefault("analytics.buffer_size", 1000)
 			viper.SetDefault("analytics.worker_count", 5)
+			viper.SetDefault("analytics.batch_size", 100)
+			viper.SetDefault("analytics.flush_interval_ms", 500)
 			viper.SetDefault("monitor.interval_minutes", 5)
+			viper.SetDefault("bots.enabled", true)
+			viper.SetDefault("geoip.database_path", "GeoLite2-City.mmdb")
+			viper.SetDefault("privacy.ip_mode", "truncate")
+			viper.SetDefault("privacy.retention_days", 90)
+			viper.SetDefault("privacy.retention_mode", "aggregate")
+			viper.SetDefault("privacy.retention_interval_minutes", 60)
+			viper.SetDefault("health.db_timeout_ms", 2000)
+			viper.SetDefault("health.queue_saturation_percent", 90)
+			viper.SetDefault("health.worker_stall_seconds", 30)
+			viper.SetDefault("cache.link_size", 10000)
+			viper.SetDefault("cache.link_ttl_seconds", 60)
+			viper.SetDefault("cache.negative_ttl_seconds", 10)
+			viper.SetDefault("rate_limit.enabled", true)
+			viper.SetDefault("rate_limit.api.requests_per_minute", 300)
+			viper.SetDefault("rate_limit.api.burst", 60)
+			viper.SetDefault("rate_limit.create.requests_per_minute", 30)
+			viper.SetDefault("rate_limit.create.burst", 10)
+			viper.SetDefault("rate_limit.stats.requests_per_minute", 120)
+			viper.SetDefault("rate_limit.stats.burst", 30)
+			viper.SetDefault("rate_limit.redirect.requests_per_minute", 600)
+			viper.SetDefault("rate_limit.redirect.burst", 100)
+			viper.SetDefault("rate_limit.unlock.requests_per_minute", 5)
+			viper.SetDefault("rate_limit.unlock.burst", 5)
+			viper.SetDefault("rate_limit.unlock_link.requests_per_minute", 60)
+			viper.SetDefault("rate_limit.unlock_link.burst", 30)
 		} else {
 			log.Printf("Erreur lors de la lecture du fichier de configuration: %v", err)
 		}
@@ -70,8 +202,8 @@ func LoadConfig() (*Config, error) {
 		return nil, err
 	}
 
-	log.Printf("Configuration loaded: Server Port=%d, DB Name=%s, Analytics Buffer=%d, Monitor Interval=%dmin",
-		cfg.Server.Port, cfg.Database.Name, cfg.Analytics.BufferSize, cfg.Monitor.IntervalMinutes)
+	log.Printf("Configuration loaded: Server Port=%d, DB Driver=%s, DB Name=%s, Analytics Buffer=%d, Monitor Interval=%dmin",
+		cfg.Server.Port, cfg.Database.Driver, cfg.Database.Name, cfg.Analytics.BufferSize, cfg.Monitor.IntervalMinutes)
 
 	return &cfg, nil
 }
@@ -0,0 +1,106 @@
+package database
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/axellelanca/urlshortener/internal/config"
+	mysqldriver "github.com/go-sql-driver/mysql"
+	"gorm.io/driver/mysql"
+	"gorm.io/driver/postgres"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+// Drivers pris en charge pour database.driver.
+const (
+	DriverSQLite   = "sqlite"
+	DriverPostgres = "postgres"
+	DriverMySQL    = "mysql"
+)
+
+// Open ouvre la connexion à la base de données décrite par la section 'database' de la configuration
+// et règle le pool de connexions. Sans driver, SQLite est utilisé ; sans DSN, le fichier SQLite est cfg.Name.
+func Open(cfg config.DatabaseConfig) (*gorm.DB, error) {
+	dialector, err := dialectorFor(cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	// TranslateError convertit les erreurs propres à chaque driver en erreurs GORM (ex: gorm.ErrDuplicatedKey)
+	db, err := gorm.Open(dialector, &gorm.Config{TranslateError: true})
+	if err != nil {
+		return nil, fmt.Errorf("[Database] connexion à la base %s impossible: %w", driverName(cfg), err)
+	}
+
+	sqlDB, err := db.DB()
+	if err != nil {
+		return nil, fmt.Errorf("[Database] accès à la connexion SQL impossible: %w", err)
+	}
+	if cfg.MaxOpenConns > 0 {
+		sqlDB.SetMaxOpenConns(cfg.MaxOpenConns)
+	}
+	if cfg.MaxIdleConns > 0 {
+		sqlDB.SetMaxIdleConns(cfg.MaxIdleConns)
+	}
+	if cfg.ConnMaxLifetimeSeconds > 0 {
+		sqlDB.SetConnMaxLifetime(time.Duration(cfg.ConnMaxLifetimeSeconds) * time.Second)
+	}
+	return db, nil
+}
+
+// dialectorFor retourne le dialecte GORM correspondant au driver configuré.
+func dialectorFor(cfg config.DatabaseConfig) (gorm.Dialector, error) {
+	switch driverName(cfg) {
+	case DriverSQLite:
+		dsn := cfg.DSN
+		if dsn == "" {
+			dsn = cfg.Name
+		}
+		return sqlite.Open(dsn), nil
+	case DriverPostgres:
+		if cfg.DSN == "" {
+			return nil, fmt.Errorf("[Database] database.dsn est requis pour le driver %s", DriverPostgres)
+		}
+		return postgres.Open(cfg.DSN), nil
+	case DriverMySQL:
+		if cfg.DSN == "" {
+			return nil, fmt.Errorf("[Database] database.dsn est requis pour le driver %s", DriverMySQL)
+		}
+		dsn, err := mysqlDSN(cfg.DSN)
+		if err != nil {
+			return nil, err
+		}
+		return mysql.Open(dsn), nil
+	default:
+		return nil, fmt.Errorf("[Database] driver '%s' inconnu (attendu : %s, %s ou %s)", cfg.Driver, DriverSQLite, DriverPostgres, DriverMySQL)
+	}
+}
+
+// mysqlDSN vérifie le DSN MySQL et active parseTime. Les dates doivent être échangées en UTC (loc=UTC,
+// la valeur par défaut) : les colonnes DATETIME ne conservent pas le fuseau horaire, et les regroupements
+// des statistiques par heure ou par jour supposent des dates UTC.
+func mysqlDSN(dsn string) (string, error) {
+	parsed, err := mysqldriver.ParseDSN(dsn)
+	if err != nil {
+		return "", fmt.Errorf("[Database] database.dsn invalide pour le driver %s: %w", DriverMySQL, err)
+	}
+	if parsed.Loc != time.UTC {
+		return "", fmt.Errorf("[Database] le DSN %s doit utiliser loc=UTC (fuseau configuré : %s)", DriverMySQL, parsed.Loc)
+	}
+	parsed.ParseTime = true
+	return parsed.FormatDSN(), nil
+}
+
+// driverName normalise le driver configuré, SQLite par défaut.
+func driverName(cfg config.DatabaseConfig) string {
+	driver := strings.ToLower(strings.TrimSpace(cfg.Driver))
+	switch driver {
+	case "", "sqlite3":
+		return DriverSQLite
+	case "postgresql", "pgx":
+		return DriverPostgres
+	}
+	return driver
+}
@@ -0,0 +1,86 @@
+package database_test
+
+import (
+	"errors"
+	"path/filepath"
+	"testing"
+
+	"github.com/axellelanca/urlshortener/internal/config"
+	"github.com/axellelanca/urlshortener/internal/database"
+	"github.com/axellelanca/urlshortener/internal/migrations"
+	"github.com/axellelanca/urlshortener/internal/models"
+	"gorm.io/gorm"
+)
+
+// testRoundTrip ouvre la base décrite par cfg, applique toutes les migrations, vérifie qu'un lien
+// s'y enregistre et que les erreurs du driver sont traduites, puis annule les migrations et les réapplique.
+// Les migrations sont annulées à la fin du test pour laisser la base vide.
+func testRoundTrip(t *testing.T, cfg config.DatabaseConfig) {
+	t.Helper()
+	db, err := database.Open(cfg)
+	if err != nil {
+		t.Fatalf("Open: %v", err)
+	}
+	sqlDB, err := db.DB()
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { sqlDB.Close() })
+
+	migrator := migrations.NewMigrator(db)
+	applied, err := migrator.Up()
+	if err != nil {
+		t.Fatalf("Up: %v", err)
+	}
+	t.Cleanup(func() {
+		if _, err := migrator.Down(len(applied)); err != nil {
+			t.Errorf("Down (nettoyage): %v", err)
+		}
+	})
+	if pending, err := migrator.Pending(); err != nil || len(pending) != 0 {
+		t.Fatalf("Pending après Up : %d migration(s), erreur %v", len(pending), err)
+	}
+
+	if err := db.Create(&models.Link{ShortCode: "roundtrip", LongURL: "https://example.com"}).Error; err != nil {
+		t.Fatalf("création d'un lien : %v", err)
+	}
+	// Les services s'appuient sur gorm.ErrDuplicatedKey pour détecter les alias déjà pris
+	err = db.Create(&models.Link{ShortCode: "roundtrip", LongURL: "https://example.org"}).Error
+	if !errors.Is(err, gorm.ErrDuplicatedKey) {
+		t.Fatalf("doublon de code court : erreur %v, attendu gorm.ErrDuplicatedKey", err)
+	}
+
+	if _, err := migrator.Down(len(applied)); err != nil {
+		t.Fatalf("Down: %v", err)
+	}
+	if db.Migrator().HasTable(&models.Link{}) {
+		t.Fatal("la table links existe encore après l'annulation de toutes les migrations")
+	}
+	if applied, err = migrator.Up(); err != nil {
+		t.Fatalf("Up après Down: %v", err)
+	}
+}
+
+func TestOpenSQLiteRoundTrip(t *testing.T) {
+	testRoundTrip(t, config.DatabaseConfig{Name: filepath.Join(t.TempDir(), "test.db")})
+}
+
+func TestOpenRejectsInvalidConfig(t *testing.T) {
+	tests := []struct {
+		name string
+		cfg  config.DatabaseConfig
+	}{
+		{"driver inconnu", config.DatabaseConfig{Driver: "oracle"}},
+		{"postgres sans DSN", config.DatabaseConfig{Driver: "postgres"}},
+		{"mysql sans DSN", config.DatabaseConfig{Driver: "mysql"}},
+		{"mysql DSN invalide", config.DatabaseConfig{Driver: "mysql", DSN: "localhost:3306"}},
+		{"mysql hors UTC", config.DatabaseConfig{Driver: "mysql", DSN: "app:secret@tcp(localhost:3306)/urls?loc=Europe%2FParis"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := database.Open(tt.cfg); err == nil {
+				t.Fatal("Open a réussi, attendu une erreur")
+			}
+		})
+	}
+}
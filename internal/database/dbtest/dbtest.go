@@ -0,0 +1,106 @@
+// Package dbtest fournit aux tests des bases de données vides pour les drivers autres que SQLite.
+package dbtest
+
+import (
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/axellelanca/urlshortener/internal/config"
+	embeddedpostgres "github.com/fergusstrange/embedded-postgres"
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+)
+
+// PostgresDSNEnv désigne une base PostgreSQL existante à utiliser à la place du serveur embarqué.
+const PostgresDSNEnv = "URLSHORTENER_TEST_POSTGRES_DSN"
+
+// schemaSeq numérote les schémas créés par Postgres au sein d'un même processus de test.
+var schemaSeq atomic.Int64
+
+// Postgres retourne la configuration d'une base PostgreSQL vide, réservée au test et supprimée à sa fin.
+// Sans PostgresDSNEnv, un serveur PostgreSQL embarqué est démarré pour le test ; ses binaires sont
+// téléchargés au premier lancement puis mis en cache. Le test est ignoré si le serveur ne peut pas démarrer
+// (ex: pas d'accès au réseau, ou exécution en root que PostgreSQL refuse).
+// La base est un schéma dédié, choisi par le search_path du DSN.
+func Postgres(t testing.TB) config.DatabaseConfig {
+	t.Helper()
+	dsn := os.Getenv(PostgresDSNEnv)
+	if dsn == "" {
+		dsn = startEmbeddedPostgres(t)
+	}
+
+	db, err := gorm.Open(postgres.Open(dsn), &gorm.Config{})
+	if err != nil {
+		t.Fatalf("connexion à PostgreSQL impossible: %v", err)
+	}
+	sqlDB, err := db.DB()
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { sqlDB.Close() })
+
+	schema := fmt.Sprintf("test_%d_%d", os.Getpid(), schemaSeq.Add(1))
+	if err := db.Exec("CREATE SCHEMA " + schema).Error; err != nil {
+		t.Fatalf("création du schéma %s impossible: %v", schema, err)
+	}
+	// Enregistré avant les nettoyages du test, le schéma est supprimé après la fermeture de ses connexions
+	t.Cleanup(func() {
+		if err := db.Exec("DROP SCHEMA " + schema + " CASCADE").Error; err != nil {
+			t.Errorf("suppression du schéma %s impossible: %v", schema, err)
+		}
+	})
+	return config.DatabaseConfig{Driver: "postgres", DSN: withSearchPath(dsn, schema), MaxOpenConns: 4}
+}
+
+// startEmbeddedPostgres démarre un serveur PostgreSQL arrêté à la fin du test et retourne son DSN.
+func startEmbeddedPostgres(t testing.TB) string {
+	t.Helper()
+	port, err := freePort()
+	if err != nil {
+		t.Fatalf("aucun port libre pour PostgreSQL: %v", err)
+	}
+	dir := t.TempDir()
+	server := embeddedpostgres.NewDatabase(embeddedpostgres.DefaultConfig().
+		Port(port).
+		RuntimePath(filepath.Join(dir, "runtime")).
+		DataPath(filepath.Join(dir, "data")).
+		StartTimeout(time.Minute).
+		Logger(io.Discard))
+	if err := server.Start(); err != nil {
+		t.Skipf("serveur PostgreSQL embarqué indisponible (%v) ; définir %s pour utiliser une base existante", err, PostgresDSNEnv)
+	}
+	t.Cleanup(func() {
+		if err := server.Stop(); err != nil {
+			t.Errorf("arrêt du serveur PostgreSQL embarqué: %v", err)
+		}
+	})
+	return fmt.Sprintf("host=localhost port=%d user=postgres password=postgres dbname=postgres sslmode=disable", port)
+}
+
+// freePort retourne un port TCP libre sur l'interface locale.
+func freePort() (uint32, error) {
+	listener, err := net.Listen("tcp", "localhost:0")
+	if err != nil {
+		return 0, err
+	}
+	defer listener.Close()
+	return uint32(listener.Addr().(*net.TCPAddr).Port), nil
+}
+
+// withSearchPath ajoute le paramètre search_path à un DSN PostgreSQL, sous forme d'URL ou de paires clé=valeur.
+func withSearchPath(dsn, schema string) string {
+	if strings.HasPrefix(dsn, "postgres://") || strings.HasPrefix(dsn, "postgresql://") {
+		if strings.Contains(dsn, "?") {
+			return dsn + "&search_path=" + schema
+		}
+		return dsn + "?search_path=" + schema
+	}
+	return dsn + " search_path=" + schema
+}
@@ -0,0 +1,22 @@
+package database
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestMySQLDSNForcesParseTimeAndUTC(t *testing.T) {
+	dsn, err := mysqlDSN("app:secret@tcp(localhost:3306)/urls?charset=utf8mb4")
+	if err != nil {
+		t.Fatalf("mysqlDSN: %v", err)
+	}
+	if !strings.Contains(dsn, "parseTime=true") {
+		t.Fatalf("DSN %q : parseTime devrait être activé", dsn)
+	}
+	if _, err := mysqlDSN("app:secret@tcp(localhost:3306)/urls?loc=UTC&parseTime=true"); err != nil {
+		t.Fatalf("DSN explicitement en UTC refusé : %v", err)
+	}
+	if _, err := mysqlDSN("app:secret@tcp(localhost:3306)/urls?loc=Local"); err == nil {
+		t.Fatal("un DSN avec loc=Local devrait être refusé")
+	}
+}
@@ -0,0 +1,12 @@
+package database_test
+
+import (
+	"testing"
+
+	"github.com/axellelanca/urlshortener/internal/database/dbtest"
+)
+
+// Utilise un serveur PostgreSQL embarqué, ou la base désignée par URLSHORTENER_TEST_POSTGRES_DSN.
+func TestOpenPostgresRoundTrip(t *testing.T) {
+	testRoundTrip(t, dbtest.Postgres(t))
+}
@@ -0,0 +1,110 @@
+package fingerprint
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log"
+	"sync"
+	"time"
+)
+
+// SaltStore conserve les sels journaliers partagés entre workers et instances.
+// Il est implémenté par repository.GormVisitorSaltRepository.
+type SaltStore interface {
+	GetOrCreateSalt(day string, candidate string) (string, error)
+	DeleteSaltsBefore(day string) error
+}
+
+// dayLayout est le format des jours servant de clé aux sels.
+const dayLayout = "2006-01-02"
+
+// ErrSaltExpired est retournée pour un clic antérieur à la veille (ex: rejoué depuis le spool après
+// un long arrêt) : le sel de son jour a été supprimé et n'est pas recréé, le clic est enregistré sans empreinte.
+var ErrSaltExpired = errors.New("sel du jour supprimé")
+
+// Hasher calcule des empreintes de visiteurs respectueuses de la vie privée :
+// SHA-256(sel du jour + IP + User-Agent). Le sel change chaque jour (UTC) et les anciens sels
+// sont supprimés, si bien qu'une empreinte ne permet ni de retrouver l'IP, ni de suivre
+// un visiteur d'un jour à l'autre.
+type Hasher struct {
+	store SaltStore
+	now   func() time.Time  // Horloge, remplacée dans les tests
+	mu    sync.Mutex        // Protège salts, le Hasher étant partagé par tous les workers
+	salts map[string]string // Cache des sels par jour
+}
+
+// NewHasher crée et retourne un nouveau Hasher s'appuyant sur store.
+func NewHasher(store SaltStore) *Hasher {
+	return &Hasher{
+		store: store,
+		now:   time.Now,
+		salts: make(map[string]string),
+	}
+}
+
+// Fingerprint retourne l'empreinte hexadécimale du visiteur (ip, userAgent) pour le jour de at.
+func (h *Hasher) Fingerprint(ip, userAgent string, at time.Time) (string, error) {
+	salt, err := h.saltFor(at.UTC().Format(dayLayout))
+	if err != nil {
+		return "", err
+	}
+
+	sum := sha256.Sum256([]byte(salt + "|" + ip + "|" + userAgent))
+	return hex.EncodeToString(sum[:]), nil
+}
+
+// HashIP retourne un condensé de l'adresse IP pour le jour de at : SHA-256(sel du jour + IP),
+// tronqué à 128 bits. Deux clics de la même IP le même jour ont le même condensé ; une fois le sel
+// du jour supprimé, il n'est plus possible de retrouver l'adresse, même par force brute.
+func (h *Hasher) HashIP(ip string, at time.Time) (string, error) {
+	salt, err := h.saltFor(at.UTC().Format(dayLayout))
+	if err != nil {
+		return "", err
+	}
+
+	sum := sha256.Sum256([]byte(salt + "|ip|" + ip))
+	return hex.EncodeToString(sum[:16]), nil
+}
+
+// saltFor retourne le sel d'un jour, depuis le cache ou le SaltStore.
+// Seuls les sels du jour et de la veille sont conservés (celui de la veille pour les clics traités juste
+// après minuit) : pour un jour plus ancien, ErrSaltExpired est retournée plutôt que de recréer un sel supprimé.
+// Au premier passage sur un nouveau jour, les sels plus anciens sont supprimés.
+func (h *Hasher) saltFor(day string) (string, error) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	// Le plus ancien jour conservé dépend de l'horloge et non du clic, qui peut être ancien ou daté
+	// dans le futur par une instance à l'horloge décalée
+	oldest := h.now().UTC().AddDate(0, 0, -1).Format(dayLayout)
+	if day < oldest {
+		return "", fmt.Errorf("[Fingerprint] %w: %s", ErrSaltExpired, day)
+	}
+	if salt, ok := h.salts[day]; ok {
+		return salt, nil
+	}
+
+	candidate := make([]byte, 32)
+	if _, err := rand.Read(candidate); err != nil {
+		return "", fmt.Errorf("[Fingerprint] Erreur lors de la génération du sel: %w", err)
+	}
+	salt, err := h.store.GetOrCreateSalt(day, hex.EncodeToString(candidate))
+	if err != nil {
+		return "", fmt.Errorf("[Fingerprint] Erreur lors de la récupération du sel du %s: %w", day, err)
+	}
+
+	if err := h.store.DeleteSaltsBefore(oldest); err != nil {
+		log.Printf("[Fingerprint] Impossible de supprimer les anciens sels : %v", err)
+	}
+	for cached := range h.salts {
+		if cached < oldest {
+			delete(h.salts, cached)
+		}
+	}
+
+	h.salts[day] = salt
+	return salt, nil
+}
@@ -0,0 +1,173 @@
+package fingerprint
+
+import (
+	"errors"
+	"sync"
+	"testing"
+	"time"
+)
+
+// memorySaltStore est un SaltStore en mémoire qui applique les suppressions comme la base.
+type memorySaltStore struct {
+	mu      sync.Mutex
+	salts   map[string]string
+	created []string // Jours dont le sel a été créé, dans l'ordre
+}
+
+func newMemorySaltStore() *memorySaltStore {
+	return &memorySaltStore{salts: make(map[string]string)}
+}
+
+func (m *memorySaltStore) GetOrCreateSalt(day, candidate string) (string, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if salt, ok := m.salts[day]; ok {
+		return salt, nil
+	}
+	m.salts[day] = candidate
+	m.created = append(m.created, day)
+	return candidate, nil
+}
+
+func (m *memorySaltStore) DeleteSaltsBefore(day string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for stored := range m.salts {
+		if stored < day {
+			delete(m.salts, stored)
+		}
+	}
+	return nil
+}
+
+// has indique si le sel de day est conservé.
+func (m *memorySaltStore) has(day string) bool {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	_, ok := m.salts[day]
+	return ok
+}
+
+// newTestHasher crée un Hasher dont l'horloge indique *clock.
+func newTestHasher(store SaltStore, clock *time.Time) *Hasher {
+	h := NewHasher(store)
+	h.now = func() time.Time { return *clock }
+	return h
+}
+
+// mustFingerprint calcule une empreinte en échouant le test en cas d'erreur.
+func mustFingerprint(t *testing.T, h *Hasher, ip, userAgent string, at time.Time) string {
+	t.Helper()
+	fingerprint, err := h.Fingerprint(ip, userAgent, at)
+	if err != nil {
+		t.Fatalf("Fingerprint: %v", err)
+	}
+	return fingerprint
+}
+
+const testUserAgent = "Mozilla/5.0 (X11; Linux x86_64; rv:128.0) Gecko/20100101 Firefox/128.0"
+
+func TestFingerprintStableWithinDay(t *testing.T) {
+	clock := time.Date(2026, time.October, 12, 23, 0, 0, 0, time.UTC)
+	h := newTestHasher(newMemorySaltStore(), &clock)
+
+	morning := mustFingerprint(t, h, "203.0.113.7", testUserAgent, time.Date(2026, time.October, 12, 0, 5, 0, 0, time.UTC))
+	// Même jour UTC, exprimé dans un autre fuseau
+	evening := mustFingerprint(t, h, "203.0.113.7", testUserAgent, time.Date(2026, time.October, 13, 1, 0, 0, 0, time.FixedZone("CEST", 2*60*60)))
+	if morning != evening {
+		t.Fatalf("empreintes différentes le même jour : %s et %s", morning, evening)
+	}
+	if len(morning) != 64 {
+		t.Fatalf("empreinte %q : attendu 64 caractères hexadécimaux", morning)
+	}
+	if other := mustFingerprint(t, h, "203.0.113.8", testUserAgent, clock); other == morning {
+		t.Fatal("deux adresses différentes ont la même empreinte")
+	}
+	if other := mustFingerprint(t, h, "203.0.113.7", "curl/8.0", clock); other == morning {
+		t.Fatal("deux User-Agent différents ont la même empreinte")
+	}
+}
+
+func TestFingerprintChangesAcrossDays(t *testing.T) {
+	clock := time.Date(2026, time.October, 13, 9, 0, 0, 0, time.UTC)
+	h := newTestHasher(newMemorySaltStore(), &clock)
+
+	yesterday := mustFingerprint(t, h, "203.0.113.7", testUserAgent, time.Date(2026, time.October, 12, 23, 59, 0, 0, time.UTC))
+	today := mustFingerprint(t, h, "203.0.113.7", testUserAgent, time.Date(2026, time.October, 13, 0, 0, 0, 0, time.UTC))
+	if yesterday == today {
+		t.Fatal("l'empreinte d'un visiteur ne devrait pas être la même d'un jour à l'autre")
+	}
+}
+
+func TestFingerprintSharedAcrossInstances(t *testing.T) {
+	clock := time.Date(2026, time.October, 13, 9, 0, 0, 0, time.UTC)
+	store := newMemorySaltStore()
+	first := mustFingerprint(t, newTestHasher(store, &clock), "203.0.113.7", testUserAgent, clock)
+	second := mustFingerprint(t, newTestHasher(store, &clock), "203.0.113.7", testUserAgent, clock)
+	if first != second {
+		t.Fatal("deux instances partageant le même SaltStore devraient calculer la même empreinte")
+	}
+}
+
+func TestSaltRotation(t *testing.T) {
+	store := newMemorySaltStore()
+	clock := time.Date(2026, time.October, 12, 9, 0, 0, 0, time.UTC)
+	h := newTestHasher(store, &clock)
+
+	oct11 := time.Date(2026, time.October, 11, 12, 0, 0, 0, time.UTC)
+	oct12 := time.Date(2026, time.October, 12, 12, 0, 0, 0, time.UTC)
+	mustFingerprint(t, h, "203.0.113.7", testUserAgent, oct11)
+	mustFingerprint(t, h, "203.0.113.7", testUserAgent, oct12)
+	if !store.has("2026-10-11") || !store.has("2026-10-12") {
+		t.Fatal("les sels du jour et de la veille devraient être conservés")
+	}
+
+	// Deux jours plus tard, le sel du 11 est supprimé au premier clic du jour
+	clock = time.Date(2026, time.October, 14, 0, 1, 0, 0, time.UTC)
+	mustFingerprint(t, h, "203.0.113.7", testUserAgent, clock)
+	if store.has("2026-10-11") || store.has("2026-10-12") {
+		t.Fatal("les sels antérieurs à la veille devraient être supprimés")
+	}
+
+	// Un clic du 11 rejoué après coup ne recrée pas le sel supprimé
+	_, err := h.Fingerprint("203.0.113.7", testUserAgent, oct11)
+	if !errors.Is(err, ErrSaltExpired) {
+		t.Fatalf("Fingerprint d'un jour expiré = %v, attendu ErrSaltExpired", err)
+	}
+	if _, err := h.HashIP("203.0.113.7", oct11); !errors.Is(err, ErrSaltExpired) {
+		t.Fatalf("HashIP d'un jour expiré = %v, attendu ErrSaltExpired", err)
+	}
+	if store.has("2026-10-11") {
+		t.Fatal("le sel du 11 a été recréé")
+	}
+	if count := countDay(store.created, "2026-10-11"); count != 1 {
+		t.Fatalf("sel du 11 créé %d fois, attendu 1", count)
+	}
+}
+
+func TestFutureDayKeepsTodaysSalt(t *testing.T) {
+	store := newMemorySaltStore()
+	clock := time.Date(2026, time.October, 12, 23, 59, 0, 0, time.UTC)
+	h := newTestHasher(store, &clock)
+
+	today := mustFingerprint(t, h, "203.0.113.7", testUserAgent, clock)
+	// Clic daté du lendemain par une instance dont l'horloge avance
+	mustFingerprint(t, h, "203.0.113.7", testUserAgent, clock.Add(2*time.Minute))
+	if !store.has("2026-10-12") {
+		t.Fatal("le sel du jour a été supprimé par un clic daté du lendemain")
+	}
+	if again := mustFingerprint(t, h, "203.0.113.7", testUserAgent, clock); again != today {
+		t.Fatal("l'empreinte du jour a changé")
+	}
+}
+
+// countDay compte les occurrences de day dans days.
+func countDay(days []string, day string) int {
+	count := 0
+	for _, d := range days {
+		if d == day {
+			count++
+		}
+	}
+	return count
+}
@@ -0,0 +1,89 @@
+package geoip
+
+import (
+	"fmt"
+	"net"
+	"os"
+
+	"github.com/oschwald/maxminddb-golang"
+)
+
+// namesLanguage est la langue utilisée pour les noms de régions et de villes.
+const namesLanguage = "en"
+
+// Location contient la position géographique déduite d'une adresse IP.
+// Les champs sont vides lorsque l'adresse n'est pas présente dans la base.
+type Location struct {
+	Country string // Code ISO 3166-1 alpha-2, ex: "FR"
+	Region  string // Première subdivision (région, état...), ex: "Île-de-France"
+	City    string // ex: "Paris"
+}
+
+// record reprend le sous-ensemble utile des enregistrements GeoIP2/GeoLite2 City et Country.
+type record struct {
+	Country struct {
+		ISOCode string `maxminddb:"iso_code"`
+	} `maxminddb:"country"`
+	Subdivisions []struct {
+		Names map[string]string `maxminddb:"names"`
+	} `maxminddb:"subdivisions"`
+	City struct {
+		Names map[string]string `maxminddb:"names"`
+	} `maxminddb:"city"`
+}
+
+// Resolver géolocalise des adresses IP à partir d'une base MaxMind (MMDB) locale, sans appel réseau.
+// Il peut être utilisé par plusieurs goroutines. Un Resolver nil est valide et ne géolocalise rien.
+type Resolver struct {
+	reader *maxminddb.Reader
+}
+
+// Open ouvre la base MMDB située à path. Un chemin vide retourne un Resolver nil (géolocalisation désactivée) ;
+// une base absente retourne une erreur satisfaisant errors.Is(err, os.ErrNotExist).
+func Open(path string) (*Resolver, error) {
+	if path == "" {
+		return nil, nil
+	}
+	if _, err := os.Stat(path); err != nil {
+		return nil, fmt.Errorf("[GeoIP] base '%s' inaccessible: %w", path, err)
+	}
+	reader, err := maxminddb.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("[GeoIP] base '%s' invalide: %w", path, err)
+	}
+	return &Resolver{reader: reader}, nil
+}
+
+// Lookup retourne la position associée à une adresse IP. Une adresse invalide, privée
+// ou absente de la base retourne une Location vide.
+func (r *Resolver) Lookup(ip string) (Location, error) {
+	if r == nil {
+		return Location{}, nil
+	}
+	parsed := net.ParseIP(ip)
+	if parsed == nil {
+		return Location{}, nil
+	}
+
+	var rec record
+	if err := r.reader.Lookup(parsed, &rec); err != nil {
+		return Location{}, fmt.Errorf("[GeoIP] recherche de '%s' impossible: %w", ip, err)
+	}
+
+	location := Location{
+		Country: rec.Country.ISOCode,
+		City:    rec.City.Names[namesLanguage],
+	}
+	if len(rec.Subdivisions) > 0 {
+		location.Region = rec.Subdivisions[0].Names[namesLanguage]
+	}
+	return location, nil
+}
+
+// Close libère la base. Sans effet sur un Resolver nil.
+func (r *Resolver) Close() error {
+	if r == nil {
+		return nil
+	}
+	return r.reader.Close()
+}
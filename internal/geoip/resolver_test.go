@@ -0,0 +1,117 @@
+package geoip
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+//go:generate go run -C testdata/generate . ../GeoIP2-City-Test.mmdb
+
+// testDatabase est une petite base au format GeoIP2 City générée par testdata/generate.
+const testDatabase = "testdata/GeoIP2-City-Test.mmdb"
+
+func openTestResolver(t *testing.T) *Resolver {
+	t.Helper()
+	resolver, err := Open(testDatabase)
+	if err != nil {
+		t.Fatalf("Open(%q) : %v", testDatabase, err)
+	}
+	t.Cleanup(func() { resolver.Close() })
+	return resolver
+}
+
+func TestLookup(t *testing.T) {
+	resolver := openTestResolver(t)
+	tests := []struct {
+		name string
+		ip   string
+		want Location
+	}{
+		{"ville", "81.2.69.142", Location{Country: "GB", Region: "England", City: "London"}},
+		{"pays seul", "89.160.20.112", Location{Country: "SE"}},
+		{"IPv6", "2a02:cf40::1", Location{Country: "FR", Region: "Île-de-France", City: "Paris"}},
+		{"adresse inconnue", "8.8.8.8", Location{}},
+		{"IPv6 inconnue", "2001:4860:4860::8888", Location{}},
+		{"adresse privée", "10.0.0.1", Location{}},
+		{"boucle locale", "::1", Location{}},
+		{"adresse invalide", "not-an-ip", Location{}},
+		{"adresse vide", "", Location{}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := resolver.Lookup(tt.ip)
+			if err != nil {
+				t.Fatalf("Lookup(%q) : %v", tt.ip, err)
+			}
+			if got != tt.want {
+				t.Errorf("Lookup(%q) = %+v, attendu %+v", tt.ip, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestOpenWithoutPathDisablesLookups(t *testing.T) {
+	resolver, err := Open("")
+	if err != nil || resolver != nil {
+		t.Fatalf("Open(\"\") = %v, %v ; attendu nil, nil", resolver, err)
+	}
+	if location, err := resolver.Lookup("81.2.69.142"); err != nil || location != (Location{}) {
+		t.Fatalf("Lookup sur un Resolver nil = %+v, %v ; attendu une position vide", location, err)
+	}
+	if err := resolver.Close(); err != nil {
+		t.Fatalf("Close sur un Resolver nil : %v", err)
+	}
+}
+
+func TestOpenMissingDatabase(t *testing.T) {
+	_, err := Open(filepath.Join(t.TempDir(), "absente.mmdb"))
+	if !errors.Is(err, os.ErrNotExist) {
+		t.Fatalf("Open d'une base absente : %v, attendu une erreur os.ErrNotExist", err)
+	}
+}
+
+func TestOpenInvalidDatabase(t *testing.T) {
+	tests := []struct {
+		name    string
+		content []byte
+	}{
+		{"fichier vide", nil},
+		{"fichier quelconque", []byte("ceci n'est pas une base MaxMind")},
+		{"base tronquée", truncatedDatabase(t)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "invalide.mmdb")
+			if err := os.WriteFile(path, tt.content, 0o600); err != nil {
+				t.Fatal(err)
+			}
+			resolver, err := Open(path)
+			if err == nil {
+				resolver.Close()
+				t.Fatal("Open a réussi, attendu une erreur")
+			}
+			if errors.Is(err, os.ErrNotExist) {
+				t.Fatalf("une base invalide ne doit pas être signalée comme absente : %v", err)
+			}
+		})
+	}
+}
+
+func TestOpenDirectory(t *testing.T) {
+	if resolver, err := Open(t.TempDir()); err == nil {
+		resolver.Close()
+		t.Fatal("Open d'un répertoire a réussi, attendu une erreur")
+	}
+}
+
+// truncatedDatabase retourne la base de test privée de ses métadonnées, placées en fin de fichier.
+func truncatedDatabase(t *testing.T) []byte {
+	t.Helper()
+	content, err := os.ReadFile(testDatabase)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return content[:len(content)/2]
+}
@@ -0,0 +1,79 @@
+// Command generate écrit la base MMDB de test du package geoip (testdata/GeoIP2-City-Test.mmdb).
+// Elle vit dans son propre module pour que mmdbwriter ne devienne pas une dépendance de l'application.
+//
+//	go generate ./internal/geoip
+package main
+
+import (
+	"fmt"
+	"net"
+	"os"
+
+	"github.com/maxmind/mmdbwriter"
+	"github.com/maxmind/mmdbwriter/mmdbtype"
+)
+
+// networks reprend des réseaux de la base de test officielle de MaxMind. L'ordre est fixe pour que
+// le fichier généré soit reproductible.
+var networks = []struct {
+	cidr   string
+	record mmdbtype.Map
+}{
+	// Enregistrement complet : pays, région et ville
+	{"81.2.69.0/24", mmdbtype.Map{
+		"country":      mmdbtype.Map{"iso_code": mmdbtype.String("GB")},
+		"subdivisions": mmdbtype.Slice{mmdbtype.Map{"names": mmdbtype.Map{"en": mmdbtype.String("England"), "fr": mmdbtype.String("Angleterre")}}},
+		"city":         mmdbtype.Map{"names": mmdbtype.Map{"en": mmdbtype.String("London"), "fr": mmdbtype.String("Londres")}},
+	}},
+	// Pays seul, comme dans une base GeoLite2 Country
+	{"89.160.20.0/24", mmdbtype.Map{
+		"country": mmdbtype.Map{"iso_code": mmdbtype.String("SE")},
+	}},
+	// Réseau IPv6
+	{"2a02:cf40::/29", mmdbtype.Map{
+		"country":      mmdbtype.Map{"iso_code": mmdbtype.String("FR")},
+		"subdivisions": mmdbtype.Slice{mmdbtype.Map{"names": mmdbtype.Map{"en": mmdbtype.String("Île-de-France")}}},
+		"city":         mmdbtype.Map{"names": mmdbtype.Map{"en": mmdbtype.String("Paris")}},
+	}},
+}
+
+func main() {
+	if len(os.Args) != 2 {
+		fmt.Fprintln(os.Stderr, "usage : generate <fichier.mmdb>")
+		os.Exit(2)
+	}
+	if err := run(os.Args[1]); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+}
+
+func run(path string) error {
+	writer, err := mmdbwriter.New(mmdbwriter.Options{
+		DatabaseType: "GeoIP2-City-Test",
+		Description:  map[string]string{"en": "urlshortener geoip test database"},
+		RecordSize:   24,
+	})
+	if err != nil {
+		return err
+	}
+	for _, entry := range networks {
+		_, network, err := net.ParseCIDR(entry.cidr)
+		if err != nil {
+			return err
+		}
+		if err := writer.Insert(network, entry.record); err != nil {
+			return fmt.Errorf("insertion de %s : %w", entry.cidr, err)
+		}
+	}
+
+	file, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	if _, err := writer.WriteTo(file); err != nil {
+		file.Close()
+		return err
+	}
+	return file.Close()
+}
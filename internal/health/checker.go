@@ -0,0 +1,190 @@
+package health
+
+import (
+	"context"
+	"time"
+
+	"github.com/axellelanca/urlshortener/internal/models"
+	"github.com/axellelanca/urlshortener/internal/monitor"
+	"github.com/axellelanca/urlshortener/internal/workers"
+	"gorm.io/gorm"
+)
+
+// États d'un composant et de l'instance.
+const (
+	StatusOK          = "ok"
+	StatusUnavailable = "unavailable" // Composant critique en défaut : l'instance n'est pas prête
+	StatusDegraded    = "degraded"    // Composant non critique en défaut, sans effet sur la disponibilité
+)
+
+// Valeurs appliquées lorsque les options sont absentes ou invalides.
+const (
+	defaultDBTimeout              = 2 * time.Second
+	defaultQueueSaturationPercent = 90
+	defaultWorkerStallTimeout     = 30 * time.Second
+)
+
+// Options règle les seuils des vérifications de disponibilité.
+type Options struct {
+	DBTimeout              time.Duration // Délai maximal du ping de la base de données
+	QueueSaturationPercent int           // Remplissage du channel de clics au-delà duquel l'instance n'est plus prête
+	WorkerStallTimeout     time.Duration // Inactivité des workers au-delà de laquelle ils sont considérés comme bloqués
+	Spooled                bool          // Avec le spool, un channel plein ne fait perdre aucun clic
+}
+
+// Component décrit l'état d'un composant dans la réponse de disponibilité.
+type Component struct {
+	Status  string         `json:"status"`
+	Message string         `json:"message,omitempty"`
+	Details map[string]any `json:"details,omitempty"`
+}
+
+// Report est le résultat d'une vérification de disponibilité.
+type Report struct {
+	Status     string               `json:"status"`
+	CheckedAt  time.Time            `json:"checked_at"`
+	Components map[string]Component `json:"components"`
+}
+
+// Ready indique si l'instance peut recevoir du trafic.
+func (r Report) Ready() bool {
+	return r.Status != StatusUnavailable
+}
+
+// Checker vérifie la disponibilité des composants dont dépend le service : base de données,
+// channel des événements de clic, workers et moniteur d'URLs.
+type Checker struct {
+	db         *gorm.DB
+	queue      chan *models.ClickEvent
+	workerPool *workers.WorkerPool
+	urlMonitor *monitor.UrlMonitor
+	opts       Options
+}
+
+// NewChecker crée un Checker. Les composants nil ne sont pas vérifiés.
+func NewChecker(db *gorm.DB, queue chan *models.ClickEvent, workerPool *workers.WorkerPool, urlMonitor *monitor.UrlMonitor, opts Options) *Checker {
+	if opts.DBTimeout <= 0 {
+		opts.DBTimeout = defaultDBTimeout
+	}
+	if opts.QueueSaturationPercent <= 0 || opts.QueueSaturationPercent > 100 {
+		opts.QueueSaturationPercent = defaultQueueSaturationPercent
+	}
+	if opts.WorkerStallTimeout <= 0 {
+		opts.WorkerStallTimeout = defaultWorkerStallTimeout
+	}
+	return &Checker{db: db, queue: queue, workerPool: workerPool, urlMonitor: urlMonitor, opts: opts}
+}
+
+// Check vérifie chaque composant. L'instance n'est pas prête si un composant critique
+// (base, channel de clics, workers) est en défaut ; un moniteur en retard la rend seulement dégradée.
+func (c *Checker) Check(ctx context.Context) Report {
+	now := time.Now()
+	report := Report{Status: StatusOK, CheckedAt: now.UTC(), Components: make(map[string]Component)}
+
+	add := func(name string, component Component) {
+		report.Components[name] = component
+		switch {
+		case component.Status == StatusUnavailable:
+			report.Status = StatusUnavailable
+		case component.Status == StatusDegraded && report.Status == StatusOK:
+			report.Status = StatusDegraded
+		}
+	}
+
+	if c.db != nil {
+		add("database", c.checkDatabase(ctx))
+	}
+	if c.queue != nil {
+		add("click_queue", c.checkQueue())
+	}
+	if c.workerPool != nil {
+		add("workers", c.checkWorkers(now))
+	}
+	if c.urlMonitor != nil {
+		add("monitor", c.checkMonitor(now))
+	}
+	return report
+}
+
+// checkDatabase vérifie que la base répond à un ping dans le délai imparti.
+func (c *Checker) checkDatabase(ctx context.Context) Component {
+	sqlDB, err := c.db.DB()
+	if err != nil {
+		return Component{Status: StatusUnavailable, Message: err.Error()}
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, c.opts.DBTimeout)
+	defer cancel()
+	start := time.Now()
+	if err := sqlDB.PingContext(ctx); err != nil {
+		return Component{Status: StatusUnavailable, Message: err.Error()}
+	}
+	return Component{Status: StatusOK, Details: map[string]any{
+		"latency_ms": time.Since(start).Milliseconds(),
+	}}
+}
+
+// checkQueue vérifie que le channel des événements de clic n'est pas saturé : sans spool,
+// les clics suivants seraient perdus. Avec le spool, un channel plein est seulement signalé.
+func (c *Checker) checkQueue() Component {
+	depth, capacity := len(c.queue), cap(c.queue)
+	component := Component{Status: StatusOK, Details: map[string]any{
+		"depth":    depth,
+		"capacity": capacity,
+	}}
+	if capacity > 0 && depth*100 >= capacity*c.opts.QueueSaturationPercent {
+		component.Message = "channel des clics saturé"
+		if c.opts.Spooled {
+			component.Status = StatusDegraded
+		} else {
+			component.Status = StatusUnavailable
+		}
+	}
+	return component
+}
+
+// checkWorkers vérifie que tous les workers tournent et qu'ils se sont manifestés récemment.
+func (c *Checker) checkWorkers(now time.Time) Component {
+	alive, size := c.workerPool.Alive(), c.workerPool.Size()
+	heartbeat := c.workerPool.LastHeartbeat()
+	component := Component{Status: StatusOK, Details: map[string]any{
+		"alive":          alive,
+		"total":          size,
+		"last_heartbeat": heartbeat.UTC(),
+	}}
+
+	// Un worker se manifeste au moins une fois par intervalle de flush, lot écrit compris
+	stallTimeout := max(c.opts.WorkerStallTimeout, 3*c.workerPool.FlushInterval())
+	switch {
+	case alive < size:
+		component.Status = StatusUnavailable
+		component.Message = "des workers se sont arrêtés"
+	case now.Sub(heartbeat) > stallTimeout:
+		component.Status = StatusUnavailable
+		component.Message = "aucune activité des workers depuis " + now.Sub(heartbeat).Round(time.Second).String()
+	}
+	return component
+}
+
+// checkMonitor indique la date de la dernière vérification du moniteur d'URLs. Le moniteur n'intervient
+// pas dans le traitement des requêtes : un retard rend l'instance dégradée mais toujours prête.
+func (c *Checker) checkMonitor(now time.Time) Component {
+	interval := c.urlMonitor.Interval()
+	component := Component{Status: StatusOK, Details: map[string]any{
+		"interval": interval.String(),
+	}}
+
+	lastRun := c.urlMonitor.LastRun()
+	if lastRun.IsZero() {
+		component.Details["last_run_at"] = nil
+		component.Message = "aucune vérification terminée"
+		return component
+	}
+	component.Details["last_run_at"] = lastRun.UTC()
+	// Une vérification peut durer : le moniteur est en retard s'il a manqué plus d'un passage
+	if now.Sub(lastRun) > 2*interval {
+		component.Status = StatusDegraded
+		component.Message = "dernière vérification il y a " + now.Sub(lastRun).Round(time.Second).String()
+	}
+	return component
+}
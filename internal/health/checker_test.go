@@ -0,0 +1,220 @@
+package health
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/axellelanca/urlshortener/internal/anonymize"
+	"github.com/axellelanca/urlshortener/internal/config"
+	"github.com/axellelanca/urlshortener/internal/database"
+	"github.com/axellelanca/urlshortener/internal/fingerprint"
+	"github.com/axellelanca/urlshortener/internal/models"
+	"github.com/axellelanca/urlshortener/internal/monitor"
+	"github.com/axellelanca/urlshortener/internal/repository"
+	"github.com/axellelanca/urlshortener/internal/workers"
+	"gorm.io/gorm"
+)
+
+// hangingConnector simule une base qui ne répond pas : chaque connexion attend l'expiration du contexte.
+type hangingConnector struct{}
+
+func (hangingConnector) Connect(ctx context.Context) (driver.Conn, error) {
+	<-ctx.Done()
+	return nil, ctx.Err()
+}
+
+func (hangingConnector) Driver() driver.Driver { return nil }
+
+// blockingClickRepository bloque chaque écriture de lot jusqu'à la fermeture de release.
+type blockingClickRepository struct {
+	repository.ClickRepository
+	release chan struct{}
+}
+
+func (r *blockingClickRepository) CreateClicks([]models.Click) error {
+	<-r.release
+	return nil
+}
+
+// memorySaltStore est un fingerprint.SaltStore en mémoire pour les tests.
+type memorySaltStore map[string]string
+
+func (m memorySaltStore) GetOrCreateSalt(day, candidate string) (string, error) {
+	if salt, ok := m[day]; ok {
+		return salt, nil
+	}
+	m[day] = candidate
+	return candidate, nil
+}
+
+func (m memorySaltStore) DeleteSaltsBefore(string) error { return nil }
+
+// startTestWorkers démarre un worker qui écrit ses lots dans repo toutes les 10 ms.
+func startTestWorkers(t *testing.T, events chan *models.ClickEvent, repo repository.ClickRepository) *workers.WorkerPool {
+	t.Helper()
+	anonymizer, err := anonymize.NewAnonymizer(anonymize.ModeNone, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	enricher := workers.NewClickEnricher(nil, fingerprint.NewHasher(memorySaltStore{}), nil, anonymizer)
+	return workers.StartClickWorkers(1, events, repo, enricher, workers.BatchConfig{Size: 1, FlushInterval: 10 * time.Millisecond}, nil)
+}
+
+// waitForStatus vérifie le composant name jusqu'à ce qu'il atteigne le statut want.
+func waitForStatus(t *testing.T, checker *Checker, name, want string) Report {
+	t.Helper()
+	deadline := time.Now().Add(2 * time.Second)
+	for {
+		report := checker.Check(context.Background())
+		if report.Components[name].Status == want {
+			return report
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("composant %s : %+v, attendu le statut %s", name, report.Components[name], want)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func TestCheckDatabase(t *testing.T) {
+	db, err := database.Open(config.DatabaseConfig{Name: filepath.Join(t.TempDir(), "test.db")})
+	if err != nil {
+		t.Fatal(err)
+	}
+	sqlDB, err := db.DB()
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { sqlDB.Close() })
+
+	report := NewChecker(db, nil, nil, nil, Options{}).Check(context.Background())
+	if report.Status != StatusOK || !report.Ready() || report.Components["database"].Status != StatusOK {
+		t.Fatalf("rapport %+v, attendu ok", report)
+	}
+
+	sqlDB.Close()
+	report = NewChecker(db, nil, nil, nil, Options{}).Check(context.Background())
+	if report.Ready() || report.Components["database"].Status != StatusUnavailable {
+		t.Fatalf("base fermée : rapport %+v, attendu indisponible", report)
+	}
+}
+
+func TestCheckDatabaseTimeout(t *testing.T) {
+	hanging := sql.OpenDB(hangingConnector{})
+	t.Cleanup(func() { hanging.Close() })
+	db := &gorm.DB{Config: &gorm.Config{ConnPool: hanging}}
+
+	start := time.Now()
+	report := NewChecker(db, nil, nil, nil, Options{DBTimeout: 50 * time.Millisecond}).Check(context.Background())
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Fatalf("vérification terminée en %v, le délai de 50 ms n'a pas été appliqué", elapsed)
+	}
+	component := report.Components["database"]
+	if report.Ready() || component.Status != StatusUnavailable {
+		t.Fatalf("base sans réponse : rapport %+v, attendu indisponible", report)
+	}
+	if component.Message != context.DeadlineExceeded.Error() {
+		t.Fatalf("message %q, attendu %q", component.Message, context.DeadlineExceeded.Error())
+	}
+}
+
+func TestCheckQueueSaturation(t *testing.T) {
+	tests := []struct {
+		name    string
+		depth   int
+		spooled bool
+		want    string
+	}{
+		{"sous le seuil", 7, false, StatusOK},
+		{"au seuil", 8, false, StatusUnavailable},
+		{"plein", 10, false, StatusUnavailable},
+		{"au seuil avec spool", 8, true, StatusDegraded},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			queue := make(chan *models.ClickEvent, 10)
+			for i := 0; i < tt.depth; i++ {
+				queue <- &models.ClickEvent{}
+			}
+			report := NewChecker(nil, queue, nil, nil, Options{QueueSaturationPercent: 80, Spooled: tt.spooled}).Check(context.Background())
+			component := report.Components["click_queue"]
+			if component.Status != tt.want || report.Status != tt.want {
+				t.Fatalf("%d/10 : composant %+v, statut %s ; attendu %s", tt.depth, component, report.Status, tt.want)
+			}
+			if component.Details["depth"] != tt.depth || component.Details["capacity"] != 10 {
+				t.Fatalf("détails %+v", component.Details)
+			}
+		})
+	}
+
+	// Un seuil invalide vaut la valeur par défaut (90 %)
+	queue := make(chan *models.ClickEvent, 10)
+	for i := 0; i < 8; i++ {
+		queue <- &models.ClickEvent{}
+	}
+	if report := NewChecker(nil, queue, nil, nil, Options{QueueSaturationPercent: 150}).Check(context.Background()); report.Status != StatusOK {
+		t.Fatalf("8/10 avec le seuil par défaut : statut %s, attendu ok", report.Status)
+	}
+}
+
+func TestCheckWorkersStall(t *testing.T) {
+	repo := &blockingClickRepository{release: make(chan struct{})}
+	events := make(chan *models.ClickEvent, 10)
+	pool := startTestWorkers(t, events, repo)
+	t.Cleanup(func() {
+		close(events)
+		pool.Wait(time.Second)
+	})
+	checker := NewChecker(nil, nil, pool, nil, Options{WorkerStallTimeout: 50 * time.Millisecond})
+
+	if report := checker.Check(context.Background()); report.Components["workers"].Status != StatusOK {
+		t.Fatalf("workers inactifs mais disponibles : %+v, attendu ok", report.Components["workers"])
+	}
+
+	// Le worker reste bloqué sur l'écriture de son lot : il ne se manifeste plus
+	events <- &models.ClickEvent{LinkID: 1, Timestamp: time.Now()}
+	report := waitForStatus(t, checker, "workers", StatusUnavailable)
+	if report.Ready() {
+		t.Fatal("l'instance ne devrait pas être prête avec des workers bloqués")
+	}
+
+	close(repo.release)
+	waitForStatus(t, checker, "workers", StatusOK)
+}
+
+func TestCheckWorkersStopped(t *testing.T) {
+	repo := &blockingClickRepository{release: make(chan struct{})}
+	close(repo.release)
+	events := make(chan *models.ClickEvent, 10)
+	pool := startTestWorkers(t, events, repo)
+	close(events)
+	if !pool.Wait(time.Second) {
+		t.Fatal("les workers ne se sont pas arrêtés")
+	}
+
+	report := NewChecker(nil, nil, pool, nil, Options{}).Check(context.Background())
+	component := report.Components["workers"]
+	if report.Ready() || component.Status != StatusUnavailable || component.Details["alive"] != 0 {
+		t.Fatalf("workers arrêtés : %+v, attendu indisponible", component)
+	}
+}
+
+func TestCheckMonitorNeverRunIsNotAnError(t *testing.T) {
+	urlMonitor := monitor.NewUrlMonitor(nil, time.Minute, nil)
+	report := NewChecker(nil, nil, nil, urlMonitor, Options{}).Check(context.Background())
+	component := report.Components["monitor"]
+	if report.Status != StatusOK || component.Status != StatusOK || component.Message == "" {
+		t.Fatalf("moniteur sans vérification terminée : %+v, attendu ok avec un message", component)
+	}
+}
+
+func TestCheckWithoutComponents(t *testing.T) {
+	report := NewChecker(nil, nil, nil, nil, Options{}).Check(context.Background())
+	if report.Status != StatusOK || len(report.Components) != 0 {
+		t.Fatalf("rapport %+v, attendu ok sans composant", report)
+	}
+}
@@ -0,0 +1,156 @@
+package metrics
+
+import (
+	"net/http"
+	"sync/atomic"
+
+	"github.com/axellelanca/urlshortener/internal/models"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+// namespace préfixe toutes les métriques de l'application.
+const namespace = "urlshortener"
+
+// Raisons de perte d'un événement de clic.
+const (
+	DropQueueFull  = "queue_full"  // Channel plein, spool désactivé
+	DropSpoolError = "spool_error" // Échec d'écriture dans le spool, puis channel plein
+)
+
+// Registry regroupe les métriques exposées par Handler. Un registre dédié (plutôt que le registre
+// global de client_golang) évite les conflits d'enregistrement et permet de l'interroger en test.
+var Registry = prometheus.NewRegistry()
+
+var (
+	// RedirectsTotal compte les redirections servies, par code de statut HTTP.
+	RedirectsTotal = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "redirects_total",
+		Help:      "Nombre de requêtes de redirection, par code de statut HTTP.",
+	}, []string{"status"})
+
+	// RedirectDuration mesure la latence des redirections, par code de statut HTTP.
+	RedirectDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "redirect_duration_seconds",
+		Help:      "Latence des requêtes de redirection, par code de statut HTTP.",
+		Buckets:   []float64{.0005, .001, .0025, .005, .01, .025, .05, .1, .25, .5, 1},
+	}, []string{"status"})
+
+	// LinksCreatedTotal compte les liens créés.
+	LinksCreatedTotal = prometheus.NewCounter(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "links_created_total",
+		Help:      "Nombre de liens courts créés.",
+	})
+
+	// ClickEventsDroppedTotal compte les événements de clic perdus, par raison.
+	ClickEventsDroppedTotal = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "click_events_dropped_total",
+		Help:      "Nombre d'événements de clic perdus avant leur persistance, par raison.",
+	}, []string{"reason"})
+
+	// ClicksPersistedTotal compte les clics enregistrés en base par les workers.
+	ClicksPersistedTotal = prometheus.NewCounter(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "clicks_persisted_total",
+		Help:      "Nombre de clics enregistrés en base par les workers.",
+	})
+
+	// ClickPersistErrorsTotal compte les clics que les workers n'ont pas pu enregistrer.
+	ClickPersistErrorsTotal = prometheus.NewCounter(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "click_persist_errors_total",
+		Help:      "Nombre de clics que les workers n'ont pas pu enregistrer en base.",
+	})
+
+	// LinkCacheRequestsTotal compte les consultations du cache des liens, réussies (hit) ou non (miss).
+	LinkCacheRequestsTotal = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "link_cache_requests_total",
+		Help:      "Consultations du cache de résolution des codes courts, par résultat (hit ou miss).",
+	}, []string{"result"})
+
+	// LinkCacheEntries donne le nombre d'entrées du cache des liens.
+	LinkCacheEntries = prometheus.NewGauge(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Name:      "link_cache_entries",
+		Help:      "Nombre d'entrées (liens et codes inconnus) du cache de résolution des codes courts.",
+	})
+
+	// MonitorCheckDuration mesure la durée des vérifications d'accessibilité des URLs longues.
+	MonitorCheckDuration = prometheus.NewHistogram(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "monitor_check_duration_seconds",
+		Help:      "Durée de la vérification d'accessibilité d'une URL longue.",
+		Buckets:   prometheus.DefBuckets,
+	})
+
+	// MonitorLinks donne le nombre d'URLs longues accessibles (up) et inaccessibles (down)
+	// lors de la dernière vérification.
+	MonitorLinks = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Name:      "monitor_links",
+		Help:      "Nombre d'URLs longues accessibles ou non lors de la dernière vérification du moniteur.",
+	}, []string{"state"})
+)
+
+// clickQueue est le channel des événements de clic observé par les jauges de file d'attente.
+var clickQueue atomic.Pointer[chan *models.ClickEvent]
+
+func init() {
+	Registry.MustRegister(
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+		RedirectsTotal,
+		RedirectDuration,
+		LinksCreatedTotal,
+		ClickEventsDroppedTotal,
+		ClicksPersistedTotal,
+		ClickPersistErrorsTotal,
+		LinkCacheRequestsTotal,
+		LinkCacheEntries,
+		MonitorCheckDuration,
+		MonitorLinks,
+		prometheus.NewGaugeFunc(prometheus.GaugeOpts{
+			Namespace: namespace,
+			Name:      "click_queue_depth",
+			Help:      "Nombre d'événements de clic en attente dans le channel des workers.",
+		}, func() float64 { return queueStat(func(ch chan *models.ClickEvent) int { return len(ch) }) }),
+		prometheus.NewGaugeFunc(prometheus.GaugeOpts{
+			Namespace: namespace,
+			Name:      "click_queue_capacity",
+			Help:      "Capacité du channel des événements de clic.",
+		}, func() float64 { return queueStat(func(ch chan *models.ClickEvent) int { return cap(ch) }) }),
+	)
+
+	// Les séries à zéro sont exposées dès le démarrage pour que les alertes sur leur variation fonctionnent
+	ClickEventsDroppedTotal.WithLabelValues(DropQueueFull)
+	ClickEventsDroppedTotal.WithLabelValues(DropSpoolError)
+	LinkCacheRequestsTotal.WithLabelValues("hit")
+	LinkCacheRequestsTotal.WithLabelValues("miss")
+	MonitorLinks.WithLabelValues("up")
+	MonitorLinks.WithLabelValues("down")
+}
+
+// ObserveClickQueue désigne le channel dont la profondeur et la capacité sont exposées.
+func ObserveClickQueue(ch chan *models.ClickEvent) {
+	clickQueue.Store(&ch)
+}
+
+// queueStat applique stat au channel observé, 0 s'il n'y en a pas.
+func queueStat(stat func(chan *models.ClickEvent) int) float64 {
+	ch := clickQueue.Load()
+	if ch == nil {
+		return 0
+	}
+	return float64(stat(*ch))
+}
+
+// Handler retourne le handler HTTP qui expose les métriques au format texte de Prometheus.
+func Handler() http.Handler {
+	return promhttp.HandlerFor(Registry, promhttp.HandlerOpts{})
+}
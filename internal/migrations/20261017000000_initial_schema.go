@@ -0,0 +1,118 @@
+package migrations
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Schéma initial, tel que créé jusqu'ici par AutoMigrate. Sur une base déjà créée par l'ancienne
+// commande 'migrate', Up ne fait que compléter ce qui manque : la base est ainsi reprise telle quelle.
+
+type initialLink struct {
+	ID             uint           `gorm:"primaryKey"`
+	ShortCode      string         `gorm:"uniqueIndex;unique;size:32;not null"`
+	LongURL        string         `gorm:"not null"`
+	CreatedAt      time.Time      `gorm:"autoCreateTime;not null"`
+	ExpiresAt      *time.Time     `gorm:"index"`
+	MaxClicks      int            `gorm:"not null;default:0"`
+	ConsumedClicks int            `gorm:"not null;default:0"`
+	DeletedAt      gorm.DeletedAt `gorm:"index"`
+	OwnerID        *uint          `gorm:"index"`
+}
+
+func (initialLink) TableName() string { return "links" }
+
+type initialClick struct {
+	ID             uint        `gorm:"primaryKey"`
+	EventID        *string     `gorm:"size:32;uniqueIndex"`
+	LinkID         uint        `gorm:"index"`
+	Link           initialLink `gorm:"foreignKey:LinkID"`
+	Timestamp      time.Time
+	UserAgent      string `gorm:"size:255"`
+	IPAddress      string `gorm:"size:50"`
+	Browser        string `gorm:"size:50;index"`
+	BrowserVersion string `gorm:"size:30"`
+	OS             string `gorm:"size:50;index"`
+	DeviceType     string `gorm:"size:20;index"`
+	IsBot          bool   `gorm:"index;not null;default:false"`
+	VisitorID      string `gorm:"size:64;index"`
+	Referrer       string `gorm:"size:255;index"`
+	Country        string `gorm:"size:2;index"`
+	Region         string `gorm:"size:100;index"`
+	City           string `gorm:"size:100;index"`
+}
+
+func (initialClick) TableName() string { return "clicks" }
+
+type initialAuditLog struct {
+	ID        uint      `gorm:"primaryKey"`
+	LinkID    uint      `gorm:"index;not null"`
+	ShortCode string    `gorm:"size:32;not null"`
+	Action    string    `gorm:"size:20;not null"`
+	Actor     string    `gorm:"size:100;not null"`
+	Before    string    `gorm:"type:text"`
+	After     string    `gorm:"type:text"`
+	CreatedAt time.Time `gorm:"autoCreateTime;index"`
+}
+
+func (initialAuditLog) TableName() string { return "audit_logs" }
+
+type initialAPIKey struct {
+	ID         uint      `gorm:"primaryKey"`
+	Name       string    `gorm:"size:100;not null"`
+	Prefix     string    `gorm:"uniqueIndex;size:16;not null"`
+	SecretHash string    `gorm:"size:64;not null"`
+	CreatedAt  time.Time `gorm:"autoCreateTime;not null"`
+	LastUsedAt *time.Time
+	RevokedAt  *time.Time `gorm:"index"`
+}
+
+func (initialAPIKey) TableName() string { return "api_keys" }
+
+type initialVisitorSalt struct {
+	Day       string    `gorm:"primaryKey;size:10"`
+	Salt      string    `gorm:"size:64;not null"`
+	CreatedAt time.Time `gorm:"autoCreateTime"`
+}
+
+func (initialVisitorSalt) TableName() string { return "visitor_salts" }
+
+type initialClickDailyAggregate struct {
+	ID             uint      `gorm:"primaryKey"`
+	LinkID         uint      `gorm:"uniqueIndex:idx_click_daily_aggregate;not null"`
+	Day            time.Time `gorm:"uniqueIndex:idx_click_daily_aggregate;not null"`
+	IsBot          bool      `gorm:"uniqueIndex:idx_click_daily_aggregate;not null;default:false"`
+	Clicks         int       `gorm:"not null;default:0"`
+	UniqueVisitors int       `gorm:"not null;default:0"`
+}
+
+func (initialClickDailyAggregate) TableName() string { return "click_daily_aggregates" }
+
+func init() {
+	register(Migration{
+		Version: "20261017000000",
+		Name:    "initial_schema",
+		Up: func(tx *gorm.DB) error {
+			return tx.AutoMigrate(
+				&initialLink{},
+				&initialClick{},
+				&initialAuditLog{},
+				&initialAPIKey{},
+				&initialVisitorSalt{},
+				&initialClickDailyAggregate{},
+			)
+		},
+		Down: func(tx *gorm.DB) error {
+			// Ordre inverse des dépendances : les clics référencent les liens
+			return tx.Migrator().DropTable(
+				&initialClickDailyAggregate{},
+				&initialVisitorSalt{},
+				&initialAPIKey{},
+				&initialAuditLog{},
+				&initialClick{},
+				&initialLink{},
+			)
+		},
+	})
+}
@@ -0,0 +1,30 @@
+package migrations
+
+import "gorm.io/gorm"
+
+// Protection des liens par mot de passe : hash du mot de passe et compteur des échecs de déverrouillage.
+
+type linkPasswordColumns struct {
+	PasswordHash   string `gorm:"size:60"`
+	UnlockFailures int    `gorm:"not null;default:0"`
+}
+
+func (linkPasswordColumns) TableName() string { return "links" }
+
+func init() {
+	register(Migration{
+		Version: "20261017100000",
+		Name:    "add_link_password",
+		Up: func(tx *gorm.DB) error {
+			return addColumns(tx, &linkPasswordColumns{}, "PasswordHash", "UnlockFailures")
+		},
+		Down: func(tx *gorm.DB) error {
+			if err := dropColumns(tx, &linkPasswordColumns{}, "UnlockFailures", "PasswordHash"); err != nil {
+				return err
+			}
+			// SQLite supprime une colonne en recréant la table, sans ses index : ils sont rétablis
+			// d'après le schéma précédent (sans effet sur les autres bases)
+			return tx.AutoMigrate(&initialLink{})
+		},
+	})
+}
@@ -0,0 +1,28 @@
+package migrations
+
+import "gorm.io/gorm"
+
+// Page d'aperçu systématique avant la redirection, pour les destinations peu sûres.
+
+type linkAlwaysPreviewColumn struct {
+	AlwaysPreview bool `gorm:"not null;default:false"`
+}
+
+func (linkAlwaysPreviewColumn) TableName() string { return "links" }
+
+func init() {
+	register(Migration{
+		Version: "20261017110000",
+		Name:    "add_link_always_preview",
+		Up: func(tx *gorm.DB) error {
+			return addColumns(tx, &linkAlwaysPreviewColumn{}, "AlwaysPreview")
+		},
+		Down: func(tx *gorm.DB) error {
+			if err := dropColumns(tx, &linkAlwaysPreviewColumn{}, "AlwaysPreview"); err != nil {
+				return err
+			}
+			// Index perdus par SQLite en recréant la table (voir add_link_password)
+			return tx.AutoMigrate(&initialLink{})
+		},
+	})
+}
@@ -0,0 +1,28 @@
+package migrations
+
+import "gorm.io/gorm"
+
+// Code HTTP de redirection propre à chaque lien, 0 pour le code par défaut du serveur.
+
+type linkRedirectTypeColumn struct {
+	RedirectType int `gorm:"not null;default:0"`
+}
+
+func (linkRedirectTypeColumn) TableName() string { return "links" }
+
+func init() {
+	register(Migration{
+		Version: "20261017120000",
+		Name:    "add_link_redirect_type",
+		Up: func(tx *gorm.DB) error {
+			return addColumns(tx, &linkRedirectTypeColumn{}, "RedirectType")
+		},
+		Down: func(tx *gorm.DB) error {
+			if err := dropColumns(tx, &linkRedirectTypeColumn{}, "RedirectType"); err != nil {
+				return err
+			}
+			// Index perdus par SQLite en recréant la table (voir add_link_password)
+			return tx.AutoMigrate(&initialLink{})
+		},
+	})
+}
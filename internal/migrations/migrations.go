@@ -0,0 +1,290 @@
+package migrations
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"text/template"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// versionFormat est le format des versions : l'horodatage UTC de création de la migration.
+const versionFormat = "20060102150405"
+
+// ErrUnknownMigration est retournée lorsque la base contient une migration appliquée absente de ce binaire,
+// typiquement après un retour à une version antérieure de l'application.
+var ErrUnknownMigration = errors.New("migration appliquée inconnue de cette version de l'application")
+
+// Migration est une évolution du schéma, appliquée par Up et annulée par Down dans une transaction.
+// Les migrations ne doivent pas dépendre des modèles de internal/models, qui évoluent : elles déclarent
+// leurs propres structures figées, dans l'état du schéma au moment de la migration.
+//
+// MySQL valide implicitement la transaction à chaque instruction DDL (CREATE, ALTER, DROP) : une migration
+// interrompue y laisse appliquées ses premières instructions, sans ligne dans schema_migrations. Up et Down
+// doivent donc pouvoir être relancés sur un schéma partiellement modifié (AutoMigrate, addColumns, dropColumns).
+type Migration struct {
+	Version string // Horodatage AAAAMMJJHHMMSS, détermine l'ordre d'application
+	Name    string
+	Up      func(tx *gorm.DB) error
+	Down    func(tx *gorm.DB) error
+}
+
+// SchemaMigration est une ligne de la table schema_migrations : une migration appliquée.
+type SchemaMigration struct {
+	Version   string    `gorm:"primaryKey;size:14"`
+	Name      string    `gorm:"size:255;not null"`
+	AppliedAt time.Time `gorm:"not null"`
+}
+
+// TableName fixe le nom de la table de suivi des migrations.
+func (SchemaMigration) TableName() string {
+	return "schema_migrations"
+}
+
+// Status décrit l'état d'une migration pour la commande 'migrate status'.
+type Status struct {
+	Version   string
+	Name      string
+	AppliedAt *time.Time // nil si la migration est en attente
+	Unknown   bool       // Appliquée en base mais absente de ce binaire
+}
+
+// registry contient les migrations déclarées par les fichiers du package, via register.
+var registry = make(map[string]Migration)
+
+// register déclare une migration. Elle est appelée depuis la fonction init de chaque fichier de migration.
+func register(migration Migration) {
+	if _, exists := registry[migration.Version]; exists {
+		panic(fmt.Sprintf("[Migrations] version %s déclarée deux fois", migration.Version))
+	}
+	if migration.Up == nil || migration.Down == nil {
+		panic(fmt.Sprintf("[Migrations] la migration %s doit définir Up et Down", migration.Version))
+	}
+	registry[migration.Version] = migration
+}
+
+// Migrator applique et annule les migrations déclarées sur une base de données.
+type Migrator struct {
+	db         *gorm.DB
+	migrations []Migration // Triées par version croissante
+}
+
+// NewMigrator crée un Migrator pour toutes les migrations déclarées dans le package.
+func NewMigrator(db *gorm.DB) *Migrator {
+	migrations := make([]Migration, 0, len(registry))
+	for _, migration := range registry {
+		migrations = append(migrations, migration)
+	}
+	sort.Slice(migrations, func(i, j int) bool { return migrations[i].Version < migrations[j].Version })
+	return &Migrator{db: db, migrations: migrations}
+}
+
+// Up applique, dans l'ordre, toutes les migrations en attente et retourne celles qui ont été appliquées.
+// Elle s'arrête à la première erreur ; les migrations déjà appliquées le restent.
+func (m *Migrator) Up() ([]Migration, error) {
+	if err := m.db.AutoMigrate(&SchemaMigration{}); err != nil {
+		return nil, fmt.Errorf("[Migrations] création de la table schema_migrations impossible: %w", err)
+	}
+	pending, err := m.Pending()
+	if err != nil {
+		return nil, err
+	}
+
+	var applied []Migration
+	for _, migration := range pending {
+		err := m.db.Transaction(func(tx *gorm.DB) error {
+			if err := migration.Up(tx); err != nil {
+				return err
+			}
+			return tx.Create(&SchemaMigration{Version: migration.Version, Name: migration.Name, AppliedAt: time.Now().UTC()}).Error
+		})
+		if err != nil {
+			return applied, fmt.Errorf("[Migrations] échec de la migration %s_%s: %w", migration.Version, migration.Name, err)
+		}
+		applied = append(applied, migration)
+	}
+	return applied, nil
+}
+
+// Down annule les n dernières migrations appliquées, de la plus récente à la plus ancienne,
+// et retourne celles qui ont été annulées.
+func (m *Migrator) Down(n int) ([]Migration, error) {
+	if n <= 0 {
+		return nil, nil
+	}
+	applied, err := m.applied()
+	if err != nil {
+		return nil, err
+	}
+	sort.Slice(applied, func(i, j int) bool { return applied[i].Version > applied[j].Version })
+	if n < len(applied) {
+		applied = applied[:n]
+	}
+
+	var reverted []Migration
+	for _, row := range applied {
+		migration, ok := registry[row.Version]
+		if !ok {
+			return reverted, fmt.Errorf("[Migrations] %w: %s_%s", ErrUnknownMigration, row.Version, row.Name)
+		}
+		err := m.db.Transaction(func(tx *gorm.DB) error {
+			if err := migration.Down(tx); err != nil {
+				return err
+			}
+			return tx.Delete(&SchemaMigration{}, "version = ?", migration.Version).Error
+		})
+		if err != nil {
+			return reverted, fmt.Errorf("[Migrations] échec de l'annulation de %s_%s: %w", migration.Version, migration.Name, err)
+		}
+		reverted = append(reverted, migration)
+	}
+	return reverted, nil
+}
+
+// Pending retourne les migrations pas encore appliquées, dans l'ordre d'application.
+func (m *Migrator) Pending() ([]Migration, error) {
+	applied, err := m.appliedVersions()
+	if err != nil {
+		return nil, err
+	}
+	var pending []Migration
+	for _, migration := range m.migrations {
+		if _, ok := applied[migration.Version]; !ok {
+			pending = append(pending, migration)
+		}
+	}
+	return pending, nil
+}
+
+// Status retourne l'état de toutes les migrations, connues ou seulement présentes en base, par version croissante.
+func (m *Migrator) Status() ([]Status, error) {
+	applied, err := m.appliedVersions()
+	if err != nil {
+		return nil, err
+	}
+
+	statuses := make([]Status, 0, len(m.migrations))
+	for _, migration := range m.migrations {
+		status := Status{Version: migration.Version, Name: migration.Name}
+		if row, ok := applied[migration.Version]; ok {
+			status.AppliedAt = &row.AppliedAt
+			delete(applied, migration.Version)
+		}
+		statuses = append(statuses, status)
+	}
+	for _, row := range applied {
+		statuses = append(statuses, Status{Version: row.Version, Name: row.Name, AppliedAt: &row.AppliedAt, Unknown: true})
+	}
+	sort.Slice(statuses, func(i, j int) bool { return statuses[i].Version < statuses[j].Version })
+	return statuses, nil
+}
+
+// applied retourne les lignes de schema_migrations, sans créer la table si elle n'existe pas.
+func (m *Migrator) applied() ([]SchemaMigration, error) {
+	if !m.db.Migrator().HasTable(&SchemaMigration{}) {
+		return nil, nil
+	}
+	var rows []SchemaMigration
+	if err := m.db.Order("version").Find(&rows).Error; err != nil {
+		return nil, fmt.Errorf("[Migrations] lecture de schema_migrations impossible: %w", err)
+	}
+	return rows, nil
+}
+
+// appliedVersions indexe les migrations appliquées par version.
+func (m *Migrator) appliedVersions() (map[string]SchemaMigration, error) {
+	rows, err := m.applied()
+	if err != nil {
+		return nil, err
+	}
+	versions := make(map[string]SchemaMigration, len(rows))
+	for _, row := range rows {
+		versions[row.Version] = row
+	}
+	return versions, nil
+}
+
+// addColumns ajoute à la table de model les colonnes des champs fields qui n'existent pas encore.
+func addColumns(tx *gorm.DB, model any, fields ...string) error {
+	for _, field := range fields {
+		if tx.Migrator().HasColumn(model, field) {
+			continue
+		}
+		if err := tx.Migrator().AddColumn(model, field); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// dropColumns supprime de la table de model les colonnes des champs fields qui existent encore.
+func dropColumns(tx *gorm.DB, model any, fields ...string) error {
+	for _, field := range fields {
+		if !tx.Migrator().HasColumn(model, field) {
+			continue
+		}
+		if err := tx.Migrator().DropColumn(model, field); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+var (
+	nameSeparators = regexp.MustCompile(`[\s-]+`)
+	validName      = regexp.MustCompile(`^[a-z][a-z0-9_]*$`)
+)
+
+// Create génère dans dir le squelette d'une nouvelle migration nommée name, versionnée à la date now,
+// et retourne le chemin du fichier créé. La migration doit ensuite être complétée puis compilée avec l'application.
+func Create(dir, name string, now time.Time) (string, error) {
+	name = nameSeparators.ReplaceAllString(strings.ToLower(strings.TrimSpace(name)), "_")
+	if !validName.MatchString(name) {
+		return "", fmt.Errorf("[Migrations] nom '%s' invalide : lettres minuscules, chiffres et '_' uniquement, en commençant par une lettre", name)
+	}
+
+	if info, err := os.Stat(dir); err != nil || !info.IsDir() {
+		return "", fmt.Errorf("[Migrations] répertoire des migrations '%s' introuvable (à lancer depuis la racine des sources, ou à indiquer avec --dir)", dir)
+	}
+
+	version := now.UTC().Format(versionFormat)
+	path := filepath.Join(dir, version+"_"+name+".go")
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0o644)
+	if err != nil {
+		return "", fmt.Errorf("[Migrations] création de '%s' impossible: %w", path, err)
+	}
+	defer file.Close()
+
+	if err := migrationTemplate.Execute(file, struct{ Version, Name string }{version, name}); err != nil {
+		return "", fmt.Errorf("[Migrations] écriture de '%s' impossible: %w", path, err)
+	}
+	return path, nil
+}
+
+var migrationTemplate = template.Must(template.New("migration").Parse(`package migrations
+
+import "gorm.io/gorm"
+
+func init() {
+	register(Migration{
+		Version: "{{.Version}}",
+		Name:    "{{.Name}}",
+		Up: func(tx *gorm.DB) error {
+			// TODO : appliquer la modification du schéma ou des données
+			// Sur MySQL, chaque instruction DDL est validée aussitôt : Up doit pouvoir être relancée
+			// après un échec partiel (voir addColumns)
+			return nil
+		},
+		Down: func(tx *gorm.DB) error {
+			// TODO : annuler exactement ce que fait Up, en tolérant de même un échec partiel (voir dropColumns)
+			return nil
+		},
+	})
+}
+`))
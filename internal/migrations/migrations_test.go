@@ -0,0 +1,125 @@
+package migrations
+
+import (
+	"go/parser"
+	"go/token"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/axellelanca/urlshortener/internal/config"
+	"github.com/axellelanca/urlshortener/internal/database"
+	"gorm.io/gorm"
+)
+
+// newTestDB ouvre une base SQLite vide dans un répertoire temporaire.
+func newTestDB(t *testing.T) *gorm.DB {
+	t.Helper()
+	db, err := database.Open(config.DatabaseConfig{Name: filepath.Join(t.TempDir(), "test.db")})
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		if sqlDB, err := db.DB(); err == nil {
+			sqlDB.Close()
+		}
+	})
+	return db
+}
+
+func TestCreate(t *testing.T) {
+	dir := t.TempDir()
+	now := time.Date(2026, time.October, 17, 14, 30, 5, 0, time.FixedZone("CEST", 2*3600))
+
+	path, err := Create(dir, "  Add Link-Title ", now)
+	if err != nil {
+		t.Fatalf("Create: %v", err)
+	}
+	if want := filepath.Join(dir, "20261017123005_add_link_title.go"); path != want {
+		t.Fatalf("chemin %q, attendu %q (version en UTC)", path, want)
+	}
+	source, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := parser.ParseFile(token.NewFileSet(), path, source, 0); err != nil {
+		t.Fatalf("squelette invalide: %v\n%s", err, source)
+	}
+	if !strings.Contains(string(source), `Version: "20261017123005"`) || !strings.Contains(string(source), `Name:    "add_link_title"`) {
+		t.Fatalf("squelette sans la version ou le nom:\n%s", source)
+	}
+
+	// Un fichier existant n'est jamais écrasé
+	if _, err := Create(dir, "add_link_title", now); err == nil {
+		t.Fatal("Create devrait refuser d'écraser une migration existante")
+	}
+	for _, name := range []string{"", "1_first", "add;drop", "été"} {
+		if _, err := Create(dir, name, now); err == nil {
+			t.Errorf("Create(%q) devrait refuser le nom", name)
+		}
+	}
+	if _, err := Create(filepath.Join(dir, "absent"), "add_link_title", now); err == nil || !strings.Contains(err.Error(), "--dir") {
+		t.Fatalf("Create dans un répertoire absent = %v, attendu une erreur mentionnant --dir", err)
+	}
+}
+
+func TestUpAndDownRoundTrip(t *testing.T) {
+	db := newTestDB(t)
+	migrator := NewMigrator(db)
+
+	applied, err := migrator.Up()
+	if err != nil {
+		t.Fatalf("Up: %v", err)
+	}
+	if len(applied) != len(registry) {
+		t.Fatalf("%d migration(s) appliquée(s), attendu %d", len(applied), len(registry))
+	}
+	if again, err := migrator.Up(); err != nil || len(again) != 0 {
+		t.Fatalf("second Up = %d migration(s), %v ; attendu aucune", len(again), err)
+	}
+
+	reverted, err := migrator.Down(len(registry))
+	if err != nil {
+		t.Fatalf("Down: %v", err)
+	}
+	if len(reverted) != len(registry) || db.Migrator().HasTable("links") {
+		t.Fatalf("%d migration(s) annulée(s), table links présente : %v", len(reverted), db.Migrator().HasTable("links"))
+	}
+	if _, err := migrator.Up(); err != nil {
+		t.Fatalf("Up après Down: %v", err)
+	}
+}
+
+func TestUpResumesPartiallyAppliedMigration(t *testing.T) {
+	db := newTestDB(t)
+	migrator := NewMigrator(db)
+	if _, err := migrator.Up(); err != nil {
+		t.Fatal(err)
+	}
+
+	// Comme sur MySQL après un échec : la première colonne est restée, la migration n'est pas enregistrée
+	if err := db.Migrator().DropColumn(&linkPasswordColumns{}, "UnlockFailures"); err != nil {
+		t.Fatal(err)
+	}
+	if err := db.Delete(&SchemaMigration{}, "version >= ?", "20261017100000").Error; err != nil {
+		t.Fatal(err)
+	}
+
+	applied, err := migrator.Up()
+	if err != nil {
+		t.Fatalf("Up sur un schéma partiellement migré: %v", err)
+	}
+	if len(applied) != 3 || !db.Migrator().HasColumn(&linkPasswordColumns{}, "UnlockFailures") {
+		t.Fatalf("%d migration(s) rejouée(s), attendu 3 avec la colonne unlock_failures rétablie", len(applied))
+	}
+
+	// Down tolère de même une colonne déjà supprimée
+	if err := db.Migrator().DropColumn(&linkRedirectTypeColumn{}, "RedirectType"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := migrator.Down(1); err != nil {
+		t.Fatalf("Down sans la colonne à supprimer: %v", err)
+	}
+}
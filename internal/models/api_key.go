@@ -0,0 +1,20 @@
+package models
+
+import "time"
+
+// APIKey représente une clé d'accès à l'API REST.
+// Seul un hash du secret est stocké : la clé complète n'est affichée qu'une fois, à sa création.
+type APIKey struct {
+	ID         uint       `gorm:"primaryKey"`
+	Name       string     `gorm:"size:100;not null"`            // Nom lisible (ex: "équipe marketing")
+	Prefix     string     `gorm:"uniqueIndex;size:16;not null"` // Partie publique de la clé, permet de la retrouver sans le secret
+	SecretHash string     `gorm:"size:64;not null"`             // SHA-256 (hexadécimal) du secret
+	CreatedAt  time.Time  `gorm:"autoCreateTime;not null"`
+	LastUsedAt *time.Time // Dernière authentification réussie
+	RevokedAt  *time.Time `gorm:"index"` // Date de révocation, nil tant que la clé est active
+}
+
+// IsRevoked indique si la clé a été révoquée.
+func (k *APIKey) IsRevoked() bool {
+	return k.RevokedAt != nil
+}
@@ -0,0 +1,25 @@
+package models
+
+import "time"
+
+// Actions possibles enregistrées dans le journal d'audit.
+const (
+	AuditActionCreate      = "create"
+	AuditActionUpdate      = "update"
+	AuditActionDelete      = "delete"
+	AuditActionRestore     = "restore"
+	AuditActionAssignOwner = "assign_owner" // Attribution du lien à une clé API
+)
+
+// AuditLog représente une entrée du journal d'audit des liens :
+// qui a fait quoi, quand, avec l'état du lien avant et après l'opération.
+type AuditLog struct {
+	ID        uint      `gorm:"primaryKey"`
+	LinkID    uint      `gorm:"index;not null"`       // Lien concerné (conservé même après suppression)
+	ShortCode string    `gorm:"size:32;not null"`     // Code court au moment de l'opération
+	Action    string    `gorm:"size:20;not null"`     // create, update, delete, restore ou assign_owner
+	Actor     string    `gorm:"size:100;not null"`    // Auteur de l'opération (ex: "cli:alice", "api:127.0.0.1")
+	Before    string    `gorm:"type:text"`            // État JSON du lien avant l'opération (vide pour create)
+	After     string    `gorm:"type:text"`            // État JSON du lien après l'opération
+	CreatedAt time.Time `gorm:"autoCreateTime;index"` // Horodatage de l'opération
+}
@@ -4,19 +4,44 @@ import "time"
 
 // Click représente un événement de clic sur un lien raccourci.
 type Click struct {
-	ID        uint      `gorm:"primaryKey"`        // Clé primaire
-	LinkID    uint      `gorm:"index"`             // Clé étrangère vers la table 'links', indexée pour des requêtes efficaces
-	Link      Link      `gorm:"foreignKey:LinkID"` // Relation GORM: indique que LinkID est une FK vers le champ ID de Link
+	ID        uint      `gorm:"primaryKey"`          // Clé primaire
+	EventID   *string   `gorm:"size:32;uniqueIndex"` // Identifiant de l'événement d'origine, écarte les doublons rejoués par le spool
+	LinkID    uint      `gorm:"index"`               // Clé étrangère vers la table 'links', indexée pour des requêtes efficaces
+	Link      Link      `gorm:"foreignKey:LinkID"`   // Relation GORM: indique que LinkID est une FK vers le champ ID de Link
 	Timestamp time.Time // Horodatage précis du clic
 	UserAgent string    `gorm:"size:255"` // User-Agent de l'utilisateur qui a cliqué (informations sur le navigateur/OS)
 	IPAddress string    `gorm:"size:50"`  // Adresse IP de l'utilisateur
+
+	// Informations extraites du User-Agent par le worker (package useragent)
+	Browser        string `gorm:"size:50;index"` // Famille de navigateur (ex: "Chrome", "Googlebot")
+	BrowserVersion string `gorm:"size:30"`       // Version du navigateur (ex: "124.0")
+	OS             string `gorm:"size:50;index"` // Système d'exploitation (ex: "Windows 10")
+	DeviceType     string `gorm:"size:20;index"` // desktop, mobile, tablet ou bot
+
+	IsBot bool `gorm:"index;not null;default:false"` // Clic émis par un robot, exclu des statistiques par défaut
+
+	// Empreinte anonyme du visiteur : SHA-256 salé de l'IP et du User-Agent, sel renouvelé chaque jour.
+	// Elle permet de compter les visiteurs uniques par jour sans conserver d'identifiant durable.
+	VisitorID string `gorm:"size:64;index"`
+
+	Referrer string `gorm:"size:255;index"` // Domaine de provenance issu de l'en-tête Referer, vide pour un accès direct
+
+	// Position déduite de l'adresse IP par le worker (package geoip), vide si la base GeoIP est absente
+	Country string `gorm:"size:2;index"`   // Code pays ISO 3166-1 alpha-2 (ex: "FR")
+	Region  string `gorm:"size:100;index"` // Région ou état (ex: "Île-de-France")
+	City    string `gorm:"size:100;index"` // Ville (ex: "Paris")
 }
 
 // ClickEvent représente un événement de clic brut, destiné à être passé via un channel
+// et, si le spool est activé, journalisé sur disque au format JSON.
 type ClickEvent struct {
+	EventID   string // Identifiant unique attribué à la redirection
 	LinkID    uint
 	Timestamp time.Time
 	ShortCode string
 	UserAgent string
 	IPAddress string
+	Referrer  string // Valeur brute de l'en-tête Referer, normalisée en domaine par le worker
+
+	SpoolSegment uint64 `json:"-"` // Segment du spool d'où provient l'événement (0 hors spool)
 }
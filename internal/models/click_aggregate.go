@@ -0,0 +1,16 @@
+package models
+
+import "time"
+
+// ClickDailyAggregate conserve le nombre de clics et de visiteurs uniques d'un lien pour un jour,
+// une fois les clics bruts de ce jour supprimés par la politique de rétention (mode aggregate).
+// Les totaux et séries temporelles en tiennent compte ; les répartitions (navigateur, pays, référent...)
+// ne portent que sur les clics bruts encore conservés.
+type ClickDailyAggregate struct {
+	ID             uint      `gorm:"primaryKey"`
+	LinkID         uint      `gorm:"uniqueIndex:idx_click_daily_aggregate;not null"`
+	Day            time.Time `gorm:"uniqueIndex:idx_click_daily_aggregate;not null"` // Minuit UTC du jour agrégé
+	IsBot          bool      `gorm:"uniqueIndex:idx_click_daily_aggregate;not null;default:false"`
+	Clicks         int       `gorm:"not null;default:0"`
+	UniqueVisitors int       `gorm:"not null;default:0"`
+}
@@ -0,0 +1,64 @@
+package models
+
+import "time"
+
+// Granularités acceptées pour l'agrégation temporelle des clics.
+const (
+	IntervalHour  = "hour"
+	IntervalDay   = "day"
+	IntervalWeek  = "week"
+	IntervalMonth = "month"
+)
+
+// ClickBucket représente le nombre de clics d'un lien sur une tranche de temps.
+type ClickBucket struct {
+	Start          time.Time // Début de la tranche (UTC)
+	Clicks         int       // Nombre de clics enregistrés dans la tranche
+	UniqueVisitors int       // Nombre d'empreintes de visiteurs distinctes dans la tranche
+}
+
+// DimensionCount représente le nombre de clics pour une valeur d'une dimension (navigateur, OS, appareil...).
+type DimensionCount struct {
+	Value  string
+	Clicks int
+}
+
+// IsValidInterval indique si la granularité fait partie de celles supportées.
+func IsValidInterval(interval string) bool {
+	switch interval {
+	case IntervalHour, IntervalDay, IntervalWeek, IntervalMonth:
+		return true
+	}
+	return false
+}
+
+// TruncateToInterval ramène un instant au début de sa tranche (heure, jour, semaine commençant le lundi, mois), en UTC.
+func TruncateToInterval(t time.Time, interval string) time.Time {
+	t = t.UTC()
+	switch interval {
+	case IntervalHour:
+		return t.Truncate(time.Hour)
+	case IntervalWeek:
+		day := time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+		offset := (int(day.Weekday()) + 6) % 7 // Nombre de jours depuis le lundi
+		return day.AddDate(0, 0, -offset)
+	case IntervalMonth:
+		return time.Date(t.Year(), t.Month(), 1, 0, 0, 0, 0, time.UTC)
+	default:
+		return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+	}
+}
+
+// NextInterval retourne le début de la tranche suivant celle qui commence à start.
+func NextInterval(start time.Time, interval string) time.Time {
+	switch interval {
+	case IntervalHour:
+		return start.Add(time.Hour)
+	case IntervalWeek:
+		return start.AddDate(0, 0, 7)
+	case IntervalMonth:
+		return start.AddDate(0, 1, 0)
+	default:
+		return start.AddDate(0, 0, 1)
+	}
+}
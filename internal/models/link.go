@@ -1,11 +1,56 @@
 package models
 
-import "time"
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
 
 // Link /**
 type Link struct {
-	ID        uint      `gorm:"primaryKey"`
-	ShortCode string    `gorm:"uniqueIndex;unique;size:10;not null"`
-	LongURL   string    `gorm:"not null"`
-	CreatedAt time.Time `gorm:"autoCreateTime;not null"`
+	ID             uint           `gorm:"primaryKey"`
+	ShortCode      string         `gorm:"uniqueIndex;unique;size:32;not null"`
+	LongURL        string         `gorm:"not null"`
+	CreatedAt      time.Time      `gorm:"autoCreateTime;not null"`
+	ExpiresAt      *time.Time     `gorm:"index"`                  // Date d'expiration optionnelle (nil = le lien n'expire jamais)
+	MaxClicks      int            `gorm:"not null;default:0"`     // Nombre maximal de redirections autorisées (0 = illimité)
+	ConsumedClicks int            `gorm:"not null;default:0"`     // Redirections déjà consommées, uniquement suivi lorsque MaxClicks > 0
+	DeletedAt      gorm.DeletedAt `gorm:"index"`                  // Suppression logique : le lien et ses clics sont conservés
+	OwnerID        *uint          `gorm:"index"`                  // Clé API propriétaire (nil pour les liens créés via la CLI)
+	PasswordHash   string         `gorm:"size:60" json:"-"`       // Hash bcrypt du mot de passe, vide si le lien n'est pas protégé
+	UnlockFailures int            `gorm:"not null;default:0"`     // Mots de passe erronés saisis pour déverrouiller le lien
+	AlwaysPreview  bool           `gorm:"not null;default:false"` // Affiche toujours la page d'aperçu avant la redirection
+	RedirectType   int            `gorm:"not null;default:0"`     // Code HTTP de redirection (301, 302, 307 ou 308), 0 pour celui du serveur
+}
+
+// IsExpired indique si le lien a dépassé sa date d'expiration ou épuisé son quota de clics.
+func (l *Link) IsExpired(now time.Time) bool {
+	if l.ExpiresAt != nil && !now.Before(*l.ExpiresAt) {
+		return true
+	}
+	return l.MaxClicks > 0 && l.ConsumedClicks >= l.MaxClicks
+}
+
+// IsProtected indique si le lien exige un mot de passe avant la redirection.
+func (l *Link) IsProtected() bool {
+	return l.PasswordHash != ""
+}
+
+// ClicksLeft retourne le nombre de redirections restantes, ou nil si le lien n'a pas de quota.
+func (l *Link) ClicksLeft() *int {
+	if l.MaxClicks <= 0 {
+		return nil
+	}
+	left := l.MaxClicks - l.ConsumedClicks
+	if left < 0 {
+		left = 0
+	}
+	return &left
+}
+
+// RequiresServerVisit indique si chaque visite doit passer par le service : date d'expiration, quota de clics,
+// mot de passe ou aperçu systématique. Une redirection permanente, mise en cache par les navigateurs,
+// permettrait de contourner ces restrictions.
+func (l *Link) RequiresServerVisit() bool {
+	return l.ExpiresAt != nil || l.MaxClicks > 0 || l.IsProtected() || l.AlwaysPreview
 }
@@ -0,0 +1,12 @@
+package models
+
+import "time"
+
+// VisitorSalt est le sel aléatoire utilisé pour calculer les empreintes de visiteurs d'une journée.
+// Il est partagé en base pour que tous les workers et toutes les instances produisent la même empreinte,
+// puis supprimé après rotation : les empreintes des jours passés ne peuvent alors plus être recalculées.
+type VisitorSalt struct {
+	Day       string    `gorm:"primaryKey;size:10"` // Jour UTC au format AAAA-MM-JJ
+	Salt      string    `gorm:"size:64;not null"`   // Sel hexadécimal
+	CreatedAt time.Time `gorm:"autoCreateTime"`
+}
@@ -6,26 +6,43 @@ import (
 	"sync" // Pour protéger l'accès concurrentiel à knownStates
 	"time"
 
+	"github.com/axellelanca/urlshortener/internal/metrics"
 	_ "github.com/axellelanca/urlshortener/internal/models"   // Importe les modèles de liens
 	"github.com/axellelanca/urlshortener/internal/repository" // Importe le repository de liens
 )
 
+// CheckTimeout est le délai maximal d'une vérification d'URL (5 secondes).
+const CheckTimeout = 5 * time.Second
+
 // UrlMonitor gère la surveillance périodique des URLs longues.
 type UrlMonitor struct {
 	linkRepo    repository.LinkRepository // Pour récupérer les URLs à surveiller
+	client      *http.Client              // Client des requêtes HEAD, qui refuse les adresses internes
 	interval    time.Duration             // Intervalle entre chaque vérification (ex: 5 minutes)
 	knownStates map[uint]bool             // État connu de chaque URL: map[LinkID]estAccessible (true/false)
 	mu          sync.Mutex                // Mutex pour protéger l'accès concurrentiel à knownStates
+	stop        chan struct{}             // Fermé par Stop pour interrompre la boucle de surveillance
+	done        chan struct{}             // Fermé par Start lorsque la boucle est terminée
+	stopOnce    sync.Once
+	lastRun     time.Time // Fin de la dernière vérification complète, protégée par mu
 }
 
 // TODO finir cette fonction
 // NewUrlMonitor crée et retourne une nouvelle instance de UrlMonitor.
+// client effectue les requêtes HEAD ; il doit vérifier l'adresse contactée à chaque connexion
+// (voir urlpolicy.Policy.HTTPClient). S'il est nil, un client sans vérification est utilisé.
 // Attention: retourne un pointeur
-func NewUrlMonitor(linkRepo repository.LinkRepository, interval time.Duration) *UrlMonitor {
+func NewUrlMonitor(linkRepo repository.LinkRepository, interval time.Duration, client *http.Client) *UrlMonitor {
+	if client == nil {
+		client = &http.Client{Timeout: CheckTimeout}
+	}
 	return &UrlMonitor{
 		linkRepo:    linkRepo,
+		client:      client,
 		interval:    interval,
 		knownStates: make(map[uint]bool),
+		stop:        make(chan struct{}),
+		done:        make(chan struct{}),
 	}
 }
 
@@ -35,16 +52,42 @@ func (m *UrlMonitor) Start() {
 	log.Printf("[MONITOR] Démarrage du moniteur d'URLs avec un intervalle de %v...", m.interval)
 	ticker := time.NewTicker(m.interval) // Crée un ticker qui envoie un signal à chaque intervalle
 	defer ticker.Stop()                  // S'assure que le ticker est arrêté quand Start se termine
+	defer close(m.done)
 
 	// Exécute une première vérification immédiatement au démarrage
 	m.checkUrls()
 
-	// Boucle principale du moniteur, déclenchée par le ticker
-	for range ticker.C {
-		m.checkUrls()
+	// Boucle principale du moniteur, déclenchée par le ticker jusqu'à l'appel de Stop
+	for {
+		select {
+		case <-ticker.C:
+			m.checkUrls()
+		case <-m.stop:
+			log.Println("[MONITOR] Moniteur d'URLs arrêté.")
+			return
+		}
 	}
 }
 
+// Stop interrompt la surveillance et attend la fin de la vérification en cours,
+// qui s'arrête avant de tester le lien suivant. Stop ne doit être appelé qu'après Start.
+func (m *UrlMonitor) Stop() {
+	m.stopOnce.Do(func() { close(m.stop) })
+	<-m.done
+}
+
+// Interval retourne l'intervalle entre deux vérifications.
+func (m *UrlMonitor) Interval() time.Duration {
+	return m.interval
+}
+
+// LastRun retourne la date de fin de la dernière vérification complète, zéro si aucune n'a encore abouti.
+func (m *UrlMonitor) LastRun() time.Time {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.lastRun
+}
+
 // checkUrls effectue une vérification de l'état de toutes les URLs longues enregistrées.
 func (m *UrlMonitor) checkUrls() {
 	log.Println("[MONITOR] Lancement de la vérification de l'état des URLs...")
@@ -58,9 +101,24 @@ func (m *UrlMonitor) checkUrls() {
 		return
 	}
 
+	var up, down int
 	for _, link := range links {
+		select {
+		case <-m.stop:
+			log.Println("[MONITOR] Vérification interrompue par l'arrêt du moniteur.")
+			return
+		default:
+		}
+
 		// TODO : Pour chaque lien, vérifier son accessibilité (isUrlAccessible).
+		start := time.Now()
 		currentState := m.isUrlAccessible(link.LongURL)
+		metrics.MonitorCheckDuration.Observe(time.Since(start).Seconds())
+		if currentState {
+			up++
+		} else {
+			down++
+		}
 		if err != nil {
 			log.Printf("[MONITOR] ERREUR lors de la vérification de l'URL '%s': %v", link.LongURL, err)
 			continue
@@ -90,20 +148,21 @@ func (m *UrlMonitor) checkUrls() {
 				link.ShortCode, link.LongURL, formatState(currentState))
 		}
 	}
+	// Les jauges ne sont mises à jour qu'après une vérification complète
+	metrics.MonitorLinks.WithLabelValues("up").Set(float64(up))
+	metrics.MonitorLinks.WithLabelValues("down").Set(float64(down))
+	m.mu.Lock()
+	m.lastRun = time.Now()
+	m.mu.Unlock()
 	log.Println("[MONITOR] Vérification de l'état des URLs terminée.")
 }
 
 // isUrlAccessible effectue une requête HTTP HEAD pour vérifier l'accessibilité d'une URL.
 func (m *UrlMonitor) isUrlAccessible(url string) bool {
-	// TODO Définir un timeout pour éviter de bloquer trop longtemps (5 secondes c'est bien)
-	client := &http.Client{
-		Timeout: 5 * time.Second, // Timeout de 5 secondes
-	}
-
 	// TODO: Effectuer une requête HEAD (plus légère que GET) sur l'URL.
 	// Un code de statut 2xx ou 3xx indique que l'URL est accessible.
 	// Si err : log.Printf("[MONITOR] Erreur d'accès à l'URL '%s': %v", url, err)
-	resp, err := client.Head(url)
+	resp, err := m.client.Head(url)
 	if err != nil {
 		log.Printf("[MONITOR] Erreur d'accès à l'URL '%s': %v", url, err)
 		return false
@@ -0,0 +1,131 @@
+package ratelimit
+
+import (
+	"context"
+	"math"
+	"sync"
+	"time"
+)
+
+// sweepInterval est l'intervalle minimal entre deux nettoyages des compteurs inactifs.
+const sweepInterval = time.Minute
+
+// Result est la décision du limiteur pour une requête.
+type Result struct {
+	Allowed    bool
+	Limit      int           // Capacité du seau : nombre de requêtes acceptées en rafale
+	Remaining  int           // Requêtes encore acceptées immédiatement
+	RetryAfter time.Duration // Attente avant qu'une requête soit de nouveau acceptée, si refusée
+	ResetAfter time.Duration // Attente avant que le seau soit de nouveau plein
+}
+
+// Limiter décide si une requête identifiée par key peut être traitée. Une implémentation partagée
+// entre instances (Redis, base de données...) peut remplacer MemoryLimiter ; elle retourne une erreur
+// si son stockage est indisponible, auquel cas l'appelant choisit de laisser passer la requête ou non.
+type Limiter interface {
+	Allow(ctx context.Context, key string) (Result, error)
+	// Peek retourne la décision qu'Allow prendrait pour key, sans consommer de jeton. Elle permet de
+	// ne décompter que certaines requêtes (ex: les échecs), une fois leur issue connue.
+	Peek(ctx context.Context, key string) (Result, error)
+}
+
+// bucket est le seau de jetons d'une clé.
+type bucket struct {
+	tokens float64
+	last   time.Time // Dernier remplissage
+}
+
+// MemoryLimiter est un Limiter en mémoire à seaux de jetons, propre à l'instance :
+// chaque clé dispose de burst jetons, regagnés au rythme de ratePerMinute par minute.
+type MemoryLimiter struct {
+	rate  float64 // Jetons par seconde
+	burst float64
+
+	mu        sync.Mutex
+	buckets   map[string]*bucket
+	lastSweep time.Time
+}
+
+// NewMemoryLimiter crée un MemoryLimiter autorisant ratePerMinute requêtes par minute et par clé,
+// avec des rafales de burst requêtes (ratePerMinute si burst <= 0).
+func NewMemoryLimiter(ratePerMinute, burst int) *MemoryLimiter {
+	if burst <= 0 {
+		burst = ratePerMinute
+	}
+	return &MemoryLimiter{
+		rate:      float64(ratePerMinute) / 60,
+		burst:     float64(burst),
+		buckets:   make(map[string]*bucket),
+		lastSweep: time.Now(),
+	}
+}
+
+// Allow consomme un jeton du seau de key s'il en reste.
+func (l *MemoryLimiter) Allow(_ context.Context, key string) (Result, error) {
+	now := time.Now()
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if now.Sub(l.lastSweep) >= sweepInterval {
+		l.sweepLocked(now)
+	}
+
+	b, ok := l.buckets[key]
+	if !ok {
+		b = &bucket{tokens: l.burst, last: now}
+		l.buckets[key] = b
+	}
+	b.tokens = math.Min(l.burst, b.tokens+now.Sub(b.last).Seconds()*l.rate)
+	b.last = now
+
+	result := Result{Limit: int(l.burst)}
+	if b.tokens >= 1 {
+		b.tokens--
+		result.Allowed = true
+	} else {
+		result.RetryAfter = l.durationFor(1 - b.tokens)
+	}
+	result.Remaining = int(b.tokens)
+	result.ResetAfter = l.durationFor(l.burst - b.tokens)
+	return result, nil
+}
+
+// Peek indique s'il reste un jeton dans le seau de key, sans le consommer.
+func (l *MemoryLimiter) Peek(_ context.Context, key string) (Result, error) {
+	now := time.Now()
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	tokens := l.burst
+	if b, ok := l.buckets[key]; ok {
+		tokens = math.Min(l.burst, b.tokens+now.Sub(b.last).Seconds()*l.rate)
+	}
+
+	result := Result{Limit: int(l.burst), Allowed: tokens >= 1, Remaining: int(tokens)}
+	if !result.Allowed {
+		result.RetryAfter = l.durationFor(1 - tokens)
+	}
+	result.ResetAfter = l.durationFor(l.burst - tokens)
+	return result, nil
+}
+
+// durationFor retourne le temps nécessaire pour regagner tokens jetons.
+func (l *MemoryLimiter) durationFor(tokens float64) time.Duration {
+	if tokens <= 0 || l.rate <= 0 {
+		return 0
+	}
+	return time.Duration(tokens / l.rate * float64(time.Second))
+}
+
+// sweepLocked supprime les seaux redevenus pleins : une clé inactive ne doit pas occuper de mémoire,
+// et un seau plein équivaut à une clé jamais vue. l.mu doit être détenu.
+func (l *MemoryLimiter) sweepLocked(now time.Time) {
+	for key, b := range l.buckets {
+		if b.tokens+now.Sub(b.last).Seconds()*l.rate >= l.burst {
+			delete(l.buckets, key)
+		}
+	}
+	l.lastSweep = now
+}
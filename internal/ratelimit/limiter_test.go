@@ -0,0 +1,40 @@
+package ratelimit
+
+import (
+	"context"
+	"testing"
+)
+
+func TestMemoryLimiterAllowConsumesBurst(t *testing.T) {
+	limiter := NewMemoryLimiter(1, 2)
+	ctx := context.Background()
+
+	for i := 0; i < 2; i++ {
+		result, _ := limiter.Allow(ctx, "k")
+		if !result.Allowed {
+			t.Fatalf("requête %d refusée, attendu acceptée", i+1)
+		}
+	}
+	result, _ := limiter.Allow(ctx, "k")
+	if result.Allowed || result.RetryAfter <= 0 {
+		t.Fatalf("requête au-delà de la rafale : %+v, attendu refusée avec RetryAfter", result)
+	}
+	if other, _ := limiter.Allow(ctx, "autre"); !other.Allowed {
+		t.Fatal("les clés doivent avoir des seaux distincts")
+	}
+}
+
+func TestMemoryLimiterPeekDoesNotConsume(t *testing.T) {
+	limiter := NewMemoryLimiter(1, 1)
+	ctx := context.Background()
+
+	for i := 0; i < 3; i++ {
+		if result, _ := limiter.Peek(ctx, "k"); !result.Allowed || result.Remaining != 1 {
+			t.Fatalf("Peek %d : %+v, attendu accepté avec 1 jeton", i+1, result)
+		}
+	}
+	limiter.Allow(ctx, "k")
+	if result, _ := limiter.Peek(ctx, "k"); result.Allowed || result.RetryAfter <= 0 {
+		t.Fatalf("Peek sur un seau vide : %+v, attendu refusé avec RetryAfter", result)
+	}
+}
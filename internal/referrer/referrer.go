@@ -0,0 +1,45 @@
+package referrer
+
+import (
+	"net"
+	"net/url"
+	"strings"
+)
+
+// Direct est la valeur utilisée pour les clics sans en-tête Referer (saisie directe, favoris, applications...).
+const Direct = "direct"
+
+// maxDomainLength correspond à la taille de la colonne Click.Referrer.
+const maxDomainLength = 255
+
+// Normalize réduit la valeur brute de l'en-tête Referer à son nom de domaine, en minuscules,
+// sans port ni préfixe "www." : "https://www.Google.com:443/search?q=x" devient "google.com".
+// Le chemin et les paramètres, qui peuvent contenir des données personnelles, ne sont jamais conservés.
+// Une valeur vide ou illisible retourne une chaîne vide.
+func Normalize(raw string) string {
+	raw = strings.TrimSpace(raw)
+	if raw == "" {
+		return ""
+	}
+
+	u, err := url.Parse(raw)
+	if err != nil {
+		return ""
+	}
+	// Un Referer sans schéma ("example.com/page") est interprété comme une URL absolue
+	if u.Host == "" && u.Scheme == "" {
+		if u, err = url.Parse("//" + raw); err != nil {
+			return ""
+		}
+	}
+
+	host := strings.ToLower(strings.TrimSuffix(u.Hostname(), "."))
+	if host == "" || len(host) > maxDomainLength {
+		return ""
+	}
+	// Les adresses IP sont conservées telles quelles, seuls les noms de domaine perdent le préfixe "www."
+	if net.ParseIP(host) == nil {
+		host = strings.TrimPrefix(host, "www.")
+	}
+	return host
+}
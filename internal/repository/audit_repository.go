@@ -0,0 +1,40 @@
+package repository
+
+import (
+	"github.com/axellelanca/urlshortener/internal/models"
+	"gorm.io/gorm"
+)
+
+// AuditRepository est une interface qui définit les méthodes d'accès aux données
+// pour le journal d'audit des opérations sur les liens.
+type AuditRepository interface {
+	CreateAuditLog(entry *models.AuditLog) error
+	ListAuditLogsByLinkID(linkID uint) ([]models.AuditLog, error)
+}
+
+// GormAuditRepository est l'implémentation de l'interface AuditRepository utilisant GORM.
+type GormAuditRepository struct {
+	db *gorm.DB
+}
+
+// NewAuditRepository crée et retourne une nouvelle instance de GormAuditRepository.
+func NewAuditRepository(db *gorm.DB) *GormAuditRepository {
+	return &GormAuditRepository{db: db}
+}
+
+// CreateAuditLog insère une nouvelle entrée dans le journal d'audit.
+func (r *GormAuditRepository) CreateAuditLog(entry *models.AuditLog) error {
+	if err := r.db.Create(entry).Error; err != nil {
+		return err
+	}
+	return nil
+}
+
+// ListAuditLogsByLinkID récupère l'historique des opérations d'un lien, du plus ancien au plus récent.
+func (r *GormAuditRepository) ListAuditLogsByLinkID(linkID uint) ([]models.AuditLog, error) {
+	var entries []models.AuditLog
+	if err := r.db.Where("link_id = ?", linkID).Order("created_at, id").Find(&entries).Error; err != nil {
+		return nil, err
+	}
+	return entries, nil
+}
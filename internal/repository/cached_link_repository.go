@@ -0,0 +1,228 @@
+package repository
+
+import (
+	"container/list"
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/axellelanca/urlshortener/internal/metrics"
+	"github.com/axellelanca/urlshortener/internal/models"
+	"gorm.io/gorm"
+)
+
+// CachedLinkRepository est un décorateur de LinkRepository qui garde en mémoire les résultats de
+// GetLinkByShortCode dans un cache LRU borné, avec une durée de vie par entrée. Les codes inconnus sont
+// aussi mis en cache (cache négatif) pour une durée plus courte. Les écritures passant par ce décorateur
+// invalident les entrées concernées ; celles faites par un autre processus (CLI, autre instance) ne sont
+// visibles qu'à l'expiration des entrées.
+type CachedLinkRepository struct {
+	LinkRepository // Les méthodes non redéfinies sont déléguées telles quelles
+
+	capacity    int
+	ttl         time.Duration
+	negativeTTL time.Duration
+
+	mu      sync.Mutex
+	entries map[string]*list.Element // Par code court
+	order   *list.List               // Du plus récemment au moins récemment utilisé
+	codes   map[uint]string          // Code court des liens en cache, par ID, pour l'invalidation
+	// generation est incrémenté à chaque invalidation : un résultat lu en base avant une invalidation
+	// concurrente n'est pas mis en cache, il pourrait être antérieur à l'écriture.
+	generation uint64
+}
+
+// linkCacheEntry est une entrée du cache : un lien, ou nil pour un code inconnu.
+type linkCacheEntry struct {
+	shortCode string
+	link      *models.Link
+	expiresAt time.Time
+}
+
+// NewCachedLinkRepository place un cache de capacity entrées devant next. Les liens restent en cache
+// au plus ttl, les codes inconnus au plus negativeTTL (pas de cache négatif si negativeTTL <= 0).
+func NewCachedLinkRepository(next LinkRepository, capacity int, ttl, negativeTTL time.Duration) *CachedLinkRepository {
+	return &CachedLinkRepository{
+		LinkRepository: next,
+		capacity:       capacity,
+		ttl:            ttl,
+		negativeTTL:    negativeTTL,
+		entries:        make(map[string]*list.Element),
+		order:          list.New(),
+		codes:          make(map[uint]string),
+	}
+}
+
+// GetLinkByShortCode retourne le lien depuis le cache s'il y est encore valide, sinon l'interroge
+// et met le résultat en cache. Chaque appel reçoit sa propre copie du lien.
+func (r *CachedLinkRepository) GetLinkByShortCode(shortCode string) (*models.Link, error) {
+	if link, found := r.lookup(shortCode); found {
+		metrics.LinkCacheRequestsTotal.WithLabelValues("hit").Inc()
+		if link == nil {
+			return nil, gorm.ErrRecordNotFound
+		}
+		return link, nil
+	}
+	metrics.LinkCacheRequestsTotal.WithLabelValues("miss").Inc()
+
+	r.mu.Lock()
+	generation := r.generation
+	r.mu.Unlock()
+
+	link, err := r.LinkRepository.GetLinkByShortCode(shortCode)
+	switch {
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		r.store(shortCode, nil, r.negativeTTL, generation)
+	case err != nil:
+		// Les erreurs de la base ne sont pas mises en cache
+	case link.MaxClicks > 0:
+		// Le compteur de redirections d'un lien limité change à chaque clic : il est toujours relu
+	default:
+		r.store(shortCode, copyLink(link), r.ttl, generation)
+	}
+	return link, err
+}
+
+// CreateLink insère le lien et retire l'éventuelle entrée négative de son code court.
+func (r *CachedLinkRepository) CreateLink(link *models.Link) error {
+	err := r.LinkRepository.CreateLink(link)
+	r.invalidate(link.ShortCode)
+	return err
+}
+
+// UpdateLink enregistre le lien et retire son entrée du cache.
+func (r *CachedLinkRepository) UpdateLink(link *models.Link) error {
+	err := r.LinkRepository.UpdateLink(link)
+	r.invalidate(link.ShortCode)
+	return err
+}
+
+// DeleteLink supprime logiquement le lien et retire son entrée du cache.
+func (r *CachedLinkRepository) DeleteLink(linkID uint) error {
+	err := r.LinkRepository.DeleteLink(linkID)
+	r.invalidateID(linkID)
+	return err
+}
+
+// RestoreLink restaure le lien et retire son entrée du cache.
+func (r *CachedLinkRepository) RestoreLink(linkID uint) error {
+	err := r.LinkRepository.RestoreLink(linkID)
+	r.invalidateID(linkID)
+	return err
+}
+
+// IncrementUnlockFailures incrémente le compteur d'échecs du lien et retire son entrée du cache,
+// pour que les statistiques affichent le compteur à jour.
+func (r *CachedLinkRepository) IncrementUnlockFailures(linkID uint) error {
+	err := r.LinkRepository.IncrementUnlockFailures(linkID)
+	r.invalidateID(linkID)
+	return err
+}
+
+// SetLinkOwner change le propriétaire du lien et retire son entrée du cache.
+func (r *CachedLinkRepository) SetLinkOwner(linkID uint, ownerID uint) error {
+	err := r.LinkRepository.SetLinkOwner(linkID, ownerID)
+	r.invalidateID(linkID)
+	return err
+}
+
+// Len retourne le nombre d'entrées du cache, expirées comprises.
+func (r *CachedLinkRepository) Len() int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.order.Len()
+}
+
+// lookup retourne une copie du lien en cache pour shortCode. found est faux si le code n'est pas en cache
+// ou si son entrée a expiré ; link est nil pour une entrée négative.
+func (r *CachedLinkRepository) lookup(shortCode string) (link *models.Link, found bool) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	element, ok := r.entries[shortCode]
+	if !ok {
+		return nil, false
+	}
+	entry := element.Value.(*linkCacheEntry)
+	if time.Now().After(entry.expiresAt) {
+		r.removeLocked(element)
+		return nil, false
+	}
+	r.order.MoveToFront(element)
+	if entry.link == nil {
+		return nil, true
+	}
+	return copyLink(entry.link), true
+}
+
+// store met en cache le lien (nil pour un code inconnu), lu en base à la génération generation,
+// et évince l'entrée la moins récemment utilisée si besoin.
+func (r *CachedLinkRepository) store(shortCode string, link *models.Link, ttl time.Duration, generation uint64) {
+	if r.capacity <= 0 || ttl <= 0 {
+		return
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if generation != r.generation {
+		return
+	}
+
+	if element, ok := r.entries[shortCode]; ok {
+		r.removeLocked(element)
+	}
+	entry := &linkCacheEntry{shortCode: shortCode, link: link, expiresAt: time.Now().Add(ttl)}
+	r.entries[shortCode] = r.order.PushFront(entry)
+	if link != nil {
+		r.codes[link.ID] = shortCode
+	}
+	for r.order.Len() > r.capacity {
+		r.removeLocked(r.order.Back())
+	}
+	metrics.LinkCacheEntries.Set(float64(r.order.Len()))
+}
+
+// invalidate retire l'entrée d'un code court.
+func (r *CachedLinkRepository) invalidate(shortCode string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.generation++
+	if element, ok := r.entries[shortCode]; ok {
+		r.removeLocked(element)
+	}
+}
+
+// invalidateID retire l'entrée du lien d'ID linkID.
+func (r *CachedLinkRepository) invalidateID(linkID uint) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.generation++
+	if shortCode, ok := r.codes[linkID]; ok {
+		r.removeLocked(r.entries[shortCode])
+	}
+}
+
+// removeLocked retire une entrée du cache. r.mu doit être détenu.
+func (r *CachedLinkRepository) removeLocked(element *list.Element) {
+	entry := r.order.Remove(element).(*linkCacheEntry)
+	delete(r.entries, entry.shortCode)
+	if entry.link != nil {
+		delete(r.codes, entry.link.ID)
+	}
+	metrics.LinkCacheEntries.Set(float64(r.order.Len()))
+}
+
+// copyLink copie un lien, y compris les valeurs pointées, pour que les appelants
+// puissent modifier le lien reçu sans altérer le cache.
+func copyLink(link *models.Link) *models.Link {
+	copied := *link
+	if link.ExpiresAt != nil {
+		expiresAt := *link.ExpiresAt
+		copied.ExpiresAt = &expiresAt
+	}
+	if link.OwnerID != nil {
+		ownerID := *link.OwnerID
+		copied.OwnerID = &ownerID
+	}
+	return &copied
+}
@@ -0,0 +1,269 @@
+package repository
+
+import (
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/axellelanca/urlshortener/internal/models"
+	"gorm.io/gorm"
+)
+
+// fakeLinkRepository est un LinkRepository en mémoire qui compte les lectures par code court.
+// Si onRead est défini, la lecture suivante l'appelle après avoir lu le lien, avant de le retourner.
+type fakeLinkRepository struct {
+	LinkRepository
+
+	mu     sync.Mutex
+	links  map[string]*models.Link
+	reads  map[string]int
+	onRead func()
+}
+
+func newFakeLinkRepository(links ...*models.Link) *fakeLinkRepository {
+	r := &fakeLinkRepository{links: make(map[string]*models.Link), reads: make(map[string]int)}
+	for _, link := range links {
+		r.links[link.ShortCode] = link
+	}
+	return r
+}
+
+func (r *fakeLinkRepository) GetLinkByShortCode(shortCode string) (*models.Link, error) {
+	r.mu.Lock()
+	r.reads[shortCode]++
+	link, ok := r.links[shortCode]
+	if ok {
+		link = copyLink(link) // Comme une lecture en base, chaque appel reçoit un nouvel objet
+	}
+	onRead := r.onRead
+	r.onRead = nil
+	r.mu.Unlock()
+
+	if onRead != nil {
+		onRead()
+	}
+	if !ok {
+		return nil, gorm.ErrRecordNotFound
+	}
+	return link, nil
+}
+
+func (r *fakeLinkRepository) CreateLink(link *models.Link) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.links[link.ShortCode] = link
+	return nil
+}
+
+func (r *fakeLinkRepository) UpdateLink(link *models.Link) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	updated := *link
+	r.links[link.ShortCode] = &updated
+	return nil
+}
+
+func (r *fakeLinkRepository) DeleteLink(linkID uint) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for code, link := range r.links {
+		if link.ID == linkID {
+			delete(r.links, code)
+		}
+	}
+	return nil
+}
+
+// readCount retourne le nombre de lectures de shortCode transmises au dépôt.
+func (r *fakeLinkRepository) readCount(shortCode string) int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.reads[shortCode]
+}
+
+// mustGet lit un lien existant à travers le cache.
+func mustGet(t *testing.T, repo LinkRepository, shortCode string) *models.Link {
+	t.Helper()
+	link, err := repo.GetLinkByShortCode(shortCode)
+	if err != nil {
+		t.Fatalf("GetLinkByShortCode(%q): %v", shortCode, err)
+	}
+	return link
+}
+
+func TestCachedLinkRepositoryServesHitsFromCache(t *testing.T) {
+	next := newFakeLinkRepository(&models.Link{ID: 1, ShortCode: "abc", LongURL: "https://example.com/"})
+	cached := NewCachedLinkRepository(next, 10, time.Minute, time.Minute)
+
+	for i := 0; i < 3; i++ {
+		if link := mustGet(t, cached, "abc"); link.LongURL != "https://example.com/" {
+			t.Fatalf("LongURL = %q", link.LongURL)
+		}
+	}
+	if got := next.readCount("abc"); got != 1 {
+		t.Fatalf("%d lecture(s) en base, attendu 1", got)
+	}
+}
+
+func TestCachedLinkRepositoryEvictsLeastRecentlyUsed(t *testing.T) {
+	next := newFakeLinkRepository(
+		&models.Link{ID: 1, ShortCode: "a"},
+		&models.Link{ID: 2, ShortCode: "b"},
+		&models.Link{ID: 3, ShortCode: "c"},
+	)
+	cached := NewCachedLinkRepository(next, 2, time.Minute, time.Minute)
+
+	mustGet(t, cached, "a")
+	mustGet(t, cached, "b")
+	mustGet(t, cached, "a") // "b" devient le moins récemment utilisé
+	mustGet(t, cached, "c") // évince "b"
+	if cached.Len() != 2 {
+		t.Fatalf("Len() = %d, attendu 2", cached.Len())
+	}
+
+	mustGet(t, cached, "a")
+	mustGet(t, cached, "c")
+	if next.readCount("a") != 1 || next.readCount("c") != 1 {
+		t.Fatalf("lectures en base : a=%d c=%d, attendu 1 chacun", next.readCount("a"), next.readCount("c"))
+	}
+	mustGet(t, cached, "b")
+	if got := next.readCount("b"); got != 2 {
+		t.Fatalf("'b' lu %d fois en base, attendu 2 après son éviction", got)
+	}
+
+	// Une entrée évincée ne doit plus être invalidée par son ID
+	if err := cached.DeleteLink(1); err != nil {
+		t.Fatal(err)
+	}
+	if cached.Len() != 2 {
+		t.Fatalf("Len() = %d après l'invalidation d'une entrée évincée, attendu 2", cached.Len())
+	}
+}
+
+func TestCachedLinkRepositoryExpiresEntries(t *testing.T) {
+	next := newFakeLinkRepository(&models.Link{ID: 1, ShortCode: "abc"})
+	cached := NewCachedLinkRepository(next, 10, 30*time.Millisecond, time.Minute)
+
+	mustGet(t, cached, "abc")
+	mustGet(t, cached, "abc")
+	if got := next.readCount("abc"); got != 1 {
+		t.Fatalf("%d lecture(s) en base avant expiration, attendu 1", got)
+	}
+	time.Sleep(50 * time.Millisecond)
+	mustGet(t, cached, "abc")
+	if got := next.readCount("abc"); got != 2 {
+		t.Fatalf("%d lecture(s) en base après expiration, attendu 2", got)
+	}
+}
+
+func TestCachedLinkRepositoryNegativeCache(t *testing.T) {
+	next := newFakeLinkRepository()
+	cached := NewCachedLinkRepository(next, 10, time.Minute, 30*time.Millisecond)
+
+	for i := 0; i < 2; i++ {
+		if _, err := cached.GetLinkByShortCode("absent"); !errors.Is(err, gorm.ErrRecordNotFound) {
+			t.Fatalf("GetLinkByShortCode = %v, attendu gorm.ErrRecordNotFound", err)
+		}
+	}
+	if got := next.readCount("absent"); got != 1 {
+		t.Fatalf("%d lecture(s) en base, attendu 1 grâce au cache négatif", got)
+	}
+
+	// Le lien créé par un autre processus n'est visible qu'à l'expiration de l'entrée négative
+	next.CreateLink(&models.Link{ID: 7, ShortCode: "absent"})
+	if _, err := cached.GetLinkByShortCode("absent"); !errors.Is(err, gorm.ErrRecordNotFound) {
+		t.Fatalf("GetLinkByShortCode avant expiration = %v, attendu gorm.ErrRecordNotFound", err)
+	}
+	time.Sleep(50 * time.Millisecond)
+	if link := mustGet(t, cached, "absent"); link.ID != 7 {
+		t.Fatalf("ID = %d, attendu 7", link.ID)
+	}
+
+	// Créé à travers le cache, le lien est visible immédiatement
+	if _, err := cached.GetLinkByShortCode("new"); !errors.Is(err, gorm.ErrRecordNotFound) {
+		t.Fatalf("GetLinkByShortCode = %v, attendu gorm.ErrRecordNotFound", err)
+	}
+	if err := cached.CreateLink(&models.Link{ID: 8, ShortCode: "new"}); err != nil {
+		t.Fatal(err)
+	}
+	if link := mustGet(t, cached, "new"); link.ID != 8 {
+		t.Fatalf("ID = %d, attendu 8", link.ID)
+	}
+
+	// Sans durée négative, les codes inconnus ne sont pas mis en cache
+	uncached := NewCachedLinkRepository(next, 10, time.Minute, 0)
+	uncached.GetLinkByShortCode("other")
+	uncached.GetLinkByShortCode("other")
+	if got := next.readCount("other"); got != 2 {
+		t.Fatalf("%d lecture(s) en base sans cache négatif, attendu 2", got)
+	}
+}
+
+func TestCachedLinkRepositoryInvalidationDuringFill(t *testing.T) {
+	next := newFakeLinkRepository(&models.Link{ID: 1, ShortCode: "abc", LongURL: "https://old.example/"})
+	cached := NewCachedLinkRepository(next, 10, time.Minute, time.Minute)
+
+	// La lecture commence avant la mise à jour et se termine après : son résultat est périmé
+	reading, release := make(chan struct{}), make(chan struct{})
+	next.onRead = func() {
+		close(reading)
+		<-release
+	}
+	stale := make(chan *models.Link)
+	go func() {
+		link, _ := cached.GetLinkByShortCode("abc")
+		stale <- link
+	}()
+	<-reading
+	if err := cached.UpdateLink(&models.Link{ID: 1, ShortCode: "abc", LongURL: "https://new.example/"}); err != nil {
+		t.Fatal(err)
+	}
+	close(release)
+
+	if link := <-stale; link.LongURL != "https://old.example/" {
+		t.Fatalf("la lecture concurrente devrait retourner l'ancienne valeur, obtenu %q", link.LongURL)
+	}
+	if link := mustGet(t, cached, "abc"); link.LongURL != "https://new.example/" {
+		t.Fatalf("LongURL = %q après la mise à jour : la lecture périmée a été mise en cache", link.LongURL)
+	}
+}
+
+func TestCachedLinkRepositoryReturnsIsolatedCopies(t *testing.T) {
+	expiresAt := time.Date(2099, time.January, 1, 0, 0, 0, 0, time.UTC)
+	ownerID := uint(42)
+	next := newFakeLinkRepository(&models.Link{ID: 1, ShortCode: "abc", LongURL: "https://example.com/", ExpiresAt: &expiresAt, OwnerID: &ownerID})
+	cached := NewCachedLinkRepository(next, 10, time.Minute, time.Minute)
+
+	// Le lien retourné par la lecture qui remplit le cache, puis celui d'un succès, sont modifiés par l'appelant
+	first := mustGet(t, cached, "abc")
+	first.LongURL = "https://first.example/"
+	*first.ExpiresAt = time.Time{}
+	*first.OwnerID = 1
+	second := mustGet(t, cached, "abc")
+	if second == first {
+		t.Fatal("deux lectures ont reçu le même pointeur")
+	}
+	second.LongURL = "https://second.example/"
+	*second.ExpiresAt = time.Time{}
+	second.OwnerID = nil
+
+	link := mustGet(t, cached, "abc")
+	if link.LongURL != "https://example.com/" || !link.ExpiresAt.Equal(expiresAt) || link.OwnerID == nil || *link.OwnerID != 42 {
+		t.Fatalf("le lien en cache a été modifié par un appelant : %+v", link)
+	}
+	if got := next.readCount("abc"); got != 1 {
+		t.Fatalf("%d lecture(s) en base, attendu 1", got)
+	}
+}
+
+func TestCachedLinkRepositoryDoesNotCacheLimitedLinks(t *testing.T) {
+	next := newFakeLinkRepository(&models.Link{ID: 1, ShortCode: "abc", MaxClicks: 5})
+	cached := NewCachedLinkRepository(next, 10, time.Minute, time.Minute)
+
+	mustGet(t, cached, "abc")
+	mustGet(t, cached, "abc")
+	if got := next.readCount("abc"); got != 2 {
+		t.Fatalf("%d lecture(s) en base, attendu 2 : un lien limité est toujours relu", got)
+	}
+}
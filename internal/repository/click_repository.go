@@ -1,8 +1,13 @@
 package repository
 
 import (
+	"fmt"
+	"sort"
+	"time"
+
 	"github.com/axellelanca/urlshortener/internal/models"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 // ClickRepository est une interface qui définit les méthodes d'accès aux données
@@ -10,7 +15,14 @@ import (
 // de rester indépendante de l'implémentation spécifique de la base de données.
 type ClickRepository interface {
 	CreateClick(click *models.Click) error
-	CountClicksByLinkID(linkID uint) (int, error) // INFO: Utilisé par LinkService pour les stats
+	CreateClicks(clicks []models.Click) error
+	CountClicksByLinkID(linkID uint, includeBots bool) (int, error) // INFO: Utilisé par LinkService pour les stats
+	CountClicksByInterval(linkID uint, from, to time.Time, interval string, includeBots bool) ([]models.ClickBucket, error)
+	CountClicksGroupedBy(linkID uint, column string, limit int, includeBots bool) ([]models.DimensionCount, error)
+	CountUniqueVisitorsByLinkID(linkID uint, includeBots bool) (int, error)
+	CountClicksBefore(cutoff time.Time) (int64, error)
+	DeleteClicksBefore(cutoff time.Time) (int64, error)
+	AggregateClicksBefore(cutoff time.Time) (int64, error)
 }
 
 // GormClickRepository est l'implémentation de l'interface ClickRepository utilisant GORM.
@@ -24,19 +36,298 @@ func NewClickRepository(db *gorm.DB) *GormClickRepository {
 }
 
 // CreateClick insère un nouvel enregistrement de clic dans la base de données.
+// Un clic dont l'EventID est déjà enregistré (événement rejoué) est ignoré sans erreur.
 func (r *GormClickRepository) CreateClick(click *models.Click) error {
-	if err := r.db.Create(click).Error; err != nil {
+	if err := r.db.Clauses(clause.OnConflict{DoNothing: true}).Create(click).Error; err != nil {
 		return err
 	}
 	return nil
 }
 
+// maxInsertRows limite le nombre de lignes par requête INSERT, pour rester sous la limite
+// de paramètres par requête de SQLite quelle que soit la taille du lot.
+const maxInsertRows = 200
+
+// CreateClicks insère un lot de clics. Les requêtes nécessaires sont exécutées dans une même transaction :
+// en cas d'erreur, aucun clic du lot n'est enregistré. Comme pour CreateClick, les doublons d'EventID sont ignorés.
+func (r *GormClickRepository) CreateClicks(clicks []models.Click) error {
+	if len(clicks) == 0 {
+		return nil
+	}
+	return r.db.Clauses(clause.OnConflict{DoNothing: true}).CreateInBatches(clicks, maxInsertRows).Error
+}
+
 // CountClicksByLinkID compte le nombre total de clics pour un ID de lien donné.
 // INFO: Cette méthode est utilisée pour fournir des statistiques pour une URL courte.
-func (r *GormClickRepository) CountClicksByLinkID(linkID uint) (int, error) {
+// Les clics de robots ne sont comptés que si includeBots est vrai.
+// Les clics bruts déjà agrégés par la politique de rétention sont inclus.
+func (r *GormClickRepository) CountClicksByLinkID(linkID uint, includeBots bool) (int, error) {
+	var count int64
+	if err := r.clicksOf(linkID, includeBots).Count(&count).Error; err != nil {
+		return 0, err
+	}
+	var aggregated int64
+	if err := r.aggregatesOf(linkID, includeBots).Select("COALESCE(SUM(clicks), 0)").Scan(&aggregated).Error; err != nil {
+		return 0, err
+	}
+	return int(count + aggregated), nil // Convert the int64 count to an int
+}
+
+// Formats des périodes retournées par periodExpression.
+const (
+	hourPeriodLayout = "2006-01-02 15"
+	dayPeriodLayout  = "2006-01-02"
+)
+
+// periodExpression retourne l'expression SQL, propre au dialecte de la base, qui ramène la colonne timestamp
+// à son heure (hourly) ou à son jour UTC, sous forme de texte au format hourPeriodLayout ou dayPeriodLayout.
+// Avec MySQL, les dates sont enregistrées en UTC : database.Open impose loc=UTC dans le DSN.
+func periodExpression(dialect string, hourly bool) (string, error) {
+	switch dialect {
+	case "sqlite":
+		// strftime convertit en UTC les dates enregistrées avec un décalage horaire
+		if hourly {
+			return "strftime('%Y-%m-%d %H', timestamp)", nil
+		}
+		return "strftime('%Y-%m-%d', timestamp)", nil
+	case "postgres":
+		if hourly {
+			return "to_char(timestamp AT TIME ZONE 'UTC', 'YYYY-MM-DD HH24')", nil
+		}
+		return "to_char(timestamp AT TIME ZONE 'UTC', 'YYYY-MM-DD')", nil
+	case "mysql":
+		if hourly {
+			return "DATE_FORMAT(timestamp, '%Y-%m-%d %H')", nil
+		}
+		return "DATE_FORMAT(timestamp, '%Y-%m-%d')", nil
+	}
+	return "", fmt.Errorf("[ClickRepository] regroupement temporel non pris en charge pour la base '%s'", dialect)
+}
+
+// periodCount est une ligne du regroupement des clics par heure ou par jour.
+type periodCount struct {
+	Period   string
+	Clicks   int
+	Visitors int
+}
+
+// CountClicksByInterval compte les clics et les visiteurs uniques d'un lien sur [from, to[ regroupés
+// par tranche de temps (heure, jour, semaine ou mois). Seules les tranches contenant au moins un clic
+// sont retournées, triées par ordre chronologique.
+// Le regroupement par heure ou par jour est fait en SQL ; les semaines et les mois additionnent ensuite
+// les jours côté Go. Le sel des empreintes changeant chaque jour, un visiteur n'a jamais la même empreinte
+// deux jours différents : la somme des visiteurs uniques journaliers est exacte.
+func (r *GormClickRepository) CountClicksByInterval(linkID uint, from, to time.Time, interval string, includeBots bool) ([]models.ClickBucket, error) {
+	hourly := interval == models.IntervalHour
+	period, err := periodExpression(r.db.Dialector.Name(), hourly)
+	if err != nil {
+		return nil, err
+	}
+	layout := dayPeriodLayout
+	if hourly {
+		layout = hourPeriodLayout
+	}
+
+	// Les clics antérieurs au calcul des empreintes n'en ont pas et ne sont pas comptés comme visiteurs
+	var counts []periodCount
+	if err := r.clicksOf(linkID, includeBots).
+		Select(period+" AS period, COUNT(*) AS clicks, COUNT(DISTINCT NULLIF(visitor_id, '')) AS visitors").
+		Where("timestamp >= ? AND timestamp < ?", from, to).
+		Group("period").
+		Order("period").
+		Scan(&counts).Error; err != nil {
+		return nil, err
+	}
+
+	var buckets []models.ClickBucket
+	for _, count := range counts {
+		periodStart, err := time.ParseInLocation(layout, count.Period, time.UTC)
+		if err != nil {
+			return nil, fmt.Errorf("[ClickRepository] période '%s' illisible: %w", count.Period, err)
+		}
+		start := models.TruncateToInterval(periodStart, interval)
+		// Les périodes étant triées, un nouveau bucket commence dès que la tranche change
+		if len(buckets) == 0 || !buckets[len(buckets)-1].Start.Equal(start) {
+			buckets = append(buckets, models.ClickBucket{Start: start})
+		}
+		bucket := &buckets[len(buckets)-1]
+		bucket.Clicks += count.Clicks
+		bucket.UniqueVisitors += count.Visitors
+	}
+
+	// Ajout des jours agrégés par la politique de rétention, dans la tranche contenant leur début
+	var aggregates []models.ClickDailyAggregate
+	if err := r.aggregatesOf(linkID, includeBots).
+		Where("day >= ? AND day < ?", from, to).
+		Find(&aggregates).Error; err != nil {
+		return nil, err
+	}
+	if len(aggregates) == 0 {
+		return buckets, nil
+	}
+	byStart := make(map[time.Time]models.ClickBucket, len(buckets)+len(aggregates))
+	for _, bucket := range buckets {
+		byStart[bucket.Start] = bucket
+	}
+	for _, aggregate := range aggregates {
+		start := models.TruncateToInterval(aggregate.Day, interval)
+		bucket := byStart[start]
+		bucket.Start = start
+		bucket.Clicks += aggregate.Clicks
+		bucket.UniqueVisitors += aggregate.UniqueVisitors
+		byStart[start] = bucket
+	}
+	merged := make([]models.ClickBucket, 0, len(byStart))
+	for _, bucket := range byStart {
+		merged = append(merged, bucket)
+	}
+	sort.Slice(merged, func(i, j int) bool { return merged[i].Start.Before(merged[j].Start) })
+	return merged, nil
+}
+
+// CountClicksGroupedBy compte les clics d'un lien regroupés par les valeurs d'une colonne
+// (ex: "browser", "os"), du plus fréquent au moins fréquent. Une limite <= 0 retourne toutes les valeurs.
+// ATTENTION : column est injectée telle quelle dans la requête, l'appelant doit la valider.
+func (r *GormClickRepository) CountClicksGroupedBy(linkID uint, column string, limit int, includeBots bool) ([]models.DimensionCount, error) {
+	var counts []models.DimensionCount
+	query := r.clicksOf(linkID, includeBots).
+		Select(column + " AS value, COUNT(*) AS clicks").
+		Group(column).
+		Order("clicks DESC, value")
+	if limit > 0 {
+		query = query.Limit(limit)
+	}
+	if err := query.Scan(&counts).Error; err != nil {
+		return nil, err
+	}
+	return counts, nil
+}
+
+// CountUniqueVisitorsByLinkID compte les empreintes de visiteurs distinctes d'un lien.
+// Le sel des empreintes changeant chaque jour, un même visiteur revenu plusieurs jours est compté une fois par jour.
+func (r *GormClickRepository) CountUniqueVisitorsByLinkID(linkID uint, includeBots bool) (int, error) {
 	var count int64
-	if err := r.db.Model(&models.Click{}).Where("link_id = ?", linkID).Count(&count).Error; err != nil {
+	if err := r.clicksOf(linkID, includeBots).
+		Where("visitor_id <> ''").
+		Distinct("visitor_id").
+		Count(&count).Error; err != nil {
+		return 0, err
+	}
+	var aggregated int64
+	if err := r.aggregatesOf(linkID, includeBots).Select("COALESCE(SUM(unique_visitors), 0)").Scan(&aggregated).Error; err != nil {
+		return 0, err
+	}
+	return int(count + aggregated), nil
+}
+
+// CountClicksBefore compte les clics bruts, tous liens confondus, antérieurs à cutoff.
+func (r *GormClickRepository) CountClicksBefore(cutoff time.Time) (int64, error) {
+	var count int64
+	err := r.db.Model(&models.Click{}).Where("timestamp < ?", cutoff).Count(&count).Error
+	return count, err
+}
+
+// DeleteClicksBefore supprime définitivement les clics bruts antérieurs à cutoff et retourne leur nombre.
+func (r *GormClickRepository) DeleteClicksBefore(cutoff time.Time) (int64, error) {
+	result := r.db.Where("timestamp < ?", cutoff).Delete(&models.Click{})
+	return result.RowsAffected, result.Error
+}
+
+// AggregateClicksBefore remplace les clics bruts antérieurs à cutoff par des agrégats journaliers
+// (nombre de clics et de visiteurs uniques par lien, jour UTC et statut robot) et retourne le nombre de clics
+// bruts supprimés. Les totaux sont calculés par la base, un jour à la fois et chacun dans sa propre transaction :
+// la mémoire utilisée dépend du nombre de liens cliqués dans une journée, pas du nombre de clics.
+// cutoff doit être un minuit UTC pour qu'un jour ne soit jamais agrégé en plusieurs fois,
+// les visiteurs uniques ne pouvant pas être additionnés sans doublons.
+func (r *GormClickRepository) AggregateClicksBefore(cutoff time.Time) (int64, error) {
+	// Même découpage en jours que CountClicksByInterval
+	dayExpr, err := periodExpression(r.db.Dialector.Name(), false)
+	if err != nil {
+		return 0, err
+	}
+	var days []string
+	if err := r.db.Model(&models.Click{}).
+		Select("DISTINCT "+dayExpr).
+		Where("timestamp < ?", cutoff).
+		Scan(&days).Error; err != nil {
 		return 0, err
 	}
-	return int(count), nil // Convert the int64 count to an int
+	sort.Strings(days)
+
+	var deleted int64
+	for _, day := range days {
+		count, err := r.aggregateDay(dayExpr, day, cutoff)
+		if err != nil {
+			return deleted, err
+		}
+		deleted += count
+	}
+	return deleted, nil
+}
+
+// dailyCount est une ligne du regroupement des clics d'un jour par lien et statut robot.
+type dailyCount struct {
+	LinkID   uint
+	IsBot    bool
+	Clicks   int
+	Visitors int
+}
+
+// aggregateDay remplace, dans une transaction, les clics antérieurs à cutoff du jour day (au format
+// dayPeriodLayout, calculé par dayExpr) par leurs agrégats, et retourne le nombre de clics supprimés.
+func (r *GormClickRepository) aggregateDay(dayExpr, day string, cutoff time.Time) (int64, error) {
+	start, err := time.ParseInLocation(dayPeriodLayout, day, time.UTC)
+	if err != nil {
+		return 0, fmt.Errorf("[ClickRepository] jour '%s' inattendu: %w", day, err)
+	}
+
+	var deleted int64
+	err = r.db.Transaction(func(tx *gorm.DB) error {
+		clicksOfDay := func() *gorm.DB {
+			return tx.Model(&models.Click{}).Where("timestamp < ? AND "+dayExpr+" = ?", cutoff, day)
+		}
+
+		var counts []dailyCount
+		if err := clicksOfDay().
+			Select("link_id, is_bot, COUNT(*) AS clicks, COUNT(DISTINCT NULLIF(visitor_id, '')) AS visitors").
+			Group("link_id, is_bot").
+			Scan(&counts).Error; err != nil {
+			return err
+		}
+		for _, count := range counts {
+			aggregate := models.ClickDailyAggregate{LinkID: count.LinkID, Day: start, IsBot: count.IsBot}
+			if err := tx.Where(&aggregate, "LinkID", "Day", "IsBot").FirstOrCreate(&aggregate).Error; err != nil {
+				return err
+			}
+			if err := tx.Model(&aggregate).Updates(map[string]any{
+				"clicks":          gorm.Expr("clicks + ?", count.Clicks),
+				"unique_visitors": gorm.Expr("unique_visitors + ?", count.Visitors),
+			}).Error; err != nil {
+				return err
+			}
+		}
+
+		result := clicksOfDay().Delete(&models.Click{})
+		deleted = result.RowsAffected
+		return result.Error
+	})
+	return deleted, err
+}
+
+// clicksOf prépare une requête sur les clics d'un lien, en excluant les robots sauf si includeBots est vrai.
+func (r *GormClickRepository) clicksOf(linkID uint, includeBots bool) *gorm.DB {
+	query := r.db.Model(&models.Click{}).Where("link_id = ?", linkID)
+	if !includeBots {
+		query = query.Where("is_bot = ?", false)
+	}
+	return query
+}
+
+// aggregatesOf prépare une requête sur les agrégats journaliers d'un lien, avec le même filtre robots que clicksOf.
+func (r *GormClickRepository) aggregatesOf(linkID uint, includeBots bool) *gorm.DB {
+	query := r.db.Model(&models.ClickDailyAggregate{}).Where("link_id = ?", linkID)
+	if !includeBots {
+		query = query.Where("is_bot = ?", false)
+	}
+	return query
 }
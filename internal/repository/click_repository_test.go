@@ -0,0 +1,334 @@
+package repository
+
+import (
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/axellelanca/urlshortener/internal/config"
+	"github.com/axellelanca/urlshortener/internal/database"
+	"github.com/axellelanca/urlshortener/internal/database/dbtest"
+	"github.com/axellelanca/urlshortener/internal/migrations"
+	"github.com/axellelanca/urlshortener/internal/models"
+	"gorm.io/gorm"
+)
+
+// newTestDB ouvre une base SQLite migrée dans un répertoire temporaire.
+func newTestDB(tb testing.TB) *gorm.DB {
+	tb.Helper()
+	return openTestDB(tb, config.DatabaseConfig{Name: filepath.Join(tb.TempDir(), "test.db")})
+}
+
+// openTestDB ouvre la base décrite par cfg et y applique les migrations.
+func openTestDB(tb testing.TB, cfg config.DatabaseConfig) *gorm.DB {
+	tb.Helper()
+	db, err := database.Open(cfg)
+	if err != nil {
+		tb.Fatal(err)
+	}
+	if _, err := migrations.NewMigrator(db).Up(); err != nil {
+		tb.Fatal(err)
+	}
+	tb.Cleanup(func() {
+		if sqlDB, err := db.DB(); err == nil {
+			sqlDB.Close()
+		}
+	})
+	return db
+}
+
+// newTestLink crée un lien auquel rattacher des clics.
+func newTestLink(tb testing.TB, db *gorm.DB) *models.Link {
+	tb.Helper()
+	link := &models.Link{ShortCode: "bench", LongURL: "https://example.com"}
+	if err := db.Create(link).Error; err != nil {
+		tb.Fatal(err)
+	}
+	return link
+}
+
+func benchmarkClick(linkID uint) models.Click {
+	return models.Click{
+		LinkID:     linkID,
+		Timestamp:  time.Now(),
+		UserAgent:  "Mozilla/5.0 (X11; Linux x86_64; rv:120.0) Gecko/20100101 Firefox/120.0",
+		IPAddress:  "203.0.113.0",
+		Browser:    "Firefox",
+		OS:         "Linux",
+		DeviceType: "desktop",
+	}
+}
+
+// BenchmarkCreateClick mesure l'écriture des clics un par un, comme avant le regroupement par lots.
+func BenchmarkCreateClick(b *testing.B) {
+	db := newTestDB(b)
+	repo := NewClickRepository(db)
+	link := newTestLink(b, db)
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		click := benchmarkClick(link.ID)
+		if err := repo.CreateClick(&click); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+// BenchmarkCreateClicks mesure l'écriture des mêmes clics par lots de la taille par défaut des workers (100).
+// Le temps rapporté est par clic, comparable à BenchmarkCreateClick.
+func BenchmarkCreateClicks(b *testing.B) {
+	const batchSize = 100
+	db := newTestDB(b)
+	repo := NewClickRepository(db)
+	link := newTestLink(b, db)
+
+	batch := make([]models.Click, 0, batchSize)
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		batch = append(batch, benchmarkClick(link.ID))
+		if len(batch) == batchSize || i == b.N-1 {
+			if err := repo.CreateClicks(batch); err != nil {
+				b.Fatal(err)
+			}
+			batch = batch[:0]
+		}
+	}
+}
+
+func TestCreateClicksIgnoresReplayedEvents(t *testing.T) {
+	db := newTestDB(t)
+	repo := NewClickRepository(db)
+	link := newTestLink(t, db)
+
+	eventID := "replayed"
+	first := benchmarkClick(link.ID)
+	first.EventID = &eventID
+	if err := repo.CreateClick(&first); err != nil {
+		t.Fatal(err)
+	}
+
+	replayed := benchmarkClick(link.ID)
+	replayed.EventID = &eventID
+	if err := repo.CreateClicks([]models.Click{replayed, benchmarkClick(link.ID)}); err != nil {
+		t.Fatalf("CreateClicks avec un événement rejoué : %v", err)
+	}
+	count, err := repo.CountClicksByLinkID(link.ID, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if count != 2 {
+		t.Fatalf("%d clic(s) enregistré(s), attendu 2 (le doublon est ignoré)", count)
+	}
+}
+
+// testDatabases ouvre, pour chaque dialecte dont l'expression de regroupement temporel diffère,
+// une base migrée : SQLite, et PostgreSQL s'il est disponible (voir dbtest.Postgres).
+var testDatabases = []struct {
+	name string
+	open func(t *testing.T) *gorm.DB
+}{
+	{"sqlite", func(t *testing.T) *gorm.DB { return newTestDB(t) }},
+	{"postgres", func(t *testing.T) *gorm.DB { return openTestDB(t, dbtest.Postgres(t)) }},
+}
+
+func TestCountClicksByInterval(t *testing.T) {
+	for _, backend := range testDatabases {
+		t.Run(backend.name, func(t *testing.T) {
+			testCountClicksByInterval(t, backend.open(t))
+		})
+	}
+}
+
+func testCountClicksByInterval(t *testing.T, db *gorm.DB) {
+	repo := NewClickRepository(db)
+	link := newTestLink(t, db)
+
+	utc := func(day, hour, minute int) time.Time {
+		return time.Date(2026, time.October, day, hour, minute, 0, 0, time.UTC)
+	}
+	paris := time.FixedZone("CEST", 2*60*60)
+	clicks := []struct {
+		at      time.Time
+		visitor string
+		isBot   bool
+	}{
+		{utc(12, 9, 5), "a", false},  // Lundi 12 octobre
+		{utc(12, 9, 40), "a", false}, // Même visiteur, même heure
+		{utc(12, 10, 0), "b", false},
+		{utc(12, 10, 1), "", false}, // Clic sans empreinte
+		{utc(12, 11, 0), "c", true}, // Robot
+		// 00:30 à Paris : 22:30 UTC le dimanche 11 octobre, dans la semaine précédente
+		{time.Date(2026, time.October, 12, 0, 30, 0, 0, paris), "d", false},
+		{utc(14, 8, 0), "a2", false}, // Mercredi : nouveau sel, nouvelle empreinte
+		{utc(1, 12, 0), "e", false},  // Hors de la période demandée
+	}
+	for _, c := range clicks {
+		click := benchmarkClick(link.ID)
+		click.Timestamp, click.VisitorID, click.IsBot = c.at, c.visitor, c.isBot
+		if err := repo.CreateClick(&click); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	from, to := utc(5, 0, 0), utc(19, 0, 0)
+	tests := []struct {
+		interval    string
+		includeBots bool
+		want        []models.ClickBucket
+	}{
+		{models.IntervalHour, false, []models.ClickBucket{
+			{Start: utc(11, 22, 0), Clicks: 1, UniqueVisitors: 1},
+			{Start: utc(12, 9, 0), Clicks: 2, UniqueVisitors: 1},
+			{Start: utc(12, 10, 0), Clicks: 2, UniqueVisitors: 1},
+			{Start: utc(14, 8, 0), Clicks: 1, UniqueVisitors: 1},
+		}},
+		{models.IntervalDay, false, []models.ClickBucket{
+			{Start: utc(11, 0, 0), Clicks: 1, UniqueVisitors: 1},
+			{Start: utc(12, 0, 0), Clicks: 4, UniqueVisitors: 2},
+			{Start: utc(14, 0, 0), Clicks: 1, UniqueVisitors: 1},
+		}},
+		{models.IntervalDay, true, []models.ClickBucket{
+			{Start: utc(11, 0, 0), Clicks: 1, UniqueVisitors: 1},
+			{Start: utc(12, 0, 0), Clicks: 5, UniqueVisitors: 3},
+			{Start: utc(14, 0, 0), Clicks: 1, UniqueVisitors: 1},
+		}},
+		{models.IntervalWeek, false, []models.ClickBucket{
+			{Start: utc(5, 0, 0), Clicks: 1, UniqueVisitors: 1},
+			{Start: utc(12, 0, 0), Clicks: 5, UniqueVisitors: 3},
+		}},
+		{models.IntervalMonth, false, []models.ClickBucket{
+			{Start: utc(1, 0, 0), Clicks: 6, UniqueVisitors: 4},
+		}},
+	}
+	for _, tt := range tests {
+		got, err := repo.CountClicksByInterval(link.ID, from, to, tt.interval, tt.includeBots)
+		if err != nil {
+			t.Fatalf("%s : %v", tt.interval, err)
+		}
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%s (robots inclus : %v) = %+v\nattendu %+v", tt.interval, tt.includeBots, got, tt.want)
+		}
+	}
+}
+
+func TestCountClicksByIntervalIncludesDailyAggregates(t *testing.T) {
+	for _, backend := range testDatabases {
+		t.Run(backend.name, func(t *testing.T) {
+			testCountClicksByIntervalIncludesDailyAggregates(t, backend.open(t))
+		})
+	}
+}
+
+func testCountClicksByIntervalIncludesDailyAggregates(t *testing.T, db *gorm.DB) {
+	repo := NewClickRepository(db)
+	link := newTestLink(t, db)
+
+	click := benchmarkClick(link.ID)
+	click.Timestamp, click.VisitorID = time.Date(2026, time.October, 14, 8, 0, 0, 0, time.UTC), "a"
+	if err := repo.CreateClick(&click); err != nil {
+		t.Fatal(err)
+	}
+	aggregate := models.ClickDailyAggregate{LinkID: link.ID, Day: time.Date(2026, time.October, 12, 0, 0, 0, 0, time.UTC), Clicks: 7, UniqueVisitors: 3}
+	if err := db.Create(&aggregate).Error; err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := repo.CountClicksByInterval(link.ID, time.Date(2026, time.October, 1, 0, 0, 0, 0, time.UTC), time.Date(2026, time.October, 31, 0, 0, 0, 0, time.UTC), models.IntervalWeek, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []models.ClickBucket{{Start: time.Date(2026, time.October, 12, 0, 0, 0, 0, time.UTC), Clicks: 8, UniqueVisitors: 4}}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("semaine avec un jour agrégé = %+v, attendu %+v", got, want)
+	}
+}
+
+func TestAggregateClicksBefore(t *testing.T) {
+	for _, backend := range testDatabases {
+		t.Run(backend.name, func(t *testing.T) {
+			testAggregateClicksBefore(t, backend.open(t))
+		})
+	}
+}
+
+func testAggregateClicksBefore(t *testing.T, db *gorm.DB) {
+	repo := NewClickRepository(db)
+	link := newTestLink(t, db)
+
+	utc := func(day, hour int) time.Time {
+		return time.Date(2026, time.October, day, hour, 0, 0, 0, time.UTC)
+	}
+	paris := time.FixedZone("CEST", 2*60*60)
+	clicks := []struct {
+		at      time.Time
+		visitor string
+		isBot   bool
+	}{
+		{utc(10, 9), "a", false},
+		{utc(10, 10), "a", false},
+		{utc(10, 11), "b", false},
+		{utc(10, 12), "", false}, // Clic sans empreinte
+		{utc(10, 13), "c", true}, // Robot
+		// 00:30 à Paris : 22:30 UTC le 10 octobre
+		{time.Date(2026, time.October, 11, 0, 30, 0, 0, paris), "d", false},
+		{utc(11, 8), "a", false},
+		{utc(12, 0), "e", false}, // Postérieur à la date limite, conservé
+	}
+	for _, c := range clicks {
+		click := benchmarkClick(link.ID)
+		click.Timestamp, click.VisitorID, click.IsBot = c.at, c.visitor, c.isBot
+		if err := repo.CreateClick(&click); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	deleted, err := repo.AggregateClicksBefore(utc(12, 0))
+	if err != nil {
+		t.Fatalf("AggregateClicksBefore: %v", err)
+	}
+	if deleted != 7 {
+		t.Fatalf("%d clic(s) supprimé(s), attendu 7", deleted)
+	}
+	if remaining, err := repo.CountClicksBefore(utc(31, 0)); err != nil || remaining != 1 {
+		t.Fatalf("%d clic(s) restant(s) (erreur %v), attendu 1", remaining, err)
+	}
+
+	var aggregates []models.ClickDailyAggregate
+	if err := db.Order("day, is_bot").Find(&aggregates).Error; err != nil {
+		t.Fatal(err)
+	}
+	want := []models.ClickDailyAggregate{
+		{LinkID: link.ID, Day: utc(10, 0), IsBot: false, Clicks: 5, UniqueVisitors: 3},
+		{LinkID: link.ID, Day: utc(10, 0), IsBot: true, Clicks: 1, UniqueVisitors: 1},
+		{LinkID: link.ID, Day: utc(11, 0), IsBot: false, Clicks: 1, UniqueVisitors: 1},
+	}
+	if len(aggregates) != len(want) {
+		t.Fatalf("agrégats = %+v\nattendu %+v", aggregates, want)
+	}
+	for i, aggregate := range aggregates {
+		aggregate.ID, aggregate.Day = 0, aggregate.Day.UTC()
+		if aggregate != want[i] {
+			t.Errorf("agrégat %d = %+v, attendu %+v", i, aggregate, want[i])
+		}
+	}
+
+	// Un second passage n'a plus rien à agréger
+	if deleted, err := repo.AggregateClicksBefore(utc(12, 0)); err != nil || deleted != 0 {
+		t.Fatalf("second passage : %d clic(s) supprimé(s) (erreur %v), attendu 0", deleted, err)
+	}
+
+	// Les séries temporelles comptent les agrégats comme les clics bruts qu'ils remplacent
+	buckets, err := repo.CountClicksByInterval(link.ID, utc(1, 0), utc(31, 0), models.IntervalDay, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	wantBuckets := []models.ClickBucket{
+		{Start: utc(10, 0), Clicks: 5, UniqueVisitors: 3},
+		{Start: utc(11, 0), Clicks: 1, UniqueVisitors: 1},
+		{Start: utc(12, 0), Clicks: 1, UniqueVisitors: 1},
+	}
+	if !reflect.DeepEqual(buckets, wantBuckets) {
+		t.Fatalf("CountClicksByInterval = %+v\nattendu %+v", buckets, wantBuckets)
+	}
+}
@@ -2,6 +2,8 @@ package repository
 
 import (
 	"errors"
+	"time"
+
 	"github.com/axellelanca/urlshortener/internal/models"
 	"gorm.io/gorm"
 )
@@ -11,7 +13,34 @@ type LinkRepository interface {
 	CreateLink(link *models.Link) error
 	GetLinkByShortCode(shortCode string) (*models.Link, error)
 	GetAllLinks() ([]models.Link, error)
-	CountClicksByLinkID(linkID uint) (int, error)
+	CountClicksByLinkID(linkID uint, includeBots bool) (int, error)
+	ConsumeClick(linkID uint) (bool, error)
+	IncrementUnlockFailures(linkID uint) error
+	ListLinks(filter LinkFilter) ([]models.Link, int64, error)
+	UpdateLink(link *models.Link) error
+	DeleteLink(linkID uint) error
+	RestoreLink(linkID uint) error
+	SetLinkOwner(linkID uint, ownerID uint) error
+}
+
+// Valeurs possibles pour LinkFilter.Status.
+const (
+	LinkStatusActive  = "active"
+	LinkStatusExpired = "expired"
+	LinkStatusDeleted = "deleted"
+)
+
+// LinkFilter décrit les critères de recherche, de tri et de pagination pour ListLinks.
+// Les champs vides sont ignorés.
+type LinkFilter struct {
+	OwnerID   *uint  // Limite aux liens d'une clé API
+	Search    string // Recherche partielle sur le code court ou l'URL longue
+	Status    string // LinkStatusActive, LinkStatusExpired ou LinkStatusDeleted
+	SortBy    string // Nom de colonne, doit être validé par l'appelant
+	SortDesc  bool
+	Limit     int
+	Offset    int
+	Reference time.Time // Date de référence pour le filtre d'expiration
 }
 
 // GormLinkRepository est l'implémentation de LinkRepository utilisant GORM.
@@ -34,9 +63,11 @@ func (r *GormLinkRepository) CreateLink(link *models.Link) error {
 }
 
 // GetLinkByShortCode récupère un lien de la base de données en utilisant son shortCode.
+// Les liens supprimés logiquement sont aussi retournés (DeletedAt renseigné) : leur code reste
+// réservé et c'est à l'appelant de décider comment les traiter.
 func (r *GormLinkRepository) GetLinkByShortCode(shortCode string) (*models.Link, error) {
 	var link models.Link
-	if err := r.db.Where("short_code = ?", shortCode).First(&link).Error; err != nil {
+	if err := r.db.Unscoped().Where("short_code = ?", shortCode).First(&link).Error; err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
 			return nil, gorm.ErrRecordNotFound
 		}
@@ -56,14 +87,143 @@ func (r *GormLinkRepository) GetAllLinks() ([]models.Link, error) {
 }
 
 // CountClicksByLinkID compte le nombre total de clics pour un ID de lien donné.
-func (r *GormLinkRepository) CountClicksByLinkID(linkID uint) (int, error) {
+// Les clics de robots ne sont comptés que si includeBots est vrai.
+// Les clics bruts déjà agrégés par la politique de rétention sont inclus.
+func (r *GormLinkRepository) CountClicksByLinkID(linkID uint, includeBots bool) (int, error) {
 	var count int64
-	if err := r.db.Model(&models.Click{}).Where("link_id = ?", linkID).Count(&count).Error; err != nil {
+	query := r.db.Model(&models.Click{}).Where("link_id = ?", linkID)
+	if !includeBots {
+		query = query.Where("is_bot = ?", false)
+	}
+	if err := query.Count(&count).Error; err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
 			return 0, gorm.ErrRecordNotFound
 		}
 		return 0, err
 	}
 
-	return int(count), nil
+	var aggregated int64
+	aggregates := r.db.Model(&models.ClickDailyAggregate{}).Where("link_id = ?", linkID)
+	if !includeBots {
+		aggregates = aggregates.Where("is_bot = ?", false)
+	}
+	if err := aggregates.Select("COALESCE(SUM(clicks), 0)").Scan(&aggregated).Error; err != nil {
+		return 0, err
+	}
+
+	return int(count + aggregated), nil
+}
+
+// ConsumeClick décrémente de façon atomique le quota de redirections d'un lien limité.
+// La mise à jour est conditionnelle : elle retourne false si le quota MaxClicks est déjà atteint,
+// ce qui évite de dépasser la limite lorsque plusieurs redirections arrivent en même temps.
+func (r *GormLinkRepository) ConsumeClick(linkID uint) (bool, error) {
+	result := r.db.Model(&models.Link{}).
+		Where("id = ? AND (max_clicks = 0 OR consumed_clicks < max_clicks)", linkID).
+		UpdateColumn("consumed_clicks", gorm.Expr("consumed_clicks + 1"))
+	if result.Error != nil {
+		return false, result.Error
+	}
+	return result.RowsAffected > 0, nil
+}
+
+// IncrementUnlockFailures incrémente de façon atomique le compteur des mots de passe erronés d'un lien.
+func (r *GormLinkRepository) IncrementUnlockFailures(linkID uint) error {
+	return r.db.Model(&models.Link{}).
+		Where("id = ?", linkID).
+		UpdateColumn("unlock_failures", gorm.Expr("unlock_failures + 1")).Error
+}
+
+// ListLinks retourne une page de liens correspondant au filtre, ainsi que le nombre total de résultats.
+func (r *GormLinkRepository) ListLinks(filter LinkFilter) ([]models.Link, int64, error) {
+	query := r.db.Model(&models.Link{})
+
+	if filter.OwnerID != nil {
+		query = query.Where("owner_id = ?", *filter.OwnerID)
+	}
+	if filter.Search != "" {
+		pattern := "%" + filter.Search + "%"
+		query = query.Where("short_code LIKE ? OR long_url LIKE ?", pattern, pattern)
+	}
+
+	// Un lien est expiré s'il a dépassé sa date d'expiration ou épuisé son quota de clics
+	expiredCondition := "(expires_at IS NOT NULL AND expires_at <= ?) OR (max_clicks > 0 AND consumed_clicks >= max_clicks)"
+	switch filter.Status {
+	case LinkStatusExpired:
+		query = query.Where(expiredCondition, filter.Reference)
+	case LinkStatusActive:
+		query = query.Not(expiredCondition, filter.Reference)
+	case LinkStatusDeleted:
+		query = query.Unscoped().Where("deleted_at IS NOT NULL")
+	}
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	if filter.SortBy != "" {
+		order := filter.SortBy
+		if filter.SortDesc {
+			order += " DESC"
+		}
+		query = query.Order(order)
+	}
+
+	var links []models.Link
+	if err := query.Order("id").Limit(filter.Limit).Offset(filter.Offset).Find(&links).Error; err != nil {
+		return nil, 0, err
+	}
+	return links, total, nil
+}
+
+// UpdateLink enregistre les modifications d'un lien existant.
+// Le compteur unlock_failures n'est modifié que par IncrementUnlockFailures : la valeur lue
+// avant la modification pourrait être dépassée.
+func (r *GormLinkRepository) UpdateLink(link *models.Link) error {
+	if err := r.db.Omit("unlock_failures").Save(link).Error; err != nil {
+		return err
+	}
+	return nil
+}
+
+// DeleteLink supprime logiquement un lien en renseignant DeletedAt.
+// La ligne et l'historique de ses clics sont conservés pour permettre une restauration.
+func (r *GormLinkRepository) DeleteLink(linkID uint) error {
+	result := r.db.Delete(&models.Link{}, linkID)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+	return nil
+}
+
+// RestoreLink annule la suppression logique d'un lien.
+func (r *GormLinkRepository) RestoreLink(linkID uint) error {
+	result := r.db.Unscoped().Model(&models.Link{}).
+		Where("id = ? AND deleted_at IS NOT NULL", linkID).
+		Update("deleted_at", nil)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+	return nil
+}
+
+// SetLinkOwner attribue un lien, supprimé ou non, à la clé API ownerID.
+func (r *GormLinkRepository) SetLinkOwner(linkID uint, ownerID uint) error {
+	result := r.db.Unscoped().Model(&models.Link{}).
+		Where("id = ?", linkID).
+		Update("owner_id", ownerID)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+	return nil
 }
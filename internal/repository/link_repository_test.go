@@ -0,0 +1,102 @@
+package repository
+
+import (
+	"testing"
+	"time"
+
+	"github.com/axellelanca/urlshortener/internal/models"
+	"gorm.io/gorm"
+)
+
+// listedCodes retourne les codes courts d'une page de ListLinks, dans l'ordre.
+func listedCodes(t *testing.T, repo *GormLinkRepository, filter LinkFilter) ([]string, int64) {
+	t.Helper()
+	links, total, err := repo.ListLinks(filter)
+	if err != nil {
+		t.Fatalf("ListLinks(%+v): %v", filter, err)
+	}
+	codes := make([]string, 0, len(links))
+	for _, link := range links {
+		codes = append(codes, link.ShortCode)
+	}
+	return codes, total
+}
+
+func TestListLinks(t *testing.T) {
+	for _, backend := range testDatabases {
+		t.Run(backend.name, func(t *testing.T) {
+			testListLinks(t, backend.open(t))
+		})
+	}
+}
+
+func testListLinks(t *testing.T, db *gorm.DB) {
+	repo := NewLinkRepository(db)
+	now := time.Now().UTC().Truncate(time.Second)
+	past, future := now.Add(-time.Hour), now.Add(time.Hour)
+	alice, bob := uint(1), uint(2)
+
+	links := []*models.Link{
+		{ShortCode: "a-active", LongURL: "https://example.com/1", OwnerID: &alice},
+		{ShortCode: "a-future", LongURL: "https://example.com/2", OwnerID: &alice, ExpiresAt: &future},
+		{ShortCode: "a-past", LongURL: "https://example.com/3", OwnerID: &alice, ExpiresAt: &past},
+		{ShortCode: "a-quota", LongURL: "https://example.com/4", OwnerID: &alice, MaxClicks: 2, ConsumedClicks: 2},
+		{ShortCode: "a-quota-left", LongURL: "https://example.com/5", OwnerID: &alice, MaxClicks: 2, ConsumedClicks: 1},
+		{ShortCode: "b-active", LongURL: "https://example.org/1", OwnerID: &bob},
+		{ShortCode: "b-past", LongURL: "https://example.org/2", OwnerID: &bob, ExpiresAt: &past},
+		{ShortCode: "cli", LongURL: "https://example.net/"},
+		{ShortCode: "a-deleted", LongURL: "https://example.com/6", OwnerID: &alice},
+	}
+	for _, link := range links {
+		if err := repo.CreateLink(link); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := repo.DeleteLink(links[len(links)-1].ID); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name   string
+		filter LinkFilter
+		want   []string
+		total  int64
+	}{
+		{"tous", LinkFilter{Limit: 20}, []string{"a-active", "a-future", "a-past", "a-quota", "a-quota-left", "b-active", "b-past", "cli"}, 8},
+		{"propriétaire", LinkFilter{OwnerID: &alice, Limit: 20}, []string{"a-active", "a-future", "a-past", "a-quota", "a-quota-left"}, 5},
+		{"actifs", LinkFilter{Status: LinkStatusActive, Limit: 20}, []string{"a-active", "a-future", "a-quota-left", "b-active", "cli"}, 5},
+		{"actifs du propriétaire", LinkFilter{OwnerID: &alice, Status: LinkStatusActive, Limit: 20}, []string{"a-active", "a-future", "a-quota-left"}, 3},
+		{"expirés", LinkFilter{Status: LinkStatusExpired, Limit: 20}, []string{"a-past", "a-quota", "b-past"}, 3},
+		// Sans parenthèses autour de la condition d'expiration, le OR échapperait au filtre du propriétaire
+		{"expirés du propriétaire", LinkFilter{OwnerID: &bob, Status: LinkStatusExpired, Limit: 20}, []string{"b-past"}, 1},
+		{"supprimés", LinkFilter{Status: LinkStatusDeleted, Limit: 20}, []string{"a-deleted"}, 1},
+		{"supprimés d'un autre propriétaire", LinkFilter{OwnerID: &bob, Status: LinkStatusDeleted, Limit: 20}, []string{}, 0},
+		{"recherche", LinkFilter{Search: "example.org", Limit: 20}, []string{"b-active", "b-past"}, 2},
+		{"recherche d'un autre propriétaire", LinkFilter{OwnerID: &alice, Search: "example.org", Limit: 20}, []string{}, 0},
+		{"recherche expirés", LinkFilter{Search: "quota", Status: LinkStatusExpired, Limit: 20}, []string{"a-quota"}, 1},
+		{"première page", LinkFilter{Limit: 3}, []string{"a-active", "a-future", "a-past"}, 8},
+		{"dernière page incomplète", LinkFilter{Limit: 3, Offset: 6}, []string{"b-past", "cli"}, 8},
+		{"au-delà de la dernière page", LinkFilter{Limit: 3, Offset: 9}, []string{}, 8},
+		{"tri décroissant", LinkFilter{OwnerID: &bob, SortBy: "short_code", SortDesc: true, Limit: 20}, []string{"b-past", "b-active"}, 2},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.filter.Reference = now
+			codes, total := listedCodes(t, repo, tt.filter)
+			if total != tt.total || len(codes) != len(tt.want) {
+				t.Fatalf("codes %v (total %d), attendu %v (total %d)", codes, total, tt.want, tt.total)
+			}
+			for i := range codes {
+				if codes[i] != tt.want[i] {
+					t.Fatalf("codes %v, attendu %v", codes, tt.want)
+				}
+			}
+		})
+	}
+
+	// Un lien qui expire exactement à la date de référence est expiré
+	codes, _ := listedCodes(t, repo, LinkFilter{Status: LinkStatusExpired, Reference: future, Limit: 20})
+	if len(codes) != 4 || codes[0] != "a-future" {
+		t.Fatalf("expirés à l'échéance de a-future : %v, attendu a-future en plus", codes)
+	}
+}
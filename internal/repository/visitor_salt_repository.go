@@ -0,0 +1,45 @@
+package repository
+
+import (
+	"github.com/axellelanca/urlshortener/internal/models"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// VisitorSaltRepository est une interface qui définit les méthodes d'accès aux sels journaliers
+// des empreintes de visiteurs.
+type VisitorSaltRepository interface {
+	GetOrCreateSalt(day string, candidate string) (string, error)
+	DeleteSaltsBefore(day string) error
+}
+
+// GormVisitorSaltRepository est l'implémentation de l'interface VisitorSaltRepository utilisant GORM.
+type GormVisitorSaltRepository struct {
+	db *gorm.DB
+}
+
+// NewVisitorSaltRepository crée et retourne une nouvelle instance de GormVisitorSaltRepository.
+func NewVisitorSaltRepository(db *gorm.DB) *GormVisitorSaltRepository {
+	return &GormVisitorSaltRepository{db: db}
+}
+
+// GetOrCreateSalt retourne le sel du jour donné, en enregistrant candidate s'il n'existe pas encore.
+// L'insertion ignore les conflits : si plusieurs workers ou instances créent le sel en même temps,
+// tous relisent la même valeur.
+func (r *GormVisitorSaltRepository) GetOrCreateSalt(day string, candidate string) (string, error) {
+	salt := models.VisitorSalt{Day: day, Salt: candidate}
+	if err := r.db.Clauses(clause.OnConflict{DoNothing: true}).Create(&salt).Error; err != nil {
+		return "", err
+	}
+
+	var stored models.VisitorSalt
+	if err := r.db.Where("day = ?", day).First(&stored).Error; err != nil {
+		return "", err
+	}
+	return stored.Salt, nil
+}
+
+// DeleteSaltsBefore supprime les sels des jours antérieurs au jour donné.
+func (r *GormVisitorSaltRepository) DeleteSaltsBefore(day string) error {
+	return r.db.Where("day < ?", day).Delete(&models.VisitorSalt{}).Error
+}
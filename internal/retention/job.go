@@ -0,0 +1,139 @@
+package retention
+
+import (
+	"fmt"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/axellelanca/urlshortener/internal/models"
+	"github.com/axellelanca/urlshortener/internal/repository"
+)
+
+// Modes de traitement des clics bruts arrivés au terme de leur durée de conservation.
+const (
+	ModePurge     = "purge"     // Suppression pure et simple
+	ModeAggregate = "aggregate" // Remplacement par des totaux journaliers (clics et visiteurs uniques)
+)
+
+// Job applique la politique de rétention des clics bruts (adresse IP, User-Agent, empreinte...).
+type Job struct {
+	clickRepo repository.ClickRepository
+	days      int           // Durée de conservation en jours ; 0 désactive la rétention
+	mode      string        // purge ou aggregate
+	interval  time.Duration // Intervalle entre deux passages de la tâche de fond
+	stop      chan struct{} // Fermé par Stop pour interrompre la tâche de fond
+	done      chan struct{} // Fermé par Start lorsque la tâche de fond est terminée
+	stopOnce  sync.Once
+}
+
+// NewJob crée et retourne un nouveau Job. Un mode vide vaut aggregate et un intervalle nul une heure.
+func NewJob(clickRepo repository.ClickRepository, days int, mode string, interval time.Duration) (*Job, error) {
+	switch mode {
+	case "":
+		mode = ModeAggregate
+	case ModePurge, ModeAggregate:
+	default:
+		return nil, fmt.Errorf("[Retention] mode '%s' inconnu (purge ou aggregate)", mode)
+	}
+	if days < 0 {
+		return nil, fmt.Errorf("[Retention] durée de conservation invalide: %d jours", days)
+	}
+	if interval <= 0 {
+		interval = time.Hour
+	}
+	return &Job{
+		clickRepo: clickRepo,
+		days:      days,
+		mode:      mode,
+		interval:  interval,
+		stop:      make(chan struct{}),
+		done:      make(chan struct{}),
+	}, nil
+}
+
+// Enabled indique si une durée de conservation est configurée.
+func (j *Job) Enabled() bool {
+	return j.days > 0
+}
+
+// Mode retourne le mode de traitement appliqué aux clics expirés.
+func (j *Job) Mode() string {
+	return j.mode
+}
+
+// Cutoff retourne la date avant laquelle les clics bruts sont expirés : minuit UTC, days jours avant now.
+// L'alignement sur minuit garantit qu'un jour est toujours agrégé en une seule fois.
+func (j *Job) Cutoff(now time.Time) time.Time {
+	return models.TruncateToInterval(now.AddDate(0, 0, -j.days), models.IntervalDay)
+}
+
+// CountExpired compte les clics bruts qui seraient traités par Run à l'instant now.
+func (j *Job) CountExpired(now time.Time) (int64, error) {
+	count, err := j.clickRepo.CountClicksBefore(j.Cutoff(now))
+	if err != nil {
+		return 0, fmt.Errorf("[Retention] Erreur lors du comptage des clics expirés: %w", err)
+	}
+	return count, nil
+}
+
+// Run purge ou agrège les clics bruts expirés à l'instant now et retourne le nombre de clics supprimés.
+func (j *Job) Run(now time.Time) (int64, error) {
+	if !j.Enabled() {
+		return 0, nil
+	}
+
+	cutoff := j.Cutoff(now)
+	var removed int64
+	var err error
+	if j.mode == ModePurge {
+		removed, err = j.clickRepo.DeleteClicksBefore(cutoff)
+	} else {
+		removed, err = j.clickRepo.AggregateClicksBefore(cutoff)
+	}
+	if err != nil {
+		return 0, fmt.Errorf("[Retention] Erreur lors du traitement des clics antérieurs au %s (%s): %w",
+			cutoff.Format(time.DateOnly), j.mode, err)
+	}
+	return removed, nil
+}
+
+// Start lance la boucle de rétention périodique.
+// Cette fonction est conçue pour être lancée dans une goroutine séparée.
+func (j *Job) Start() {
+	log.Printf("[RETENTION] Démarrage de la rétention des clics (%d jours, mode %s, intervalle %v)...", j.days, j.mode, j.interval)
+	ticker := time.NewTicker(j.interval)
+	defer ticker.Stop()
+	defer close(j.done)
+
+	// Exécute un premier passage immédiatement au démarrage
+	j.runAndLog()
+
+	for {
+		select {
+		case <-ticker.C:
+			j.runAndLog()
+		case <-j.stop:
+			return
+		}
+	}
+}
+
+// Stop interrompt la tâche de fond et attend la fin du passage en cours.
+// Stop ne doit être appelé qu'après Start.
+func (j *Job) Stop() {
+	j.stopOnce.Do(func() { close(j.stop) })
+	<-j.done
+}
+
+// runAndLog exécute un passage de rétention et journalise son résultat.
+func (j *Job) runAndLog() {
+	removed, err := j.Run(time.Now())
+	if err != nil {
+		log.Printf("[RETENTION] ERREUR : %v", err)
+		return
+	}
+	if removed > 0 {
+		log.Printf("[RETENTION] %d clic(s) antérieur(s) au %s traité(s) (%s).", removed, j.Cutoff(time.Now()).Format(time.DateOnly), j.mode)
+	}
+}
@@ -0,0 +1,143 @@
+package retention
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/axellelanca/urlshortener/internal/config"
+	"github.com/axellelanca/urlshortener/internal/database"
+	"github.com/axellelanca/urlshortener/internal/migrations"
+	"github.com/axellelanca/urlshortener/internal/models"
+	"github.com/axellelanca/urlshortener/internal/repository"
+	"gorm.io/gorm"
+)
+
+// now est l'instant de référence des tests : la date limite d'une rétention de 30 jours est le 17 septembre.
+var now = time.Date(2026, time.October, 17, 15, 0, 0, 0, time.UTC)
+
+// newTestClicks ouvre une base SQLite migrée contenant deux clics antérieurs à la date limite
+// (dont un robot) et un clic postérieur, et retourne la base et son ClickRepository.
+func newTestClicks(t *testing.T) (*gorm.DB, repository.ClickRepository) {
+	t.Helper()
+	db, err := database.Open(config.DatabaseConfig{Name: filepath.Join(t.TempDir(), "test.db")})
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		if sqlDB, err := db.DB(); err == nil {
+			sqlDB.Close()
+		}
+	})
+	if _, err := migrations.NewMigrator(db).Up(); err != nil {
+		t.Fatal(err)
+	}
+
+	link := &models.Link{ShortCode: "retention", LongURL: "https://example.com"}
+	if err := db.Create(link).Error; err != nil {
+		t.Fatal(err)
+	}
+	repo := repository.NewClickRepository(db)
+	for _, click := range []models.Click{
+		{LinkID: link.ID, Timestamp: time.Date(2026, time.September, 1, 10, 0, 0, 0, time.UTC), VisitorID: "a", IPAddress: "203.0.113.0"},
+		{LinkID: link.ID, Timestamp: time.Date(2026, time.September, 16, 23, 59, 0, 0, time.UTC), VisitorID: "b", IsBot: true},
+		{LinkID: link.ID, Timestamp: time.Date(2026, time.September, 17, 0, 0, 0, 0, time.UTC), VisitorID: "c"},
+	} {
+		if err := repo.CreateClick(&click); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return db, repo
+}
+
+// countRows compte les lignes de la table du modèle model.
+func countRows(t *testing.T, db *gorm.DB, model any) int64 {
+	t.Helper()
+	var count int64
+	if err := db.Model(model).Count(&count).Error; err != nil {
+		t.Fatal(err)
+	}
+	return count
+}
+
+func TestNewJob(t *testing.T) {
+	job, err := NewJob(nil, 30, "", 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if job.Mode() != ModeAggregate || !job.Enabled() {
+		t.Fatalf("mode %q, activé %v : attendu aggregate et activé", job.Mode(), job.Enabled())
+	}
+	if want := time.Date(2026, time.September, 17, 0, 0, 0, 0, time.UTC); !job.Cutoff(now).Equal(want) {
+		t.Fatalf("Cutoff = %v, attendu %v", job.Cutoff(now), want)
+	}
+
+	if _, err := NewJob(nil, 30, "archive", 0); err == nil {
+		t.Fatal("NewJob devrait refuser un mode inconnu")
+	}
+	if _, err := NewJob(nil, -1, ModePurge, 0); err == nil {
+		t.Fatal("NewJob devrait refuser une durée négative")
+	}
+}
+
+func TestRunDisabledKeepsClicks(t *testing.T) {
+	db, repo := newTestClicks(t)
+	job, err := NewJob(repo, 0, ModePurge, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if removed, err := job.Run(now); err != nil || removed != 0 {
+		t.Fatalf("Run = %d, %v ; attendu 0 sans durée de conservation", removed, err)
+	}
+	if got := countRows(t, db, &models.Click{}); got != 3 {
+		t.Fatalf("%d clic(s) en base, attendu 3", got)
+	}
+}
+
+func TestRunPurge(t *testing.T) {
+	db, repo := newTestClicks(t)
+	job, err := NewJob(repo, 30, ModePurge, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if expired, err := job.CountExpired(now); err != nil || expired != 2 {
+		t.Fatalf("CountExpired = %d, %v ; attendu 2", expired, err)
+	}
+
+	removed, err := job.Run(now)
+	if err != nil || removed != 2 {
+		t.Fatalf("Run = %d, %v ; attendu 2", removed, err)
+	}
+	if got := countRows(t, db, &models.Click{}); got != 1 {
+		t.Fatalf("%d clic(s) en base, attendu 1", got)
+	}
+	if got := countRows(t, db, &models.ClickDailyAggregate{}); got != 0 {
+		t.Fatalf("%d agrégat(s) après une purge, attendu 0", got)
+	}
+}
+
+func TestRunAggregate(t *testing.T) {
+	db, repo := newTestClicks(t)
+	job, err := NewJob(repo, 30, ModeAggregate, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	removed, err := job.Run(now)
+	if err != nil || removed != 2 {
+		t.Fatalf("Run = %d, %v ; attendu 2", removed, err)
+	}
+	if got := countRows(t, db, &models.Click{}); got != 1 {
+		t.Fatalf("%d clic(s) en base, attendu 1", got)
+	}
+	var aggregates []models.ClickDailyAggregate
+	if err := db.Order("day").Find(&aggregates).Error; err != nil {
+		t.Fatal(err)
+	}
+	if len(aggregates) != 2 || aggregates[0].Clicks != 1 || aggregates[0].IsBot || !aggregates[1].IsBot {
+		t.Fatalf("agrégats = %+v, attendu un jour visiteur et un jour robot", aggregates)
+	}
+	if expired, err := job.CountExpired(now); err != nil || expired != 0 {
+		t.Fatalf("CountExpired après Run = %d, %v ; attendu 0", expired, err)
+	}
+}
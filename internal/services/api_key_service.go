@@ -0,0 +1,153 @@
+package services
+
+import (
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+
+	"github.com/axellelanca/urlshortener/internal/models"
+	"github.com/axellelanca/urlshortener/internal/repository"
+)
+
+// Format des clés API : "usk_<prefix>_<secret>".
+// Le préfixe est stocké en clair pour retrouver la clé, seul le hash du secret est conservé.
+const (
+	apiKeyScheme       = "usk"
+	apiKeyPrefixLength = 8
+	apiKeySecretLength = 32
+
+	// apiKeyTouchInterval limite la fréquence d'écriture de LastUsedAt pour ne pas
+	// ajouter une requête d'écriture à chaque appel authentifié.
+	apiKeyTouchInterval = time.Minute
+)
+
+// Erreurs personnalisées exposées par l'APIKeyService.
+var (
+	ErrInvalidAPIKey = errors.New("clé API invalide")
+	ErrAPIKeyRevoked = errors.New("clé API révoquée")
+)
+
+// APIKeyService fournit la logique métier de gestion et de vérification des clés API.
+type APIKeyService struct {
+	apiKeyRepo repository.APIKeyRepository
+}
+
+// NewAPIKeyService crée et retourne une nouvelle instance de APIKeyService.
+func NewAPIKeyService(apiKeyRepo repository.APIKeyRepository) *APIKeyService {
+	return &APIKeyService{
+		apiKeyRepo: apiKeyRepo,
+	}
+}
+
+// CreateAPIKey génère une nouvelle clé API et retourne la clé complète en clair.
+// Celle-ci n'est jamais stockée : elle doit être communiquée immédiatement à son utilisateur.
+func (s *APIKeyService) CreateAPIKey(name string) (*models.APIKey, string, error) {
+	name = strings.TrimSpace(name)
+	if name == "" {
+		return nil, "", errors.New("[Service::CreateAPIKey] le nom de la clé est obligatoire")
+	}
+
+	prefix, err := GenerateShortCode(apiKeyPrefixLength)
+	if err != nil {
+		return nil, "", fmt.Errorf("[Service::CreateAPIKey] Erreur lors de la génération du préfixe: %w", err)
+	}
+	secret, err := GenerateShortCode(apiKeySecretLength)
+	if err != nil {
+		return nil, "", fmt.Errorf("[Service::CreateAPIKey] Erreur lors de la génération du secret: %w", err)
+	}
+
+	key := &models.APIKey{
+		Name:       name,
+		Prefix:     prefix,
+		SecretHash: hashAPIKeySecret(secret),
+		CreatedAt:  time.Now(),
+	}
+	if err := s.apiKeyRepo.CreateAPIKey(key); err != nil {
+		return nil, "", fmt.Errorf("[Service::CreateAPIKey] Erreur lors de l'enregistrement de la clé: %w", err)
+	}
+
+	return key, fmt.Sprintf("%s_%s_%s", apiKeyScheme, prefix, secret), nil
+}
+
+// ListAPIKeys retourne toutes les clés API, révoquées comprises.
+func (s *APIKeyService) ListAPIKeys() ([]models.APIKey, error) {
+	keys, err := s.apiKeyRepo.ListAPIKeys()
+	if err != nil {
+		return nil, fmt.Errorf("[Service::ListAPIKeys] Erreur lors de la récupération des clés: %w", err)
+	}
+	return keys, nil
+}
+
+// RevokeAPIKey révoque une clé API : elle ne permet plus de s'authentifier.
+func (s *APIKeyService) RevokeAPIKey(id uint) error {
+	if err := s.apiKeyRepo.RevokeAPIKey(id, time.Now()); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return fmt.Errorf("[Service::RevokeAPIKey] Aucune clé active avec l'ID %d: %w", id, err)
+		}
+		return fmt.Errorf("[Service::RevokeAPIKey] Erreur lors de la révocation de la clé: %w", err)
+	}
+	return nil
+}
+
+// GetActiveAPIKey retourne la clé API d'ID id, si elle existe et n'est pas révoquée.
+// Elle permet à la CLI d'attribuer des liens à une clé.
+func (s *APIKeyService) GetActiveAPIKey(id uint) (*models.APIKey, error) {
+	key, err := s.apiKeyRepo.GetAPIKeyByID(id)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, fmt.Errorf("[Service::GetActiveAPIKey] Aucune clé avec l'ID %d: %w", id, err)
+		}
+		return nil, fmt.Errorf("[Service::GetActiveAPIKey] Erreur lors de la récupération de la clé: %w", err)
+	}
+	if key.IsRevoked() {
+		return nil, fmt.Errorf("[Service::GetActiveAPIKey] %w: '%s'", ErrAPIKeyRevoked, key.Name)
+	}
+	return key, nil
+}
+
+// Authenticate vérifie une clé API présentée par un client et retourne la clé correspondante.
+func (s *APIKeyService) Authenticate(rawKey string) (*models.APIKey, error) {
+	parts := strings.Split(rawKey, "_")
+	if len(parts) != 3 || parts[0] != apiKeyScheme || len(parts[1]) != apiKeyPrefixLength || parts[2] == "" {
+		return nil, fmt.Errorf("[Service::Authenticate] %w: format inattendu", ErrInvalidAPIKey)
+	}
+
+	key, err := s.apiKeyRepo.GetAPIKeyByPrefix(parts[1])
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, fmt.Errorf("[Service::Authenticate] %w", ErrInvalidAPIKey)
+		}
+		return nil, fmt.Errorf("[Service::Authenticate] Erreur lors de la récupération de la clé: %w", err)
+	}
+
+	// Comparaison en temps constant pour ne pas révéler d'information sur le hash attendu
+	if subtle.ConstantTimeCompare([]byte(hashAPIKeySecret(parts[2])), []byte(key.SecretHash)) != 1 {
+		return nil, fmt.Errorf("[Service::Authenticate] %w", ErrInvalidAPIKey)
+	}
+	if key.IsRevoked() {
+		return nil, fmt.Errorf("[Service::Authenticate] %w: '%s'", ErrAPIKeyRevoked, key.Name)
+	}
+
+	now := time.Now()
+	if key.LastUsedAt == nil || now.Sub(*key.LastUsedAt) > apiKeyTouchInterval {
+		if err := s.apiKeyRepo.TouchAPIKey(key.ID, now); err != nil {
+			log.Printf("[Service::Authenticate] Impossible de mettre à jour la dernière utilisation de la clé %d : %v", key.ID, err)
+		}
+		key.LastUsedAt = &now
+	}
+	return key, nil
+}
+
+// hashAPIKeySecret calcule le hash SHA-256 d'un secret de clé API.
+// Les secrets étant longs et aléatoires, un hash rapide sans sel suffit à les protéger.
+func hashAPIKeySecret(secret string) string {
+	sum := sha256.Sum256([]byte(secret))
+	return hex.EncodeToString(sum[:])
+}
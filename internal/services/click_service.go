@@ -1,12 +1,62 @@
 package services
 
 import (
+	"errors"
 	"fmt"
+	"sort"
+	"time"
 
 	"github.com/axellelanca/urlshortener/internal/models"
+	"github.com/axellelanca/urlshortener/internal/referrer"
 	"github.com/axellelanca/urlshortener/internal/repository" // Importe le package repository
+	"github.com/axellelanca/urlshortener/internal/useragent"
 )
 
+// maxTimeSeriesBuckets limite le nombre de tranches d'une série temporelle pour éviter
+// qu'une requête sur une très longue période à l'heure ne génère une réponse démesurée.
+const maxTimeSeriesBuckets = 1000
+
+// Nombre de sources retournées par défaut et au maximum par le classement des référents.
+const (
+	DefaultTopReferrers = 10
+	MaxTopReferrers     = 100
+)
+
+// Erreurs personnalisées exposées par le ClickService.
+var (
+	ErrInvalidTimeRange = errors.New("période ou granularité invalide")
+	ErrInvalidDimension = errors.New("dimension inconnue")
+)
+
+// Dimensions de répartition des clics issues de l'analyse du User-Agent et de la géolocalisation.
+const (
+	DimensionBrowser = "browser"
+	DimensionOS      = "os"
+	DimensionDevice  = "device"
+	DimensionCountry = "country"
+	DimensionRegion  = "region"
+	DimensionCity    = "city"
+)
+
+// breakdownColumns associe chaque dimension exposée à sa colonne dans la table clicks.
+var breakdownColumns = map[string]string{
+	DimensionBrowser: "browser",
+	DimensionOS:      "os",
+	DimensionDevice:  "device_type",
+	DimensionCountry: "country",
+	DimensionRegion:  "region",
+	DimensionCity:    "city",
+}
+
+// defaultTimeSeriesWindow donne la période analysée par défaut pour chaque granularité
+// lorsque le début n'est pas précisé.
+var defaultTimeSeriesWindow = map[string]func(to time.Time) time.Time{
+	models.IntervalHour:  func(to time.Time) time.Time { return to.Add(-48 * time.Hour) },
+	models.IntervalDay:   func(to time.Time) time.Time { return to.AddDate(0, 0, -30) },
+	models.IntervalWeek:  func(to time.Time) time.Time { return to.AddDate(0, 0, -7*12) },
+	models.IntervalMonth: func(to time.Time) time.Time { return to.AddDate(-1, 0, 0) },
+}
+
 // DONE : créer la struct
 // ClickService est une structure qui fournit des méthodes pour la logique métier des clics.
 // Elle est juste composer de clickRepo qui est de type ClickRepository
@@ -33,10 +83,144 @@ func (s *ClickService) RecordClick(click *models.Click) error {
 
 // GetClicksCountByLinkID récupère le nombre total de clics pour un LinkID donné.
 // Cette méthode pourrait être utilisée par le LinkService pour les statistiques, ou directement par l'API stats.
-func (s *ClickService) GetClicksCountByLinkID(linkID uint) (int, error) {
-	count, err := s.clickRepo.CountClicksByLinkID(linkID)
+func (s *ClickService) GetClicksCountByLinkID(linkID uint, includeBots bool) (int, error) {
+	count, err := s.clickRepo.CountClicksByLinkID(linkID, includeBots)
 	if err != nil {
 		return 0, fmt.Errorf("GetClicksCountByLinkID: %w", err)
 	}
 	return count, err
 }
+
+// GetUniqueVisitorsByLinkID retourne le nombre de visiteurs uniques d'un lien (cumul des visiteurs uniques par jour).
+func (s *ClickService) GetUniqueVisitorsByLinkID(linkID uint, includeBots bool) (int, error) {
+	count, err := s.clickRepo.CountUniqueVisitorsByLinkID(linkID, includeBots)
+	if err != nil {
+		return 0, fmt.Errorf("[Service::GetUniqueVisitorsByLinkID] %w", err)
+	}
+	return count, nil
+}
+
+// GetClickTimeSeries retourne l'évolution des clics d'un lien sur [from, to[ par tranche de temps.
+// Les tranches sans clic sont incluses avec un compteur à zéro pour obtenir une série continue.
+// Si from ou to sont nuls, ils valent respectivement une période par défaut avant to et maintenant.
+// Les clics de robots ne sont comptés que si includeBots est vrai.
+func (s *ClickService) GetClickTimeSeries(linkID uint, from, to time.Time, interval string, includeBots bool) ([]models.ClickBucket, error) {
+	if interval == "" {
+		interval = models.IntervalDay
+	}
+	if !models.IsValidInterval(interval) {
+		return nil, fmt.Errorf("[Service::GetClickTimeSeries] %w: granularité '%s' inconnue (hour, day, week ou month)", ErrInvalidTimeRange, interval)
+	}
+	if to.IsZero() {
+		to = time.Now()
+	}
+	if from.IsZero() {
+		from = defaultTimeSeriesWindow[interval](to)
+	}
+	if !from.Before(to) {
+		return nil, fmt.Errorf("[Service::GetClickTimeSeries] %w: le début doit précéder la fin", ErrInvalidTimeRange)
+	}
+
+	// Construction de la série complète, tranche par tranche
+	var series []models.ClickBucket
+	for start := models.TruncateToInterval(from, interval); start.Before(to); start = models.NextInterval(start, interval) {
+		if len(series) >= maxTimeSeriesBuckets {
+			return nil, fmt.Errorf("[Service::GetClickTimeSeries] %w: plus de %d tranches, réduisez la période ou augmentez la granularité", ErrInvalidTimeRange, maxTimeSeriesBuckets)
+		}
+		series = append(series, models.ClickBucket{Start: start})
+	}
+
+	buckets, err := s.clickRepo.CountClicksByInterval(linkID, from, to, interval, includeBots)
+	if err != nil {
+		return nil, fmt.Errorf("[Service::GetClickTimeSeries] Erreur lors de l'agrégation des clics: %w", err)
+	}
+
+	// Report des compteurs dans la série ; les deux listes sont triées chronologiquement
+	i := 0
+	for _, bucket := range buckets {
+		for i < len(series) && series[i].Start.Before(bucket.Start) {
+			i++
+		}
+		if i < len(series) && series[i].Start.Equal(bucket.Start) {
+			series[i].Clicks = bucket.Clicks
+			series[i].UniqueVisitors = bucket.UniqueVisitors
+		}
+	}
+	return series, nil
+}
+
+// ParseTimeParam interprète une date fournie par l'utilisateur, au format RFC 3339
+// (ex: "2025-06-01T12:00:00Z") ou sous la forme d'un jour (ex: "2025-06-01", minuit UTC).
+// Une chaîne vide retourne la date zéro.
+func ParseTimeParam(value string) (time.Time, error) {
+	if value == "" {
+		return time.Time{}, nil
+	}
+	if t, err := time.Parse(time.RFC3339, value); err == nil {
+		return t, nil
+	}
+	t, err := time.Parse(time.DateOnly, value)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("%w: date '%s' invalide (RFC 3339 ou AAAA-MM-JJ attendu)", ErrInvalidTimeRange, value)
+	}
+	return t, nil
+}
+
+// GetClickBreakdown retourne la répartition des clics d'un lien selon une dimension
+// (navigateur, système d'exploitation, type d'appareil, pays, région ou ville), de la valeur la plus fréquente
+// à la moins fréquente. Les clics sans valeur pour la dimension (enregistrés avant l'enrichissement,
+// ou non géolocalisés) apparaissent sous la valeur "unknown".
+// Les clics de robots ne sont comptés que si includeBots est vrai.
+func (s *ClickService) GetClickBreakdown(linkID uint, dimension string, includeBots bool) ([]models.DimensionCount, error) {
+	column, ok := breakdownColumns[dimension]
+	if !ok {
+		return nil, fmt.Errorf("[Service::GetClickBreakdown] %w: '%s' (browser, os, device, country, region ou city)", ErrInvalidDimension, dimension)
+	}
+
+	counts, err := s.clickRepo.CountClicksGroupedBy(linkID, column, 0, includeBots)
+	if err != nil {
+		return nil, fmt.Errorf("[Service::GetClickBreakdown] Erreur lors du regroupement des clics: %w", err)
+	}
+	return mergeUnknown(counts), nil
+}
+
+// GetTopReferrers retourne les domaines de provenance qui ont amené le plus de clics sur un lien,
+// du plus fréquent au moins fréquent. Les clics sans référent sont regroupés sous la valeur "direct".
+// Une limite <= 0 applique DefaultTopReferrers ; elle est plafonnée à MaxTopReferrers.
+func (s *ClickService) GetTopReferrers(linkID uint, limit int, includeBots bool) ([]models.DimensionCount, error) {
+	if limit <= 0 {
+		limit = DefaultTopReferrers
+	}
+	limit = min(limit, MaxTopReferrers)
+
+	counts, err := s.clickRepo.CountClicksGroupedBy(linkID, "referrer", limit, includeBots)
+	if err != nil {
+		return nil, fmt.Errorf("[Service::GetTopReferrers] Erreur lors du regroupement des clics: %w", err)
+	}
+	for i := range counts {
+		if counts[i].Value == "" {
+			counts[i].Value = referrer.Direct
+		}
+	}
+	return counts, nil
+}
+
+// mergeUnknown regroupe les valeurs vides (clics non enrichis) avec la valeur "unknown".
+func mergeUnknown(counts []models.DimensionCount) []models.DimensionCount {
+	merged := make([]models.DimensionCount, 0, len(counts))
+	unknownIndex := -1
+	for _, count := range counts {
+		if count.Value == "" || count.Value == useragent.Unknown {
+			if unknownIndex == -1 {
+				unknownIndex = len(merged)
+				merged = append(merged, models.DimensionCount{Value: useragent.Unknown})
+			}
+			merged[unknownIndex].Clicks += count.Clicks
+			continue
+		}
+		merged = append(merged, count)
+	}
+	// La fusion peut modifier l'ordre : on retrie par nombre de clics décroissant
+	sort.SliceStable(merged, func(i, j int) bool { return merged[i].Clicks > merged[j].Clicks })
+	return merged
+}
@@ -2,14 +2,20 @@ package services
 
 import (
 	"crypto/rand"
+	"encoding/json"
 	"errors"
 	"fmt"
 	"log"
 	"math/big"
+	"net/http"
+	"regexp"
+	"strings"
 	"time"
 
+	"golang.org/x/crypto/bcrypt"
 	"gorm.io/gorm" // Nécessaire pour la gestion spécifique de gorm.ErrRecordNotFound
 
+	"github.com/axellelanca/urlshortener/internal/metrics"
 	"github.com/axellelanca/urlshortener/internal/models"
 	"github.com/axellelanca/urlshortener/internal/repository" // Importe le package repository
 )
@@ -17,14 +23,123 @@ import (
 // Définition du jeu de caractères pour la génération des codes courts.
 const charset = "abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ0123456789"
 
+// Contraintes sur les alias personnalisés (codes courts choisis par l'utilisateur).
+const (
+	aliasMinLength = 3
+	aliasMaxLength = 32 // Doit rester cohérent avec la taille de la colonne ShortCode
+)
+
+// aliasPattern n'autorise que des lettres, chiffres, tirets et underscores,
+// et impose un premier caractère alphanumérique.
+var aliasPattern = regexp.MustCompile(`^[a-zA-Z0-9][a-zA-Z0-9_-]*$`)
+
+// redirectTypes liste les codes HTTP de redirection qu'un lien peut utiliser, associés à leur équivalent
+// temporaire : 301 et 308 sont permanents (mis en cache par les navigateurs), 302 et 307 temporaires.
+// 308 et 307 conservent la méthode et le corps de la requête, contrairement à 301 et 302.
+var redirectTypes = map[int]int{
+	http.StatusMovedPermanently:  http.StatusFound,
+	http.StatusFound:             http.StatusFound,
+	http.StatusTemporaryRedirect: http.StatusTemporaryRedirect,
+	http.StatusPermanentRedirect: http.StatusTemporaryRedirect,
+}
+
+// reservedAliases liste les mots qui ne peuvent pas servir d'alias car ils masqueraient
+// des routes du service (ex: /api) ou des chemins couramment demandés par les navigateurs.
+var reservedAliases = map[string]struct{}{
+	"api":     {},
+	"health":  {},
+	"metrics": {},
+	"admin":   {},
+	"static":  {},
+	"assets":  {},
+	"login":   {},
+	"logout":  {},
+}
+
+// Erreurs personnalisées exposées par le LinkService.
+// Les handlers et la CLI les testent avec errors.Is pour choisir la réponse adaptée.
+var (
+	ErrInvalidAlias  = errors.New("alias invalide")
+	ErrReservedAlias = errors.New("alias réservé")
+	ErrAliasTaken    = errors.New("alias déjà utilisé")
+
+	ErrInvalidExpiration = errors.New("paramètres d'expiration invalides")
+	ErrLinkExpired       = errors.New("lien expiré")
+
+	ErrInvalidListQuery = errors.New("paramètres de liste invalides")
+
+	ErrLinkDeleted    = errors.New("lien supprimé")
+	ErrLinkNotDeleted = errors.New("le lien n'est pas supprimé")
+
+	ErrNotLinkOwner     = errors.New("le lien appartient à une autre clé API")
+	ErrLinkAlreadyOwned = errors.New("le lien a déjà un propriétaire")
+
+	ErrURLRejected = errors.New("URL refusée")
+
+	ErrInvalidPassword  = errors.New("mot de passe invalide")
+	ErrPasswordRequired = errors.New("mot de passe requis")
+	ErrWrongPassword    = errors.New("mot de passe incorrect")
+	ErrLinkNotProtected = errors.New("le lien n'est pas protégé par un mot de passe")
+
+	ErrPreviewRequired = errors.New("aperçu requis avant la redirection")
+
+	ErrInvalidRedirectType = errors.New("type de redirection invalide")
+)
+
+// Longueurs autorisées pour le mot de passe d'un lien. bcrypt ignore au-delà de 72 octets.
+const (
+	passwordMinLength = 4
+	passwordMaxLength = 72
+)
+
+// URLPolicy vérifie qu'une URL de destination peut être enregistrée (schéma, domaine, adresse...).
+// Check retourne une erreur décrivant le refus, typiquement une *urlpolicy.Violation.
+type URLPolicy interface {
+	Check(rawURL string) error
+}
+
+// Pagination par défaut et maximale pour ListLinks.
+const (
+	DefaultPageSize = 20
+	MaxPageSize     = 100
+)
+
+// sortableLinkFields associe les noms de tri acceptés par l'API aux colonnes de la table links.
+var sortableLinkFields = map[string]string{
+	"created_at": "created_at",
+	"short_code": "short_code",
+	"long_url":   "long_url",
+	"expires_at": "expires_at",
+}
+
+// CreateLinkInput regroupe les paramètres de création d'un lien.
+// Seul LongURL est obligatoire, les autres champs sont optionnels.
+type CreateLinkInput struct {
+	LongURL   string
+	Alias     string     // Code court personnalisé, généré aléatoirement si vide
+	ExpiresAt *time.Time // Date après laquelle le lien ne redirige plus
+	MaxClicks int        // Nombre maximal de redirections (0 = illimité)
+	OwnerID   *uint      // Clé API propriétaire du lien (nil pour la CLI)
+	Password  string     // Mot de passe demandé avant la redirection, aucun si vide
+	// Affiche toujours la page d'aperçu avant la redirection
+	AlwaysPreview bool
+	// Code HTTP de redirection (301, 302, 307 ou 308), 0 pour celui du serveur
+	RedirectType int
+}
+
 type LinkService struct {
-	linkRepo repository.LinkRepository // Référence vers le repository de liens
+	linkRepo  repository.LinkRepository  // Référence vers le repository de liens
+	auditRepo repository.AuditRepository // Journal d'audit des opérations sur les liens
+	urlPolicy URLPolicy                  // Politique des URLs de destination, nil pour n'en appliquer aucune
 }
 
 // NewLinkService crée et retourne une nouvelle instance de LinkService.
-func NewLinkService(linkRepo repository.LinkRepository) *LinkService {
+// urlPolicy est appliquée aux URLs longues à la création et à la modification des liens ; elle peut être nil.
+func NewLinkService(linkRepo repository.LinkRepository, auditRepo repository.AuditRepository, urlPolicy URLPolicy) *LinkService {
 	return &LinkService{
-		linkRepo: linkRepo,
+		linkRepo:  linkRepo,
+		auditRepo: auditRepo,
+		urlPolicy: urlPolicy,
 	}
 }
 
@@ -47,8 +162,175 @@ func GenerateShortCode(length int) (string, error) {
 	return string(shortCode), nil
 }
 
+// ValidateAlias vérifie qu'un alias personnalisé respecte le jeu de caractères,
+// les longueurs autorisées et qu'il ne fait pas partie des mots réservés.
+func ValidateAlias(alias string) error {
+	if len(alias) < aliasMinLength || len(alias) > aliasMaxLength {
+		return fmt.Errorf("%w: la longueur doit être comprise entre %d et %d caractères", ErrInvalidAlias, aliasMinLength, aliasMaxLength)
+	}
+	if !aliasPattern.MatchString(alias) {
+		return fmt.Errorf("%w: seuls les lettres, chiffres, '-' et '_' sont autorisés", ErrInvalidAlias)
+	}
+	if _, reserved := reservedAliases[strings.ToLower(alias)]; reserved {
+		return fmt.Errorf("%w: '%s'", ErrReservedAlias, alias)
+	}
+	return nil
+}
+
 // CreateLink crée un nouveau lien raccourci.
-func (s *LinkService) CreateLink(longURL string) (*models.Link, error) {
+// Si input.Alias est non vide, il est utilisé comme code court à la place d'un code généré.
+// actor identifie l'auteur de l'opération dans le journal d'audit.
+func (s *LinkService) CreateLink(input CreateLinkInput, actor string) (*models.Link, error) {
+	now := time.Now()
+	if input.ExpiresAt != nil && !input.ExpiresAt.After(now) {
+		return nil, fmt.Errorf("[Service::CreateLink] %w: la date d'expiration doit être dans le futur", ErrInvalidExpiration)
+	}
+	if input.MaxClicks < 0 {
+		return nil, fmt.Errorf("[Service::CreateLink] %w: le nombre maximal de clics ne peut pas être négatif", ErrInvalidExpiration)
+	}
+	if err := s.checkURL(input.LongURL); err != nil {
+		return nil, fmt.Errorf("[Service::CreateLink] %w", err)
+	}
+	passwordHash, err := hashPassword(input.Password)
+	if err != nil {
+		return nil, fmt.Errorf("[Service::CreateLink] %w", err)
+	}
+	link := &models.Link{
+		LongURL:       input.LongURL,
+		CreatedAt:     now,
+		ExpiresAt:     input.ExpiresAt,
+		MaxClicks:     input.MaxClicks,
+		OwnerID:       input.OwnerID,
+		PasswordHash:  passwordHash,
+		AlwaysPreview: input.AlwaysPreview,
+		RedirectType:  input.RedirectType,
+	}
+	if err := checkRedirectType(link); err != nil {
+		return nil, fmt.Errorf("[Service::CreateLink] %w", err)
+	}
+
+	var shortCode string
+
+	alias := input.Alias
+	if alias != "" {
+		shortCode, err = s.reserveAlias(alias)
+	} else {
+		shortCode, err = s.generateUniqueShortCode()
+	}
+	if err != nil {
+		return nil, err
+	}
+	link.ShortCode = shortCode
+
+	if err := s.linkRepo.CreateLink(link); err != nil {
+		if alias != "" && (errors.Is(err, gorm.ErrDuplicatedKey) || s.shortCodeExists(alias)) {
+			// L'alias a été pris entre la vérification et l'insertion
+			return nil, fmt.Errorf("[Service::CreateLink] %w: '%s'", ErrAliasTaken, alias)
+		}
+		return nil, fmt.Errorf("[Service::CreateLink] erreur lors de la création du lien: %w", err)
+	}
+
+	metrics.LinksCreatedTotal.Inc()
+	s.recordAudit(models.AuditActionCreate, actor, nil, link)
+	return link, nil
+}
+
+// checkURL applique la politique des URLs de destination, si elle est configurée.
+func (s *LinkService) checkURL(longURL string) error {
+	if s.urlPolicy == nil {
+		return nil
+	}
+	if err := s.urlPolicy.Check(longURL); err != nil {
+		return fmt.Errorf("%w: %w", ErrURLRejected, err)
+	}
+	return nil
+}
+
+// ValidateRedirectType vérifie qu'un code de redirection fait partie des codes autorisés.
+// 0, qui désigne le code par défaut du serveur, est accepté.
+func ValidateRedirectType(code int) error {
+	if code == 0 {
+		return nil
+	}
+	if _, ok := redirectTypes[code]; !ok {
+		return fmt.Errorf("%w: %d (301, 302, 307 ou 308 attendu)", ErrInvalidRedirectType, code)
+	}
+	return nil
+}
+
+// checkRedirectType valide le code de redirection d'un lien et refuse les codes permanents (301, 308)
+// pour les liens dont chaque visite doit passer par le service (voir models.Link.RequiresServerVisit).
+func checkRedirectType(link *models.Link) error {
+	if err := ValidateRedirectType(link.RedirectType); err != nil {
+		return err
+	}
+	if temporary := redirectTypes[link.RedirectType]; temporary != link.RedirectType && link.RequiresServerVisit() {
+		return fmt.Errorf("%w: %d est permanent et serait mis en cache par les navigateurs, ce qui contournerait "+
+			"l'expiration, le quota de clics, le mot de passe ou l'aperçu du lien (utilisez %d)", ErrInvalidRedirectType, link.RedirectType, temporary)
+	}
+	return nil
+}
+
+// EffectiveRedirectType retourne le code HTTP de redirection d'un lien : le sien s'il en a un, sinon
+// defaultType (302 si 0). Un code permanent est remplacé par son équivalent temporaire pour les liens
+// dont chaque visite doit passer par le service, ce qui couvre le code par défaut du serveur.
+func EffectiveRedirectType(link *models.Link, defaultType int) int {
+	code := link.RedirectType
+	if code == 0 {
+		code = defaultType
+	}
+	if code == 0 {
+		return http.StatusFound
+	}
+	if link.RequiresServerVisit() {
+		if temporary, ok := redirectTypes[code]; ok {
+			return temporary
+		}
+	}
+	return code
+}
+
+// hashPassword valide le mot de passe d'un lien et retourne son hash bcrypt, vide si password est vide.
+func hashPassword(password string) (string, error) {
+	if password == "" {
+		return "", nil
+	}
+	if len(password) < passwordMinLength || len(password) > passwordMaxLength {
+		return "", fmt.Errorf("%w: la longueur doit être comprise entre %d et %d caractères", ErrInvalidPassword, passwordMinLength, passwordMaxLength)
+	}
+	hash, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+	if err != nil {
+		return "", fmt.Errorf("erreur lors du hachage du mot de passe: %w", err)
+	}
+	return string(hash), nil
+}
+
+// reserveAlias valide un alias personnalisé et vérifie qu'il n'est pas déjà utilisé.
+func (s *LinkService) reserveAlias(alias string) (string, error) {
+	if err := ValidateAlias(alias); err != nil {
+		return "", fmt.Errorf("[Service::CreateLink] %w", err)
+	}
+
+	_, err := s.linkRepo.GetLinkByShortCode(alias)
+	if err == nil {
+		return "", fmt.Errorf("[Service::CreateLink] %w: '%s'", ErrAliasTaken, alias)
+	}
+	if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return "", fmt.Errorf("[Service::CreateLink] Erreur lors de la vérification de l'alias: %w", err)
+	}
+	return alias, nil
+}
+
+// shortCodeExists indique si un lien, supprimé ou non, utilise déjà shortCode. Elle identifie un conflit
+// d'insertion quel que soit le driver, l'erreur de contrainte d'unicité n'étant traduite en
+// gorm.ErrDuplicatedKey que si la connexion est ouverte avec TranslateError.
+func (s *LinkService) shortCodeExists(shortCode string) bool {
+	_, err := s.linkRepo.GetLinkByShortCode(shortCode)
+	return err == nil
+}
+
+// generateUniqueShortCode génère un code court aléatoire en gérant les collisions par retry.
+func (s *LinkService) generateUniqueShortCode() (string, error) {
 	const maxRetries = 5
 	var shortCode string
 	var err error
@@ -56,7 +338,7 @@ func (s *LinkService) CreateLink(longURL string) (*models.Link, error) {
 	for i := 0; i < maxRetries; i++ {
 		shortCode, err = GenerateShortCode(6)
 		if err != nil {
-			return nil, fmt.Errorf("[Service::CreateLink] Erreur lors de la génération du code court: %w", err)
+			return "", fmt.Errorf("[Service::CreateLink] Erreur lors de la génération du code court: %w", err)
 		}
 
 		_, err = s.linkRepo.GetLinkByShortCode(shortCode)
@@ -65,7 +347,7 @@ func (s *LinkService) CreateLink(longURL string) (*models.Link, error) {
 				// Le code est unique on peut sortir de la boucle
 				break
 			}
-			return nil, fmt.Errorf("[Service::CreateLink] Erreur lors de la vérification d'unicité du code court: %w", err)
+			return "", fmt.Errorf("[Service::CreateLink] Erreur lors de la vérification d'unicité du code court: %w", err)
 		}
 
 		// Collision détectée, on log et on retente
@@ -74,23 +356,267 @@ func (s *LinkService) CreateLink(longURL string) (*models.Link, error) {
 
 	if err == nil {
 		// Si on sort de la boucle sans erreurs, c'est qu'on a trouvé un code existant à chaque fois
-		return nil, errors.New("[Service::CreateLink] Impossible de gén un code court unique après plusieurs tentatives")
+		return "", errors.New("[Service::CreateLink] Impossible de gén un code court unique après plusieurs tentatives")
 	}
 
-	link := &models.Link{
-		LongURL:   longURL,
-		ShortCode: shortCode,
-		CreatedAt: time.Now(),
+	return shortCode, nil
+}
+
+// ListLinksInput regroupe les paramètres de pagination, de filtre et de tri pour ListLinks.
+type ListLinksInput struct {
+	Page     int    // Numéro de page, à partir de 1
+	PageSize int    // Nombre de liens par page (DefaultPageSize si 0)
+	Search   string // Recherche partielle sur le code court ou l'URL longue
+	Status   string // "active", "expired", "deleted" ou vide pour tous les liens non supprimés
+	Sort     string // Champ de tri, préfixé par '-' pour un ordre décroissant (ex: "-created_at")
+	OwnerID  *uint  // Limite la liste aux liens d'une clé API (nil pour tous les liens)
+}
+
+// UpdateLinkInput regroupe les champs modifiables d'un lien. Les champs nil ne sont pas modifiés.
+type UpdateLinkInput struct {
+	LongURL   *string
+	ExpiresAt *time.Time
+	MaxClicks *int
+	Password  *string // Nouveau mot de passe, ou chaîne vide pour retirer la protection
+	// Active ou désactive la page d'aperçu systématique
+	AlwaysPreview *bool
+	// Nouveau code de redirection, ou 0 pour revenir à celui du serveur
+	RedirectType *int
+}
+
+// ListLinks retourne une page de liens ainsi que le nombre total de liens correspondant aux filtres.
+func (s *LinkService) ListLinks(input ListLinksInput) ([]models.Link, int64, error) {
+	if input.Page == 0 {
+		input.Page = 1
+	}
+	if input.PageSize == 0 {
+		input.PageSize = DefaultPageSize
+	}
+	if input.Page < 1 || input.PageSize < 1 || input.PageSize > MaxPageSize {
+		return nil, 0, fmt.Errorf("[Service::ListLinks] %w: page >= 1 et page_size entre 1 et %d attendus", ErrInvalidListQuery, MaxPageSize)
 	}
 
-	if err := s.linkRepo.CreateLink(link); err != nil {
-		return nil, fmt.Errorf("[Service::CreateLink] erreur lors de la création du lien: %w", err)
+	filter := repository.LinkFilter{
+		OwnerID:   input.OwnerID,
+		Search:    input.Search,
+		Limit:     input.PageSize,
+		Offset:    (input.Page - 1) * input.PageSize,
+		Reference: time.Now(),
+	}
+
+	switch input.Status {
+	case "", repository.LinkStatusActive, repository.LinkStatusExpired, repository.LinkStatusDeleted:
+		filter.Status = input.Status
+	default:
+		return nil, 0, fmt.Errorf("[Service::ListLinks] %w: statut '%s' inconnu", ErrInvalidListQuery, input.Status)
+	}
+
+	if input.Sort != "" {
+		field := strings.TrimPrefix(input.Sort, "-")
+		column, ok := sortableLinkFields[field]
+		if !ok {
+			return nil, 0, fmt.Errorf("[Service::ListLinks] %w: tri sur '%s' non supporté", ErrInvalidListQuery, field)
+		}
+		filter.SortBy = column
+		filter.SortDesc = strings.HasPrefix(input.Sort, "-")
+	}
+
+	links, total, err := s.linkRepo.ListLinks(filter)
+	if err != nil {
+		return nil, 0, fmt.Errorf("[Service::ListLinks] Erreur lors de la récupération des liens: %w", err)
+	}
+	return links, total, nil
+}
+
+// UpdateLink modifie la destination et/ou les paramètres d'expiration d'un lien existant.
+func (s *LinkService) UpdateLink(shortCode string, input UpdateLinkInput, actor string) (*models.Link, error) {
+	link, err := s.GetLinkByShortCode(shortCode)
+	if err != nil {
+		return nil, err
+	}
+	before := *link
+
+	if input.LongURL != nil {
+		if err := s.checkURL(*input.LongURL); err != nil {
+			return nil, fmt.Errorf("[Service::UpdateLink] %w", err)
+		}
+		link.LongURL = *input.LongURL
+	}
+	if input.ExpiresAt != nil {
+		if !input.ExpiresAt.After(time.Now()) {
+			return nil, fmt.Errorf("[Service::UpdateLink] %w: la date d'expiration doit être dans le futur", ErrInvalidExpiration)
+		}
+		link.ExpiresAt = input.ExpiresAt
+	}
+	if input.MaxClicks != nil {
+		if *input.MaxClicks < 0 {
+			return nil, fmt.Errorf("[Service::UpdateLink] %w: le nombre maximal de clics ne peut pas être négatif", ErrInvalidExpiration)
+		}
+		link.MaxClicks = *input.MaxClicks
+	}
+	if input.Password != nil {
+		passwordHash, err := hashPassword(*input.Password)
+		if err != nil {
+			return nil, fmt.Errorf("[Service::UpdateLink] %w", err)
+		}
+		link.PasswordHash = passwordHash
+	}
+	if input.AlwaysPreview != nil {
+		link.AlwaysPreview = *input.AlwaysPreview
+	}
+	if input.RedirectType != nil {
+		link.RedirectType = *input.RedirectType
+	}
+	// Vérifié après toutes les modifications : ajouter une restriction à un lien permanent est aussi refusé
+	if err := checkRedirectType(link); err != nil {
+		return nil, fmt.Errorf("[Service::UpdateLink] %w", err)
+	}
+
+	if err := s.linkRepo.UpdateLink(link); err != nil {
+		return nil, fmt.Errorf("[Service::UpdateLink] Erreur lors de la mise à jour du lien: %w", err)
+	}
+
+	s.recordAudit(models.AuditActionUpdate, actor, &before, link)
+	return link, nil
+}
+
+// DeleteLink supprime logiquement un lien : il ne redirige plus mais reste restaurable,
+// et l'historique de ses clics est conservé.
+func (s *LinkService) DeleteLink(shortCode string, actor string) error {
+	link, err := s.GetLinkByShortCode(shortCode)
+	if err != nil {
+		return err
+	}
+
+	if err := s.linkRepo.DeleteLink(link.ID); err != nil {
+		return fmt.Errorf("[Service::DeleteLink] Erreur lors de la suppression du lien: %w", err)
+	}
+
+	after := *link
+	after.DeletedAt = gorm.DeletedAt{Time: time.Now(), Valid: true}
+	s.recordAudit(models.AuditActionDelete, actor, link, &after)
+	return nil
+}
+
+// RestoreLink restaure un lien précédemment supprimé.
+func (s *LinkService) RestoreLink(shortCode string, actor string) (*models.Link, error) {
+	link, err := s.linkRepo.GetLinkByShortCode(shortCode)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, fmt.Errorf("[Service::RestoreLink] Lien non trouvé pour le code court '%s': %w", shortCode, err)
+		}
+		return nil, fmt.Errorf("[Service::RestoreLink] Erreur lors de la récupération du lien: %w", err)
+	}
+	if !link.DeletedAt.Valid {
+		return nil, fmt.Errorf("[Service::RestoreLink] %w: '%s'", ErrLinkNotDeleted, shortCode)
+	}
+	before := *link
+
+	if err := s.linkRepo.RestoreLink(link.ID); err != nil {
+		return nil, fmt.Errorf("[Service::RestoreLink] Erreur lors de la restauration du lien: %w", err)
+	}
+
+	link.DeletedAt = gorm.DeletedAt{}
+	s.recordAudit(models.AuditActionRestore, actor, &before, link)
+	return link, nil
+}
+
+// AssignOwner attribue un lien, supprimé ou non, à la clé API ownerID, qui peut alors le gérer via l'API.
+// Elle sert à reprendre les liens sans propriétaire (créés par la CLI ou avant les clés API) ; un lien
+// qui appartient déjà à une autre clé n'est transféré que si force est vrai.
+func (s *LinkService) AssignOwner(shortCode string, ownerID uint, force bool, actor string) (*models.Link, error) {
+	link, err := s.linkRepo.GetLinkByShortCode(shortCode)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, fmt.Errorf("[Service::AssignOwner] Lien non trouvé pour le code court '%s': %w", shortCode, err)
+		}
+		return nil, fmt.Errorf("[Service::AssignOwner] Erreur lors de la récupération du lien: %w", err)
+	}
+	if link.OwnerID != nil && *link.OwnerID == ownerID {
+		return link, nil
+	}
+	if link.OwnerID != nil && !force {
+		return nil, fmt.Errorf("[Service::AssignOwner] %w: '%s' appartient à la clé %d", ErrLinkAlreadyOwned, shortCode, *link.OwnerID)
+	}
+	before := *link
+
+	if err := s.linkRepo.SetLinkOwner(link.ID, ownerID); err != nil {
+		return nil, fmt.Errorf("[Service::AssignOwner] Erreur lors de l'attribution du lien: %w", err)
 	}
 
+	link.OwnerID = &ownerID
+	s.recordAudit(models.AuditActionAssignOwner, actor, &before, link)
 	return link, nil
 }
 
+// CheckOwnership vérifie qu'un lien, supprimé ou non, appartient à la clé API ownerID.
+func (s *LinkService) CheckOwnership(shortCode string, ownerID uint) error {
+	link, err := s.linkRepo.GetLinkByShortCode(shortCode)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return fmt.Errorf("[Service::CheckOwnership] Lien non trouvé pour le code court '%s': %w", shortCode, err)
+		}
+		return fmt.Errorf("[Service::CheckOwnership] Erreur lors de la récupération du lien: %w", err)
+	}
+	if link.OwnerID == nil || *link.OwnerID != ownerID {
+		return fmt.Errorf("[Service::CheckOwnership] %w: '%s'", ErrNotLinkOwner, shortCode)
+	}
+	return nil
+}
+
+// GetAuditLog retourne l'historique des opérations d'un lien, y compris s'il est supprimé.
+func (s *LinkService) GetAuditLog(shortCode string) ([]models.AuditLog, error) {
+	link, err := s.linkRepo.GetLinkByShortCode(shortCode)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, fmt.Errorf("[Service::GetAuditLog] Lien non trouvé pour le code court '%s': %w", shortCode, err)
+		}
+		return nil, fmt.Errorf("[Service::GetAuditLog] Erreur lors de la récupération du lien: %w", err)
+	}
+
+	entries, err := s.auditRepo.ListAuditLogsByLinkID(link.ID)
+	if err != nil {
+		return nil, fmt.Errorf("[Service::GetAuditLog] Erreur lors de la récupération du journal d'audit: %w", err)
+	}
+	return entries, nil
+}
+
+// recordAudit enregistre une opération dans le journal d'audit avec l'état du lien avant et après.
+// Un échec d'écriture est journalisé sans annuler l'opération, qui a déjà été appliquée.
+func (s *LinkService) recordAudit(action string, actor string, before, after *models.Link) {
+	entry := &models.AuditLog{
+		Action:    action,
+		Actor:     actor,
+		Before:    snapshotLink(before),
+		After:     snapshotLink(after),
+		CreatedAt: time.Now(),
+	}
+	if after != nil {
+		entry.LinkID, entry.ShortCode = after.ID, after.ShortCode
+	} else if before != nil {
+		entry.LinkID, entry.ShortCode = before.ID, before.ShortCode
+	}
+
+	if err := s.auditRepo.CreateAuditLog(entry); err != nil {
+		log.Printf("[Service::recordAudit] ERREUR lors de l'écriture du journal d'audit (%s sur '%s' par %s) : %v",
+			action, entry.ShortCode, actor, err)
+	}
+}
+
+// snapshotLink sérialise l'état d'un lien en JSON pour le journal d'audit.
+func snapshotLink(link *models.Link) string {
+	if link == nil {
+		return ""
+	}
+	data, err := json.Marshal(link)
+	if err != nil {
+		return ""
+	}
+	return string(data)
+}
+
 // GetLinkByShortCode récupère un lien via son court code
+// Retourne ErrLinkDeleted si le lien a été supprimé logiquement.
 func (s *LinkService) GetLinkByShortCode(shortCode string) (*models.Link, error) {
 	link, err := s.linkRepo.GetLinkByShortCode(shortCode)
 	if err != nil {
@@ -99,12 +625,85 @@ func (s *LinkService) GetLinkByShortCode(shortCode string) (*models.Link, error)
 		}
 		return nil, fmt.Errorf("[Service::GetLinkByShortCode] Erreur lors de la récupération du lien: %w", err)
 	}
+	if link.DeletedAt.Valid {
+		return nil, fmt.Errorf("[Service::GetLinkByShortCode] %w: '%s'", ErrLinkDeleted, shortCode)
+	}
+	return link, nil
+}
+
+// ResolveLink récupère le lien à utiliser pour une redirection.
+// Il retourne ErrLinkExpired si le lien a dépassé sa date d'expiration ou son quota de clics,
+// ErrPreviewRequired si le lien exige la page d'aperçu et que le visiteur ne l'a pas validée (previewed),
+// ErrPasswordRequired si le lien est protégé (voir UnlockLink),
+// et consomme une redirection sur le quota des liens limités, sauf pour un robot (bot) :
+// les robots et aperçus de liens des messageries n'épuisent pas le quota destiné aux visiteurs.
+func (s *LinkService) ResolveLink(shortCode string, previewed, bot bool) (*models.Link, error) {
+	link, err := s.GetLinkByShortCode(shortCode)
+	if err != nil {
+		return nil, err
+	}
+
+	if link.IsExpired(time.Now()) {
+		return nil, fmt.Errorf("[Service::ResolveLink] %w: '%s'", ErrLinkExpired, shortCode)
+	}
+	if link.AlwaysPreview && !previewed {
+		return nil, fmt.Errorf("[Service::ResolveLink] %w: '%s'", ErrPreviewRequired, shortCode)
+	}
+	if link.IsProtected() {
+		return nil, fmt.Errorf("[Service::ResolveLink] %w: '%s'", ErrPasswordRequired, shortCode)
+	}
+
+	return s.consumeClick(link, bot)
+}
+
+// UnlockLink vérifie le mot de passe d'un lien protégé et, s'il est correct, le résout comme ResolveLink.
+// Un mot de passe erroné est compté dans les statistiques du lien et retourne ErrWrongPassword.
+// Un lien sans mot de passe retourne ErrLinkNotProtected : il ne se résout que par ResolveLink,
+// qui lui applique l'éventuelle page d'aperçu.
+func (s *LinkService) UnlockLink(shortCode, password string, bot bool) (*models.Link, error) {
+	link, err := s.GetLinkByShortCode(shortCode)
+	if err != nil {
+		return nil, err
+	}
+
+	if link.IsExpired(time.Now()) {
+		return nil, fmt.Errorf("[Service::UnlockLink] %w: '%s'", ErrLinkExpired, shortCode)
+	}
+	if !link.IsProtected() {
+		return nil, fmt.Errorf("[Service::UnlockLink] %w: '%s'", ErrLinkNotProtected, shortCode)
+	}
+	if bcrypt.CompareHashAndPassword([]byte(link.PasswordHash), []byte(password)) != nil {
+		if err := s.linkRepo.IncrementUnlockFailures(link.ID); err != nil {
+			log.Printf("[Service::UnlockLink] ERREUR lors du comptage de l'échec de déverrouillage de '%s' : %v", shortCode, err)
+		}
+		return nil, fmt.Errorf("[Service::UnlockLink] %w: '%s'", ErrWrongPassword, shortCode)
+	}
+
+	return s.consumeClick(link, bot)
+}
+
+// consumeClick consomme une redirection sur le quota d'un lien limité et retourne le lien.
+// La redirection d'un robot ne consomme rien.
+func (s *LinkService) consumeClick(link *models.Link, bot bool) (*models.Link, error) {
+	if link.MaxClicks > 0 && !bot {
+		consumed, err := s.linkRepo.ConsumeClick(link.ID)
+		if err != nil {
+			return nil, fmt.Errorf("[Service::consumeClick] Erreur lors de la consommation du quota de clics: %w", err)
+		}
+		if !consumed {
+			// Un autre clic concurrent a épuisé le quota entre la lecture et la mise à jour
+			return nil, fmt.Errorf("[Service::consumeClick] %w: '%s'", ErrLinkExpired, link.ShortCode)
+		}
+		link.ConsumedClicks++
+	}
+
 	return link, nil
 }
 
 // GetLinkStats récupère les statistiques pour un lien donné (nombre total de clics).
 // Il interagit avec le LinkRepository pour obtenir le lien, puis avec le ClickRepository
-func (s *LinkService) GetLinkStats(shortCode string) (*models.Link, int, error) {
+// Les clics de robots ne sont comptés que si includeBots est vrai.
+func (s *LinkService) GetLinkStats(shortCode string, includeBots bool) (*models.Link, int, error) {
 	// TODO : Récupérer le lien par son shortCode
 	link, err := s.GetLinkByShortCode(shortCode)
 	if err != nil {
@@ -114,7 +713,7 @@ func (s *LinkService) GetLinkStats(shortCode string) (*models.Link, int, error)
 		return nil, 0, fmt.Errorf("[Service::GetLinkStats] Lien non trouvé pour le code court '%s'", shortCode)
 	}
 
-	clicksCount, err := s.linkRepo.CountClicksByLinkID(link.ID)
+	clicksCount, err := s.linkRepo.CountClicksByLinkID(link.ID, includeBots)
 	if err != nil {
 		return nil, 0, fmt.Errorf("[Service::GetLinkStats] Erreur lors du comptage des clics pour le lien ID %d: %w", link.ID, err)
 	}
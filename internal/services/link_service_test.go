@@ -0,0 +1,261 @@
+package services
+
+import (
+	"errors"
+	"net/http"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/axellelanca/urlshortener/internal/migrations"
+	"github.com/axellelanca/urlshortener/internal/models"
+	"github.com/axellelanca/urlshortener/internal/repository"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+func TestEffectiveRedirectType(t *testing.T) {
+	expiresAt := time.Now().Add(time.Hour)
+	tests := []struct {
+		name        string
+		link        models.Link
+		defaultType int
+		want        int
+	}{
+		{"sans code", models.Link{}, 0, http.StatusFound},
+		{"code du serveur", models.Link{}, http.StatusMovedPermanently, http.StatusMovedPermanently},
+		{"code du lien", models.Link{RedirectType: http.StatusPermanentRedirect}, http.StatusFound, http.StatusPermanentRedirect},
+		{"serveur permanent, quota", models.Link{MaxClicks: 3}, http.StatusMovedPermanently, http.StatusFound},
+		{"serveur permanent, expiration", models.Link{ExpiresAt: &expiresAt}, http.StatusPermanentRedirect, http.StatusTemporaryRedirect},
+		{"serveur permanent, mot de passe", models.Link{PasswordHash: "hash"}, http.StatusMovedPermanently, http.StatusFound},
+		// Lien enregistré avant le refus des codes permanents pour les liens restreints
+		{"lien permanent, aperçu", models.Link{RedirectType: http.StatusPermanentRedirect, AlwaysPreview: true}, 0, http.StatusTemporaryRedirect},
+		{"code temporaire conservé", models.Link{RedirectType: http.StatusTemporaryRedirect, MaxClicks: 3}, http.StatusMovedPermanently, http.StatusTemporaryRedirect},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := EffectiveRedirectType(&tt.link, tt.defaultType); got != tt.want {
+				t.Errorf("EffectiveRedirectType = %d, attendu %d", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCheckRedirectType(t *testing.T) {
+	tests := []struct {
+		name    string
+		link    models.Link
+		wantErr bool
+	}{
+		{"permanent sans restriction", models.Link{RedirectType: http.StatusMovedPermanently}, false},
+		{"temporaire avec quota", models.Link{RedirectType: http.StatusFound, MaxClicks: 3}, false},
+		{"code du serveur avec quota", models.Link{MaxClicks: 3}, false},
+		{"301 avec quota", models.Link{RedirectType: http.StatusMovedPermanently, MaxClicks: 3}, true},
+		{"308 avec aperçu", models.Link{RedirectType: http.StatusPermanentRedirect, AlwaysPreview: true}, true},
+		{"code inconnu", models.Link{RedirectType: 303}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := checkRedirectType(&tt.link); (err != nil) != tt.wantErr {
+				t.Errorf("checkRedirectType = %v, erreur attendue : %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+// racingLinkRepository simule un alias réservé par une autre requête entre la vérification de
+// disponibilité et l'insertion : la première recherche de chaque code ne trouve rien.
+type racingLinkRepository struct {
+	repository.LinkRepository
+	checked map[string]bool
+}
+
+func (r *racingLinkRepository) GetLinkByShortCode(shortCode string) (*models.Link, error) {
+	if !r.checked[shortCode] {
+		r.checked[shortCode] = true
+		return nil, gorm.ErrRecordNotFound
+	}
+	return r.LinkRepository.GetLinkByShortCode(shortCode)
+}
+
+func TestCreateLinkDetectsAliasRaceWithoutTranslateError(t *testing.T) {
+	// Connexion sans TranslateError : la violation d'unicité reste une erreur brute du driver
+	db, err := gorm.Open(sqlite.Open(filepath.Join(t.TempDir(), "test.db")), &gorm.Config{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := migrations.NewMigrator(db).Up(); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		if sqlDB, err := db.DB(); err == nil {
+			sqlDB.Close()
+		}
+	})
+
+	if err := db.Create(&models.Link{ShortCode: "promo", LongURL: "https://example.com/first"}).Error; err != nil {
+		t.Fatal(err)
+	}
+	repo := &racingLinkRepository{LinkRepository: repository.NewLinkRepository(db), checked: map[string]bool{}}
+	service := NewLinkService(repo, repository.NewAuditRepository(db), nil)
+
+	_, err = service.CreateLink(CreateLinkInput{LongURL: "https://example.com/second", Alias: "promo"}, "test")
+	if !errors.Is(err, ErrAliasTaken) {
+		t.Fatalf("CreateLink = %v, attendu ErrAliasTaken", err)
+	}
+}
+
+// newTestLinkService crée un LinkService sur une base SQLite migrée dans un répertoire temporaire.
+func newTestLinkService(t *testing.T) (*LinkService, *gorm.DB) {
+	t.Helper()
+	db, err := gorm.Open(sqlite.Open(filepath.Join(t.TempDir(), "test.db")), &gorm.Config{TranslateError: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := migrations.NewMigrator(db).Up(); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		if sqlDB, err := db.DB(); err == nil {
+			sqlDB.Close()
+		}
+	})
+	return NewLinkService(repository.NewLinkRepository(db), repository.NewAuditRepository(db), nil), db
+}
+
+func TestResolveLinkRejectsExpiredLink(t *testing.T) {
+	service, db := newTestLinkService(t)
+	link, err := service.CreateLink(CreateLinkInput{LongURL: "https://example.com/"}, "test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := service.ResolveLink(link.ShortCode, false, false); err != nil {
+		t.Fatalf("ResolveLink avant expiration : %v", err)
+	}
+
+	// UpdateLink refuse une date passée : le lien expire pendant sa vie
+	if err := db.Model(link).Update("expires_at", time.Now().Add(-time.Minute)).Error; err != nil {
+		t.Fatal(err)
+	}
+	if _, err := service.ResolveLink(link.ShortCode, false, false); !errors.Is(err, ErrLinkExpired) {
+		t.Fatalf("ResolveLink après expiration = %v, attendu ErrLinkExpired", err)
+	}
+	if _, err := service.ResolveLink(link.ShortCode, false, true); !errors.Is(err, ErrLinkExpired) {
+		t.Fatalf("ResolveLink d'un robot après expiration = %v, attendu ErrLinkExpired", err)
+	}
+}
+
+func TestResolveLinkConsumesQuotaExceptForBots(t *testing.T) {
+	service, _ := newTestLinkService(t)
+	link, err := service.CreateLink(CreateLinkInput{LongURL: "https://example.com/", MaxClicks: 2}, "test")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Les robots sont redirigés sans entamer le quota
+	for i := 0; i < 5; i++ {
+		if _, err := service.ResolveLink(link.ShortCode, false, true); err != nil {
+			t.Fatalf("ResolveLink d'un robot n°%d : %v", i+1, err)
+		}
+	}
+	for i := 1; i <= 2; i++ {
+		resolved, err := service.ResolveLink(link.ShortCode, false, false)
+		if err != nil {
+			t.Fatalf("ResolveLink n°%d : %v", i, err)
+		}
+		if resolved.ConsumedClicks != i {
+			t.Fatalf("ConsumedClicks = %d après %d redirection(s)", resolved.ConsumedClicks, i)
+		}
+	}
+
+	// Quota épuisé : le lien est expiré pour tous, robots compris
+	if _, err := service.ResolveLink(link.ShortCode, false, false); !errors.Is(err, ErrLinkExpired) {
+		t.Fatalf("ResolveLink après épuisement du quota = %v, attendu ErrLinkExpired", err)
+	}
+	if _, err := service.ResolveLink(link.ShortCode, false, true); !errors.Is(err, ErrLinkExpired) {
+		t.Fatalf("ResolveLink d'un robot après épuisement du quota = %v, attendu ErrLinkExpired", err)
+	}
+	stored, err := service.GetLinkByShortCode(link.ShortCode)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if stored.ConsumedClicks != 2 {
+		t.Fatalf("ConsumedClicks enregistré = %d, attendu 2", stored.ConsumedClicks)
+	}
+}
+
+func TestResolveLinkQuotaUnderConcurrency(t *testing.T) {
+	service, _ := newTestLinkService(t)
+	link, err := service.CreateLink(CreateLinkInput{LongURL: "https://example.com/", MaxClicks: 5}, "test")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	const attempts = 20
+	results := make(chan error, attempts)
+	for i := 0; i < attempts; i++ {
+		go func() {
+			_, err := service.ResolveLink(link.ShortCode, false, false)
+			results <- err
+		}()
+	}
+	resolved := 0
+	for i := 0; i < attempts; i++ {
+		err := <-results
+		switch {
+		case err == nil:
+			resolved++
+		case !errors.Is(err, ErrLinkExpired):
+			t.Fatalf("ResolveLink : %v", err)
+		}
+	}
+	if resolved != 5 {
+		t.Fatalf("%d redirection(s) accordée(s), attendu 5", resolved)
+	}
+}
+
+func TestListLinksPagination(t *testing.T) {
+	service, _ := newTestLinkService(t)
+	for i := 0; i < 25; i++ {
+		if _, err := service.CreateLink(CreateLinkInput{LongURL: "https://example.com/"}, "test"); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	tests := []struct {
+		name  string
+		input ListLinksInput
+		want  int
+	}{
+		{"valeurs par défaut", ListLinksInput{}, DefaultPageSize},
+		{"deuxième page", ListLinksInput{Page: 2}, 5},
+		{"au-delà de la dernière page", ListLinksInput{Page: 3}, 0},
+		{"taille maximale", ListLinksInput{PageSize: MaxPageSize}, 25},
+		{"taille minimale", ListLinksInput{Page: 25, PageSize: 1}, 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			links, total, err := service.ListLinks(tt.input)
+			if err != nil {
+				t.Fatalf("ListLinks(%+v): %v", tt.input, err)
+			}
+			if len(links) != tt.want || total != 25 {
+				t.Fatalf("%d lien(s) sur %d, attendu %d sur 25", len(links), total, tt.want)
+			}
+		})
+	}
+
+	invalid := []ListLinksInput{
+		{Page: -1},
+		{PageSize: -1},
+		{PageSize: MaxPageSize + 1},
+		{Status: "archived"},
+		{Sort: "password_hash"},
+		{Sort: "-owner_id"},
+	}
+	for _, input := range invalid {
+		if _, _, err := service.ListLinks(input); !errors.Is(err, ErrInvalidListQuery) {
+			t.Errorf("ListLinks(%+v) = %v, attendu ErrInvalidListQuery", input, err)
+		}
+	}
+}
@@ -0,0 +1,395 @@
+package spool
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+
+	"github.com/axellelanca/urlshortener/internal/models"
+)
+
+const (
+	// maxSegmentBytes déclenche le passage à un nouveau segment : un segment entièrement traité
+	// peut alors être supprimé sans attendre les événements suivants.
+	maxSegmentBytes = 4 << 20
+	// maxLineBytes borne la taille d'un événement relu (User-Agent et Referer compris).
+	maxLineBytes   = 1 << 20
+	segmentPattern = "clicks-*.spool"
+	segmentFormat  = "clicks-%020d.spool"
+)
+
+// ErrClosed est retournée par Append une fois le spool fermé.
+var ErrClosed = errors.New("spool fermé")
+
+// segment est un fichier du spool. Seul le dernier segment est ouvert en écriture ;
+// les autres sont scellés et supprimés dès que tous leurs événements ont été persistés.
+type segment struct {
+	seq         uint64
+	path        string
+	file        *os.File // Ouvert en écriture pour le segment actif uniquement
+	size        int64    // Octets écrits, toujours à une fin de ligne
+	sealed      bool     // Plus aucun événement ne sera ajouté
+	consumed    bool     // Le lecteur a parcouru tous les événements du segment
+	fresh       bool     // Segment créé par cette exécution : direct et undelivered sont connus
+	direct      []bool   // Pour chaque ligne, vrai si Append l'a transmise directement au channel
+	undelivered int      // Lignes que le channel, plein, n'a pas acceptées et que le lecteur doit transmettre
+	delivered   int      // Événements transmis aux workers
+	acked       int      // Événements persistés en base
+}
+
+// Spool est un journal sur disque des événements de clic, en segments de fichiers en ajout seul.
+// Chaque événement y est écrit avant d'entrer dans le channel des workers, puis acquitté une fois
+// son lot écrit en base : un crash ne perd ni les événements en attente dans le channel, ni les lots
+// en cours d'écriture. Append transmet directement l'événement au channel s'il a de la place ; sinon
+// un lecteur unique (Feed) le relit sur disque et le transmet au rythme des workers. Les segments sont
+// supprimés une fois leurs événements persistés. Les segments restants au démarrage sont rejoués :
+// un événement peut donc être transmis plusieurs fois, les doublons étant écartés grâce à Click.EventID.
+type Spool struct {
+	dir string
+
+	mu       sync.Mutex
+	segments map[uint64]*segment
+	active   *segment
+	closed   bool
+	notify   chan struct{} // Réveille le lecteur après un ajout (tampon de 1, non bloquant)
+
+	stop     chan struct{}
+	done     chan struct{}
+	stopOnce sync.Once
+}
+
+// Open ouvre (ou crée) le spool du répertoire dir. Les segments existants, laissés par un arrêt
+// ou un crash précédent, sont scellés et seront rejoués par Feed avant les nouveaux événements.
+func Open(dir string) (*Spool, error) {
+	if err := os.MkdirAll(dir, 0o750); err != nil {
+		return nil, fmt.Errorf("[Spool] création du répertoire '%s' impossible: %w", dir, err)
+	}
+
+	s := &Spool{
+		dir:      dir,
+		segments: make(map[uint64]*segment),
+		notify:   make(chan struct{}, 1),
+		stop:     make(chan struct{}),
+		done:     make(chan struct{}),
+	}
+
+	paths, err := filepath.Glob(filepath.Join(dir, segmentPattern))
+	if err != nil {
+		return nil, fmt.Errorf("[Spool] lecture du répertoire '%s' impossible: %w", dir, err)
+	}
+	var lastSeq uint64
+	for _, path := range paths {
+		var seq uint64
+		if _, err := fmt.Sscanf(filepath.Base(path), segmentFormat, &seq); err != nil {
+			continue
+		}
+		info, err := os.Stat(path)
+		if err != nil {
+			return nil, fmt.Errorf("[Spool] segment '%s' illisible: %w", path, err)
+		}
+		s.segments[seq] = &segment{seq: seq, path: path, size: info.Size(), sealed: true}
+		lastSeq = max(lastSeq, seq)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if err := s.rotateLocked(lastSeq + 1); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+// Recovered retourne le nombre de segments laissés par une exécution précédente, à rejouer.
+func (s *Spool) Recovered() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return len(s.segments) - 1
+}
+
+// Append écrit un événement dans le segment actif, puis le transmet au channel des workers avec send,
+// qui ne doit pas bloquer. Elle retourne true si send l'a accepté ; sinon (ou si send est nil),
+// l'événement reste sur disque et Feed le transmettra dès que le channel se libère. L'événement
+// porte ensuite son segment (SpoolSegment), à acquitter avec Ack une fois le clic persisté.
+// L'écriture n'est pas synchronisée sur disque à chaque événement (pas de fsync) : elle survit
+// à un crash du processus, pas à celui du système.
+func (s *Spool) Append(event *models.ClickEvent, send func(*models.ClickEvent) bool) (bool, error) {
+	line, err := json.Marshal(event)
+	if err != nil {
+		return false, fmt.Errorf("[Spool] encodage de l'événement impossible: %w", err)
+	}
+	line = append(line, '\n')
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.closed {
+		return false, ErrClosed
+	}
+	if s.active.size > 0 && s.active.size+int64(len(line)) > maxSegmentBytes {
+		if err := s.rotateLocked(s.active.seq + 1); err != nil {
+			return false, err
+		}
+	}
+	n, err := s.active.file.Write(line)
+	if err != nil {
+		// Une ligne partielle serait illisible : le segment est scellé à sa dernière fin de ligne valide
+		if n > 0 {
+			_ = s.active.file.Truncate(s.active.size)
+		}
+		return false, fmt.Errorf("[Spool] écriture dans '%s' impossible: %w", s.active.path, err)
+	}
+	s.active.size += int64(n)
+
+	// L'envoi a lieu sous le verrou : le lecteur ne voit la ligne qu'une fois son sort décidé,
+	// et un acquittement du worker ne peut pas précéder le décompte de l'événement.
+	event.SpoolSegment = s.active.seq
+	delivered := send != nil && send(event)
+	s.active.direct = append(s.active.direct, delivered)
+	if delivered {
+		s.active.delivered++
+		return true, nil
+	}
+	s.active.undelivered++
+	select {
+	case s.notify <- struct{}{}:
+	default:
+	}
+	return false, nil
+}
+
+// Drain vide le channel events, fermé, lorsque les workers n'ont pas terminé dans le délai d'arrêt.
+// Les événements qui proviennent du spool y restent, non acquittés, et seront rejoués au prochain
+// démarrage ; les autres (écrits dans le channel après un échec d'Append) y sont ajoutés.
+// Drain retourne le nombre d'événements conservés et doit être appelée avant Close.
+func (s *Spool) Drain(events <-chan *models.ClickEvent) int {
+	kept := 0
+	for event := range events {
+		if event.SpoolSegment == 0 {
+			if _, err := s.Append(event, nil); err != nil {
+				log.Printf("[Spool] ERREUR : événement %s perdu à l'arrêt : %v", event.EventID, err)
+				continue
+			}
+		}
+		kept++
+	}
+	return kept
+}
+
+// Feed transmet au channel, dans l'ordre, les événements de tous les segments, en commençant par
+// ceux à rejouer, puis attend les nouveaux ajouts. Elle bloque tant que le channel est plein et
+// se termine à l'appel de StopFeeding. Cette fonction est conçue pour être lancée dans une goroutine séparée.
+func (s *Spool) Feed(events chan<- *models.ClickEvent) {
+	defer close(s.done)
+
+	for {
+		seg := s.nextSegment()
+		if seg == nil {
+			return
+		}
+		if !s.feedSegment(seg, events) {
+			return
+		}
+	}
+}
+
+// nextSegment retourne le plus ancien segment pas encore entièrement transmis.
+func (s *Spool) nextSegment() *segment {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var seqs []uint64
+	for seq, seg := range s.segments {
+		if !seg.consumed {
+			seqs = append(seqs, seq)
+		}
+	}
+	if len(seqs) == 0 {
+		return nil
+	}
+	sort.Slice(seqs, func(i, j int) bool { return seqs[i] < seqs[j] })
+	return s.segments[seqs[0]]
+}
+
+// feedSegment transmet les événements d'un segment jusqu'à ce qu'il soit scellé et lu en entier.
+// Elle retourne false si le lecteur a été arrêté.
+func (s *Spool) feedSegment(seg *segment, events chan<- *models.ClickEvent) bool {
+	file, err := os.Open(seg.path)
+	if err != nil {
+		log.Printf("[Spool] ERREUR : segment '%s' illisible, ignoré : %v", seg.path, err)
+		s.markConsumed(seg)
+		return true
+	}
+	defer file.Close()
+
+	var offset int64
+	line := 0 // Index de la prochaine ligne, pour reconnaître celles déjà transmises par Append
+	for {
+		s.mu.Lock()
+		size, sealed := seg.size, seg.sealed
+		s.mu.Unlock()
+
+		if offset < size {
+			// Seule la partie écrite jusqu'à une fin de ligne est lue
+			scanner := bufio.NewScanner(io.NewSectionReader(file, offset, size-offset))
+			scanner.Buffer(make([]byte, 0, 64*1024), maxLineBytes)
+			for scanner.Scan() {
+				data := scanner.Bytes()
+				offset += int64(len(data)) + 1
+				index := line
+				line++
+				if s.deliveredDirectly(seg, index) {
+					continue
+				}
+
+				var event models.ClickEvent
+				if err := json.Unmarshal(data, &event); err != nil {
+					// Typiquement la dernière ligne d'un segment interrompu par un crash
+					log.Printf("[Spool] ERREUR : événement illisible dans '%s', ignoré : %v", seg.path, err)
+					continue
+				}
+				event.SpoolSegment = seg.seq
+
+				select {
+				case events <- &event:
+					s.mu.Lock()
+					seg.delivered++
+					if seg.fresh {
+						seg.undelivered--
+					}
+					s.mu.Unlock()
+				case <-s.stop:
+					return false
+				}
+			}
+			if err := scanner.Err(); err != nil {
+				log.Printf("[Spool] ERREUR : lecture de '%s' interrompue : %v", seg.path, err)
+				offset = size
+			}
+			continue
+		}
+
+		if sealed {
+			s.markConsumed(seg)
+			return true
+		}
+
+		// Segment actif lu en entier : attente d'un nouvel ajout
+		select {
+		case <-s.notify:
+		case <-s.stop:
+			return false
+		}
+	}
+}
+
+// deliveredDirectly indique si la ligne index d'un segment de cette exécution a déjà été transmise par Append.
+func (s *Spool) deliveredDirectly(seg *segment, index int) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return seg.fresh && index < len(seg.direct) && seg.direct[index]
+}
+
+// markConsumed note qu'un segment a été entièrement transmis et le supprime s'il est aussi entièrement persisté.
+func (s *Spool) markConsumed(seg *segment) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	seg.consumed = true
+	s.removeIfDoneLocked(seg)
+}
+
+// Ack enregistre la persistance d'événements transmis par Feed. Les segments scellés dont tous
+// les événements sont persistés sont supprimés. Les événements qui n'ont pas pu être persistés
+// ne doivent pas être acquittés : leur segment est conservé et rejoué au prochain démarrage.
+func (s *Spool) Ack(events []*models.ClickEvent) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, event := range events {
+		seg, ok := s.segments[event.SpoolSegment]
+		if !ok {
+			continue
+		}
+		seg.acked++
+		s.removeIfDoneLocked(seg)
+	}
+}
+
+// removeIfDoneLocked supprime un segment scellé, entièrement transmis et persisté. Un segment de cette
+// exécution dont tous les événements ont été transmis par Append n'attend pas le lecteur. s.mu doit être détenu.
+func (s *Spool) removeIfDoneLocked(seg *segment) {
+	transmitted := seg.consumed || (seg.fresh && seg.undelivered == 0)
+	if !seg.sealed || !transmitted || seg.acked < seg.delivered {
+		return
+	}
+	if err := os.Remove(seg.path); err != nil && !errors.Is(err, os.ErrNotExist) {
+		log.Printf("[Spool] ERREUR : suppression de '%s' impossible : %v", seg.path, err)
+		return
+	}
+	delete(s.segments, seg.seq)
+}
+
+// rotateLocked scelle le segment actif et en ouvre un nouveau. s.mu doit être détenu.
+func (s *Spool) rotateLocked(seq uint64) error {
+	path := filepath.Join(s.dir, fmt.Sprintf(segmentFormat, seq))
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o640)
+	if err != nil {
+		return fmt.Errorf("[Spool] création du segment '%s' impossible: %w", path, err)
+	}
+
+	if previous := s.active; previous != nil {
+		if err := previous.file.Close(); err != nil {
+			log.Printf("[Spool] ERREUR : fermeture de '%s' : %v", previous.path, err)
+		}
+		previous.file = nil
+		previous.sealed = true
+		s.removeIfDoneLocked(previous)
+	}
+
+	s.active = &segment{seq: seq, path: path, file: file, fresh: true}
+	s.segments[seq] = s.active
+
+	// Réveille le lecteur qui attendrait la fin du segment précédent
+	select {
+	case s.notify <- struct{}{}:
+	default:
+	}
+	return nil
+}
+
+// Pending retourne le nombre de segments encore présents sur disque, actif compris.
+func (s *Spool) Pending() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return len(s.segments)
+}
+
+// StopFeeding arrête le lecteur lancé par Feed et attend sa fin. Les événements non transmis
+// restent dans le spool et seront rejoués au prochain démarrage.
+func (s *Spool) StopFeeding() {
+	s.stopOnce.Do(func() { close(s.stop) })
+	<-s.done
+}
+
+// Close refuse les ajouts suivants et ferme le segment actif, supprimé si tous ses événements ont été
+// persistés. Close doit être appelé après StopFeeding et la fin (ou le délai d'arrêt) des workers.
+func (s *Spool) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.closed {
+		return nil
+	}
+	s.closed = true
+
+	active := s.active
+	err := active.file.Close()
+	active.file = nil
+	active.sealed = true
+	s.removeIfDoneLocked(active)
+	return err
+}
@@ -0,0 +1,385 @@
+package spool_test
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/axellelanca/urlshortener/internal/anonymize"
+	"github.com/axellelanca/urlshortener/internal/botdetect"
+	"github.com/axellelanca/urlshortener/internal/config"
+	"github.com/axellelanca/urlshortener/internal/database"
+	"github.com/axellelanca/urlshortener/internal/fingerprint"
+	"github.com/axellelanca/urlshortener/internal/migrations"
+	"github.com/axellelanca/urlshortener/internal/models"
+	"github.com/axellelanca/urlshortener/internal/repository"
+	"github.com/axellelanca/urlshortener/internal/spool"
+	"github.com/axellelanca/urlshortener/internal/workers"
+	"gorm.io/gorm"
+)
+
+func testEvent(i int) *models.ClickEvent {
+	return &models.ClickEvent{
+		EventID:   fmt.Sprintf("event-%02d", i),
+		LinkID:    1,
+		ShortCode: "spooled",
+		Timestamp: time.Now(),
+		UserAgent: "Mozilla/5.0 Firefox/120.0",
+		IPAddress: "203.0.113.7",
+	}
+}
+
+// sendTo retourne une fonction d'envoi non bloquant dans events, comme celle des redirections.
+func sendTo(events chan<- *models.ClickEvent) func(*models.ClickEvent) bool {
+	return func(event *models.ClickEvent) bool {
+		select {
+		case events <- event:
+			return true
+		default:
+			return false
+		}
+	}
+}
+
+// receive lit n événements sur events, en échouant le test s'ils n'arrivent pas à temps.
+func receive(t *testing.T, events <-chan *models.ClickEvent, n int) []*models.ClickEvent {
+	t.Helper()
+	received := make([]*models.ClickEvent, 0, n)
+	timeout := time.After(2 * time.Second)
+	for len(received) < n {
+		select {
+		case event := <-events:
+			received = append(received, event)
+		case <-timeout:
+			t.Fatalf("%d événement(s) reçu(s), attendu %d", len(received), n)
+		}
+	}
+	return received
+}
+
+// waitFor attend que cond soit vraie, au plus deux secondes.
+func waitFor(t *testing.T, what string, cond func() bool) {
+	t.Helper()
+	deadline := time.Now().Add(2 * time.Second)
+	for !cond() {
+		if time.Now().After(deadline) {
+			t.Fatalf("délai dépassé : %s", what)
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
+
+func TestReplayAfterCrash(t *testing.T) {
+	dir := t.TempDir()
+	crashed, err := spool.Open(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < 3; i++ {
+		if _, err := crashed.Append(testEvent(i), nil); err != nil {
+			t.Fatal(err)
+		}
+	}
+	// Crash pendant l'écriture d'un quatrième événement : le processus s'arrête sans Close
+	// et le segment se termine par une ligne incomplète.
+	segments, _ := filepath.Glob(filepath.Join(dir, "clicks-*.spool"))
+	if len(segments) != 1 {
+		t.Fatalf("%d segment(s) sur disque, attendu 1", len(segments))
+	}
+	file, err := os.OpenFile(segments[0], os.O_APPEND|os.O_WRONLY, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	file.WriteString(`{"EventID":"event-03","LinkID":1,"Times`)
+	file.Close()
+
+	restarted, err := spool.Open(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if restarted.Recovered() != 1 {
+		t.Fatalf("%d segment(s) à rejouer, attendu 1", restarted.Recovered())
+	}
+	events := make(chan *models.ClickEvent, 10)
+	go restarted.Feed(events)
+	defer restarted.StopFeeding()
+
+	replayed := receive(t, events, 3)
+	for i, event := range replayed {
+		if event.EventID != testEvent(i).EventID {
+			t.Errorf("événement rejoué %d : %q, attendu %q", i, event.EventID, testEvent(i).EventID)
+		}
+	}
+	select {
+	case event := <-events:
+		t.Fatalf("la ligne incomplète a été rejouée : %+v", event)
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	// Le segment rejoué est supprimé une fois tous ses événements acquittés
+	restarted.Ack(replayed[:2])
+	if restarted.Pending() != 2 {
+		t.Fatalf("%d segment(s) après un acquittement partiel, attendu 2", restarted.Pending())
+	}
+	restarted.Ack(replayed[2:])
+	waitFor(t, "suppression du segment rejoué", func() bool { return restarted.Pending() == 1 })
+}
+
+func TestAppendSendsDirectlyAndFeedsOnlyOverflow(t *testing.T) {
+	s, err := spool.Open(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer s.Close()
+
+	events := make(chan *models.ClickEvent, 2)
+	for i := 0; i < 3; i++ {
+		sent, err := s.Append(testEvent(i), sendTo(events))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if want := i < 2; sent != want {
+			t.Fatalf("événement %d : transmis directement = %v, attendu %v", i, sent, want)
+		}
+	}
+
+	// Le lecteur ne retransmet que l'événement que le channel plein n'a pas accepté
+	go s.Feed(events)
+	defer s.StopFeeding()
+	received := receive(t, events, 3)
+	for i, event := range received {
+		if event.EventID != testEvent(i).EventID {
+			t.Errorf("événement %d : %q, attendu %q", i, event.EventID, testEvent(i).EventID)
+		}
+		if event.SpoolSegment == 0 {
+			t.Errorf("événement %d sans segment du spool : il ne pourrait pas être acquitté", i)
+		}
+	}
+	select {
+	case event := <-events:
+		t.Fatalf("événement transmis deux fois : %+v", event)
+	case <-time.After(50 * time.Millisecond):
+	}
+}
+
+func TestEventsSentDirectlyAreReplayedAfterCrash(t *testing.T) {
+	dir := t.TempDir()
+	crashed, err := spool.Open(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	events := make(chan *models.ClickEvent, 10)
+	for i := 0; i < 3; i++ {
+		if sent, err := crashed.Append(testEvent(i), sendTo(events)); err != nil || !sent {
+			t.Fatalf("Append(%d) = %v, %v", i, sent, err)
+		}
+	}
+	// Le premier événement est persisté et acquitté, puis le processus s'arrête brutalement :
+	// les deux autres, encore dans le channel ou dans un lot en cours, ne doivent pas être perdus.
+	crashed.Ack([]*models.ClickEvent{<-events})
+
+	restarted, err := spool.Open(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	replay := make(chan *models.ClickEvent, 10)
+	go restarted.Feed(replay)
+	defer restarted.StopFeeding()
+	// Le segment est rejoué en entier, l'événement déjà persisté étant écarté en base grâce à EventID
+	replayed := receive(t, replay, 3)
+	for i, event := range replayed {
+		if event.EventID != testEvent(i).EventID {
+			t.Errorf("événement rejoué %d : %q, attendu %q", i, event.EventID, testEvent(i).EventID)
+		}
+	}
+}
+
+func TestSegmentRemovedOnceDirectEventsAreAcked(t *testing.T) {
+	dir := t.TempDir()
+	s, err := spool.Open(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	events := make(chan *models.ClickEvent, 10)
+	var sent []*models.ClickEvent
+	for i := 0; i < 3; i++ {
+		if _, err := s.Append(testEvent(i), sendTo(events)); err != nil {
+			t.Fatal(err)
+		}
+		sent = append(sent, <-events)
+	}
+	s.Ack(sent[:2])
+	if err := s.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if s.Pending() != 1 {
+		t.Fatalf("%d segment(s) avec un événement non acquitté, attendu 1", s.Pending())
+	}
+	s.Ack(sent[2:])
+	if s.Pending() != 0 {
+		t.Fatalf("%d segment(s) après l'acquittement de tous les événements, attendu 0", s.Pending())
+	}
+	if segments, _ := filepath.Glob(filepath.Join(dir, "clicks-*.spool")); len(segments) != 0 {
+		t.Fatalf("segments restés sur disque : %v", segments)
+	}
+}
+
+func TestDrainKeepsEventsLeftInChannel(t *testing.T) {
+	dir := t.TempDir()
+	s, err := spool.Open(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	events := make(chan *models.ClickEvent, 10)
+	// Un événement passé par le spool, et un autre envoyé directement après un échec d'écriture
+	if _, err := s.Append(testEvent(0), sendTo(events)); err != nil {
+		t.Fatal(err)
+	}
+	events <- testEvent(1)
+	close(events)
+
+	if kept := s.Drain(events); kept != 2 {
+		t.Fatalf("Drain a conservé %d événement(s), attendu 2", kept)
+	}
+	if err := s.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	restarted, err := spool.Open(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	replay := make(chan *models.ClickEvent, 10)
+	go restarted.Feed(replay)
+	defer restarted.StopFeeding()
+	replayed := receive(t, replay, 2)
+	if replayed[0].EventID != "event-00" || replayed[1].EventID != "event-01" {
+		t.Fatalf("événements rejoués %q et %q, attendu event-00 et event-01", replayed[0].EventID, replayed[1].EventID)
+	}
+	select {
+	case event := <-replay:
+		t.Fatalf("événement conservé deux fois : %+v", event)
+	case <-time.After(50 * time.Millisecond):
+	}
+}
+
+func TestUnackedEventsAreReplayedAndDeduplicated(t *testing.T) {
+	db := newTestDB(t)
+	clickRepo := repository.NewClickRepository(db)
+	link := &models.Link{ShortCode: "spooled", LongURL: "https://example.com"}
+	if err := db.Create(link).Error; err != nil {
+		t.Fatal(err)
+	}
+	enricher := newTestEnricher(t, db)
+	dir := t.TempDir()
+
+	// Première exécution : les clics sont écrits en base, mais le processus s'arrête avant l'acquittement
+	first, err := spool.Open(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	firstEvents := make(chan *models.ClickEvent, 10)
+	for i := 0; i < 5; i++ {
+		if _, err := first.Append(testEvent(i), sendTo(firstEvents)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	go first.Feed(firstEvents)
+	pool := workers.StartClickWorkers(1, firstEvents, clickRepo, enricher, workers.BatchConfig{Size: 5, FlushInterval: 10 * time.Millisecond}, nil)
+	waitFor(t, "écriture des clics", func() bool { return countClicks(t, db) == 5 })
+	first.StopFeeding()
+	close(firstEvents)
+	pool.Wait(2 * time.Second)
+
+	// Seconde exécution : le segment est rejoué, les doublons sont écartés grâce à EventID
+	second, err := spool.Open(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	secondEvents := make(chan *models.ClickEvent, 10)
+	go second.Feed(secondEvents)
+	pool = workers.StartClickWorkers(1, secondEvents, clickRepo, enricher, workers.BatchConfig{Size: 5, FlushInterval: 10 * time.Millisecond}, second)
+	waitFor(t, "acquittement des événements rejoués", func() bool { return second.Pending() == 1 })
+	second.StopFeeding()
+	close(secondEvents)
+	pool.Wait(2 * time.Second)
+	second.Close()
+
+	if n := countClicks(t, db); n != 5 {
+		t.Fatalf("%d clic(s) en base après le rejeu, attendu 5", n)
+	}
+}
+
+// BenchmarkAppend mesure l'écriture d'un événement dans le spool, que fait chaque redirection
+// lorsque le spool est activé.
+func BenchmarkAppend(b *testing.B) {
+	s, err := spool.Open(b.TempDir())
+	if err != nil {
+		b.Fatal(err)
+	}
+	defer s.Close()
+	event := testEvent(0)
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, err := s.Append(event, nil); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+// BenchmarkChannelSend mesure, pour comparaison, l'envoi non bloquant dans le channel des workers.
+func BenchmarkChannelSend(b *testing.B) {
+	events := make(chan *models.ClickEvent, 1)
+	event := testEvent(0)
+
+	for i := 0; i < b.N; i++ {
+		select {
+		case events <- event:
+		default:
+		}
+		<-events
+	}
+}
+
+func newTestDB(t *testing.T) *gorm.DB {
+	t.Helper()
+	db, err := database.Open(config.DatabaseConfig{Name: filepath.Join(t.TempDir(), "test.db")})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := migrations.NewMigrator(db).Up(); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		if sqlDB, err := db.DB(); err == nil {
+			sqlDB.Close()
+		}
+	})
+	return db
+}
+
+func newTestEnricher(t *testing.T, db *gorm.DB) *workers.ClickEnricher {
+	t.Helper()
+	detector, err := botdetect.NewDetector(true, nil, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	hasher := fingerprint.NewHasher(repository.NewVisitorSaltRepository(db))
+	anonymizer, err := anonymize.NewAnonymizer(anonymize.ModeTruncate, hasher)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return workers.NewClickEnricher(detector, hasher, nil, anonymizer)
+}
+
+func countClicks(t *testing.T, db *gorm.DB) int64 {
+	t.Helper()
+	var count int64
+	if err := db.Model(&models.Click{}).Count(&count).Error; err != nil {
+		t.Fatal(err)
+	}
+	return count
+}
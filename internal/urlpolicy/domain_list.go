@@ -0,0 +1,145 @@
+package urlpolicy
+
+import (
+	"bufio"
+	"fmt"
+	"log"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+// DomainList est une liste de domaines lue depuis un fichier texte : un domaine par ligne,
+// lignes vides et commentaires (#) ignorés. Un domaine couvre aussi ses sous-domaines.
+// Start surveille le fichier et recharge la liste lorsqu'il est modifié.
+type DomainList struct {
+	path     string
+	interval time.Duration
+
+	mu      sync.RWMutex
+	domains map[string]struct{}
+	modTime time.Time
+	size    int64
+
+	stop     chan struct{}
+	done     chan struct{}
+	stopOnce sync.Once
+}
+
+// LoadDomainList lit la liste du fichier path, rechargée toutes les interval par Start si le fichier change.
+func LoadDomainList(path string, interval time.Duration) (*DomainList, error) {
+	l := &DomainList{
+		path:     path,
+		interval: interval,
+		stop:     make(chan struct{}),
+		done:     make(chan struct{}),
+	}
+	if err := l.reload(); err != nil {
+		return nil, err
+	}
+	return l, nil
+}
+
+// Match indique si host est un domaine de la liste ou l'un de leurs sous-domaines.
+func (l *DomainList) Match(host string) bool {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+
+	for {
+		if _, ok := l.domains[host]; ok {
+			return true
+		}
+		dot := strings.IndexByte(host, '.')
+		if dot < 0 {
+			return false
+		}
+		host = host[dot+1:]
+	}
+}
+
+// Len retourne le nombre de domaines de la liste.
+func (l *DomainList) Len() int {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+	return len(l.domains)
+}
+
+// Start vérifie périodiquement si le fichier a changé et recharge la liste. En cas d'erreur de lecture,
+// la liste précédente est conservée. Cette fonction est conçue pour être lancée dans une goroutine séparée.
+func (l *DomainList) Start() {
+	ticker := time.NewTicker(l.interval)
+	defer ticker.Stop()
+	defer close(l.done)
+
+	for {
+		select {
+		case <-ticker.C:
+			changed, err := l.changed()
+			if err != nil {
+				log.Printf("[URLPolicy] ERREUR : liste '%s' illisible, version précédente conservée : %v", l.path, err)
+				continue
+			}
+			if !changed {
+				continue
+			}
+			if err := l.reload(); err != nil {
+				log.Printf("[URLPolicy] ERREUR : rechargement de '%s' impossible, version précédente conservée : %v", l.path, err)
+				continue
+			}
+			log.Printf("[URLPolicy] Liste '%s' rechargée (%d domaine(s)).", l.path, l.Len())
+		case <-l.stop:
+			return
+		}
+	}
+}
+
+// Stop arrête la surveillance du fichier. Stop ne doit être appelé qu'après Start.
+func (l *DomainList) Stop() {
+	l.stopOnce.Do(func() { close(l.stop) })
+	<-l.done
+}
+
+// changed indique si le fichier a été modifié depuis le dernier chargement.
+func (l *DomainList) changed() (bool, error) {
+	info, err := os.Stat(l.path)
+	if err != nil {
+		return false, err
+	}
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+	return !info.ModTime().Equal(l.modTime) || info.Size() != l.size, nil
+}
+
+// reload lit le fichier et remplace la liste.
+func (l *DomainList) reload() error {
+	file, err := os.Open(l.path)
+	if err != nil {
+		return fmt.Errorf("[URLPolicy] ouverture de la liste '%s' impossible: %w", l.path, err)
+	}
+	defer file.Close()
+	info, err := file.Stat()
+	if err != nil {
+		return fmt.Errorf("[URLPolicy] lecture de la liste '%s' impossible: %w", l.path, err)
+	}
+
+	domains := make(map[string]struct{})
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		line, _, _ := strings.Cut(scanner.Text(), "#")
+		domain := strings.TrimSuffix(strings.TrimPrefix(strings.ToLower(strings.TrimSpace(line)), "*."), ".")
+		if domain != "" {
+			domains[domain] = struct{}{}
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return fmt.Errorf("[URLPolicy] lecture de la liste '%s' impossible: %w", l.path, err)
+	}
+
+	l.mu.Lock()
+	l.domains = domains
+	l.modTime = info.ModTime()
+	l.size = info.Size()
+	l.mu.Unlock()
+	return nil
+}
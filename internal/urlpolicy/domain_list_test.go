@@ -0,0 +1,95 @@
+package urlpolicy
+
+import (
+	"os"
+	"testing"
+	"time"
+)
+
+// waitForMatch attend que list.Match(host) retourne want.
+func waitForMatch(t *testing.T, list *DomainList, host string, want bool) {
+	t.Helper()
+	deadline := time.Now().Add(2 * time.Second)
+	for list.Match(host) != want {
+		if time.Now().After(deadline) {
+			t.Fatalf("Match(%q) vaut toujours %v", host, !want)
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
+
+func TestDomainListMatch(t *testing.T) {
+	list, err := LoadDomainList(writeList(t, "example.com\n  *.Ads.Example.  \n# commentaire.com\nfoo.bar # fin de ligne\n"), time.Minute)
+	if err != nil {
+		t.Fatalf("LoadDomainList: %v", err)
+	}
+	if list.Len() != 3 {
+		t.Fatalf("Len() = %d, attendu 3", list.Len())
+	}
+
+	tests := map[string]bool{
+		"example.com":        true,
+		"www.example.com":    true,
+		"deep.a.example.com": true,
+		"ads.example":        true,
+		"x.ads.example":      true,
+		"foo.bar":            true,
+		"myexample.com":      false,
+		"example.com.evil":   false,
+		"com":                false,
+		"commentaire.com":    false,
+		"":                   false,
+	}
+	for host, want := range tests {
+		if got := list.Match(host); got != want {
+			t.Errorf("Match(%q) = %v, attendu %v", host, got, want)
+		}
+	}
+}
+
+func TestDomainListReloadsWhenModified(t *testing.T) {
+	path := writeList(t, "aaa.com\n")
+	list, err := LoadDomainList(path, 10*time.Millisecond)
+	if err != nil {
+		t.Fatalf("LoadDomainList: %v", err)
+	}
+	go list.Start()
+	defer list.Stop()
+
+	// Même taille : seule la date de modification signale le changement
+	if err := os.WriteFile(path, []byte("bbb.com\n"), 0o644); err != nil {
+		t.Fatalf("écriture de la liste: %v", err)
+	}
+	modTime := time.Now().Add(time.Hour)
+	if err := os.Chtimes(path, modTime, modTime); err != nil {
+		t.Fatalf("Chtimes: %v", err)
+	}
+	waitForMatch(t, list, "bbb.com", true)
+	if list.Match("aaa.com") {
+		t.Fatal("l'ancien domaine devrait avoir disparu de la liste")
+	}
+
+	// Fichier supprimé : la liste précédente est conservée
+	if err := os.Remove(path); err != nil {
+		t.Fatalf("suppression de la liste: %v", err)
+	}
+	time.Sleep(50 * time.Millisecond)
+	if !list.Match("bbb.com") {
+		t.Fatal("la liste précédente devrait être conservée si le fichier est illisible")
+	}
+
+	// Fichier recréé
+	if err := os.WriteFile(path, []byte("ccc.com\nddd.com\n"), 0o644); err != nil {
+		t.Fatalf("écriture de la liste: %v", err)
+	}
+	waitForMatch(t, list, "sub.ddd.com", true)
+	if list.Len() != 2 {
+		t.Fatalf("Len() = %d, attendu 2", list.Len())
+	}
+}
+
+func TestLoadDomainListMissingFile(t *testing.T) {
+	if _, err := LoadDomainList(t.TempDir()+"/absent.txt", time.Minute); err == nil {
+		t.Fatal("LoadDomainList devrait échouer si le fichier n'existe pas")
+	}
+}
@@ -0,0 +1,323 @@
+package urlpolicy
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/http"
+	"net/netip"
+	"net/url"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/axellelanca/urlshortener/internal/config"
+)
+
+// Codes des refus, retournés aux clients de l'API dans le champ "code".
+const (
+	CodeInvalidURL       = "invalid_url"
+	CodeURLTooLong       = "url_too_long"
+	CodeSchemeNotAllowed = "scheme_not_allowed"
+	CodeDomainBlocked    = "domain_blocked"
+	CodeDomainNotAllowed = "domain_not_allowed"
+	CodePrivateAddress   = "private_address"
+	CodeUnresolvableHost = "unresolvable_host"
+)
+
+// Valeurs appliquées lorsque les options sont absentes.
+const (
+	defaultMaxLength      = 2048
+	defaultResolveTimeout = 2 * time.Second
+)
+
+var defaultSchemes = []string{"http", "https"}
+
+// Violation est l'erreur retournée lorsqu'une URL enfreint la politique.
+type Violation struct {
+	Code   string // Code stable, destiné aux clients
+	Reason string // Message lisible
+}
+
+func (v *Violation) Error() string {
+	return v.Reason
+}
+
+func reject(code, format string, args ...any) *Violation {
+	return &Violation{Code: code, Reason: fmt.Sprintf(format, args...)}
+}
+
+// Options configure la politique.
+type Options struct {
+	AllowedSchemes  []string      // Schémas acceptés (http et https si vide)
+	MaxLength       int           // Longueur maximale de l'URL en octets
+	BlockPrivateIPs bool          // Refuse les hôtes locaux, privés, de bouclage ou de lien local
+	ResolveHosts    bool          // Avec BlockPrivateIPs, résout aussi les noms d'hôte pour vérifier leurs adresses
+	ResolveFailOpen bool          // Avec ResolveHosts, accepte les noms dont la résolution échoue au lieu de les refuser
+	Blocklist       *DomainList   // Domaines refusés, sous-domaines compris (optionnel)
+	Allowlist       *DomainList   // Si non vide, seuls ces domaines et leurs sous-domaines sont acceptés (optionnel)
+	Resolver        *net.Resolver // Résolveur DNS (net.DefaultResolver si nil)
+}
+
+// Policy vérifie les URLs de destination avant leur enregistrement :
+// longueur, schéma, listes de domaines et adresses internes.
+type Policy struct {
+	opts Options
+}
+
+// NewPolicy crée une Policy.
+func NewPolicy(opts Options) *Policy {
+	schemes := defaultSchemes
+	if len(opts.AllowedSchemes) > 0 {
+		schemes = make([]string, len(opts.AllowedSchemes))
+		for i, scheme := range opts.AllowedSchemes {
+			schemes[i] = strings.ToLower(scheme)
+		}
+	}
+	opts.AllowedSchemes = schemes
+	if opts.MaxLength <= 0 {
+		opts.MaxLength = defaultMaxLength
+	}
+	if opts.Resolver == nil {
+		opts.Resolver = net.DefaultResolver
+	}
+	return &Policy{opts: opts}
+}
+
+// Check retourne une *Violation si rawURL ne respecte pas la politique.
+func (p *Policy) Check(rawURL string) error {
+	if len(rawURL) > p.opts.MaxLength {
+		return reject(CodeURLTooLong, "l'URL dépasse %d caractères", p.opts.MaxLength)
+	}
+
+	u, err := url.Parse(rawURL)
+	if err != nil || u.Scheme == "" {
+		return reject(CodeInvalidURL, "l'URL est mal formée")
+	}
+	scheme := strings.ToLower(u.Scheme)
+	if !slices.Contains(p.opts.AllowedSchemes, scheme) {
+		return reject(CodeSchemeNotAllowed, "le schéma '%s' n'est pas autorisé (autorisés : %s)", scheme, strings.Join(p.opts.AllowedSchemes, ", "))
+	}
+	if u.User != nil {
+		// "https://banque.fr@pirate.example" masque la véritable destination
+		return reject(CodeInvalidURL, "les identifiants dans l'URL ne sont pas autorisés")
+	}
+
+	host := strings.TrimSuffix(strings.ToLower(u.Hostname()), ".")
+	if host == "" {
+		return reject(CodeInvalidURL, "l'URL doit contenir un nom d'hôte")
+	}
+
+	if p.opts.Blocklist != nil && p.opts.Blocklist.Match(host) {
+		return reject(CodeDomainBlocked, "le domaine '%s' est bloqué", host)
+	}
+	if p.opts.Allowlist != nil && p.opts.Allowlist.Len() > 0 && !p.opts.Allowlist.Match(host) {
+		return reject(CodeDomainNotAllowed, "le domaine '%s' n'est pas autorisé", host)
+	}
+
+	if p.opts.BlockPrivateIPs {
+		if err := p.checkAddress(host); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// checkAddress refuse les hôtes qui désignent le réseau interne, écrits comme adresse IP
+// (y compris sous les formes décimale, octale ou hexadécimale acceptées par les navigateurs)
+// ou comme nom local. Avec ResolveHosts, les adresses des autres noms sont aussi vérifiées.
+func (p *Policy) checkAddress(host string) error {
+	if host == "localhost" || strings.HasSuffix(host, ".localhost") ||
+		strings.HasSuffix(host, ".local") || strings.HasSuffix(host, ".internal") {
+		return reject(CodePrivateAddress, "l'hôte '%s' désigne le réseau local", host)
+	}
+
+	if addr, ok := parseHostIP(host); ok {
+		if isInternal(addr) {
+			return reject(CodePrivateAddress, "l'adresse '%s' est une adresse interne", host)
+		}
+		return nil
+	}
+
+	if !p.opts.ResolveHosts {
+		return nil
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), defaultResolveTimeout)
+	defer cancel()
+	addrs, err := p.opts.Resolver.LookupNetIP(ctx, "ip", host)
+	if err != nil {
+		// Un délai dépassé ou un SERVFAIL ne prouve pas que le nom est public : il est refusé,
+		// sauf si l'exploitant a choisi d'accepter les noms non résolus
+		if p.opts.ResolveFailOpen {
+			return nil
+		}
+		return reject(CodeUnresolvableHost, "l'hôte '%s' n'a pas pu être résolu pour vérifier ses adresses", host)
+	}
+	for _, addr := range addrs {
+		if isInternal(addr) {
+			return reject(CodePrivateAddress, "l'hôte '%s' pointe vers une adresse interne", host)
+		}
+	}
+	return nil
+}
+
+// HTTPClient retourne un client HTTP pour contacter les URLs de destination (moniteur). Avec BlockPrivateIPs,
+// l'adresse effectivement contactée est vérifiée à chaque connexion, redirections comprises : un nom dont
+// le DNS pointe vers une adresse interne après la création du lien (DNS rebinding) est ainsi refusé.
+// Le proxy de l'environnement n'est pas utilisé, il masquerait l'adresse réelle de la destination.
+func (p *Policy) HTTPClient(timeout time.Duration) *http.Client {
+	dialer := &net.Dialer{Timeout: timeout}
+	if p.opts.BlockPrivateIPs {
+		dialer.Control = dialControl
+	}
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.Proxy = nil
+	transport.DialContext = dialer.DialContext
+	return &http.Client{Timeout: timeout, Transport: transport}
+}
+
+// dialControl refuse une connexion vers une adresse interne. address est l'adresse IP déjà résolue.
+func dialControl(network, address string, _ syscall.RawConn) error {
+	addrPort, err := netip.ParseAddrPort(address)
+	if err != nil {
+		return reject(CodeInvalidURL, "adresse de connexion '%s' inattendue", address)
+	}
+	if isInternal(addrPort.Addr()) {
+		return reject(CodePrivateAddress, "connexion à l'adresse interne '%s' refusée", addrPort.Addr())
+	}
+	return nil
+}
+
+// isInternal indique si une adresse n'est pas joignable publiquement.
+func isInternal(addr netip.Addr) bool {
+	addr = addr.Unmap()
+	return addr.IsLoopback() || addr.IsPrivate() || addr.IsUnspecified() ||
+		addr.IsLinkLocalUnicast() || addr.IsLinkLocalMulticast() || addr.IsInterfaceLocalMulticast() ||
+		addr.IsMulticast() || thisNetwork.Contains(addr) || cgnat.Contains(addr)
+}
+
+var (
+	// thisNetwork désigne la machine locale sur la plupart des systèmes (RFC 1122).
+	thisNetwork = netip.MustParsePrefix("0.0.0.0/8")
+	// cgnat est la plage partagée des opérateurs (RFC 6598), non routable sur Internet.
+	cgnat = netip.MustParsePrefix("100.64.0.0/10")
+)
+
+// parseHostIP interprète un hôte comme adresse IP. En plus des notations standard, les formes
+// IPv4 héritées de inet_aton (ex: "2130706433", "0x7f.1", "0177.0.0.1") sont reconnues,
+// car les navigateurs les résolvent vers l'adresse correspondante.
+func parseHostIP(host string) (netip.Addr, bool) {
+	if addr, err := netip.ParseAddr(strings.Trim(host, "[]")); err == nil {
+		return addr, true
+	}
+
+	parts := strings.Split(host, ".")
+	if len(parts) > 4 {
+		return netip.Addr{}, false
+	}
+	values := make([]uint64, len(parts))
+	for i, part := range parts {
+		value, ok := parseInetPart(part)
+		if !ok {
+			return netip.Addr{}, false
+		}
+		values[i] = value
+	}
+
+	// Les premières parties occupent un octet chacune, la dernière les octets restants
+	last := len(values) - 1
+	if values[last] >= 1<<(8*(4-last)) {
+		return netip.Addr{}, false
+	}
+	ip := values[last]
+	for i := 0; i < last; i++ {
+		if values[i] > 0xff {
+			return netip.Addr{}, false
+		}
+		ip |= values[i] << (8 * (3 - i))
+	}
+	return netip.AddrFrom4([4]byte{byte(ip >> 24), byte(ip >> 16), byte(ip >> 8), byte(ip)}), true
+}
+
+// parseInetPart lit une partie d'adresse IPv4 comme inet_aton : décimale, hexadécimale (0x) ou octale (0).
+// Contrairement à strconv en base 0, les formes 0b, 0o et 1_000 n'en font pas une adresse.
+func parseInetPart(part string) (uint64, bool) {
+	base, digits := 10, part
+	switch {
+	case len(part) > 2 && (part[:2] == "0x" || part[:2] == "0X"):
+		base, digits = 16, part[2:]
+	case len(part) > 1 && part[0] == '0':
+		base, digits = 8, part[1:]
+	}
+	value, err := strconv.ParseUint(digits, base, 32)
+	return value, err == nil
+}
+
+// defaultReloadInterval est l'intervalle de surveillance des listes de domaines par défaut.
+const defaultReloadInterval = 30 * time.Second
+
+// FromConfig crée la Policy décrite par la section 'url_policy' et charge ses listes de domaines.
+func FromConfig(cfg config.URLPolicyConfig) (*Policy, error) {
+	interval := time.Duration(cfg.ReloadIntervalSeconds) * time.Second
+	if interval <= 0 {
+		interval = defaultReloadInterval
+	}
+
+	opts := Options{
+		AllowedSchemes:  cfg.AllowedSchemes,
+		MaxLength:       cfg.MaxLength,
+		BlockPrivateIPs: cfg.BlockPrivateIPs,
+		ResolveHosts:    cfg.ResolveHosts,
+		ResolveFailOpen: cfg.ResolveFailOpen,
+	}
+	if cfg.BlocklistFile != "" {
+		list, err := LoadDomainList(cfg.BlocklistFile, interval)
+		if err != nil {
+			return nil, err
+		}
+		opts.Blocklist = list
+	}
+	if cfg.AllowlistFile != "" {
+		list, err := LoadDomainList(cfg.AllowlistFile, interval)
+		if err != nil {
+			return nil, err
+		}
+		opts.Allowlist = list
+	}
+	return NewPolicy(opts), nil
+}
+
+// Start surveille les fichiers des listes de domaines et les recharge lorsqu'ils changent,
+// jusqu'à l'appel de Stop. Cette fonction est conçue pour être lancée dans une goroutine séparée.
+func (p *Policy) Start() {
+	var wg sync.WaitGroup
+	for _, list := range p.lists() {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			list.Start()
+		}()
+	}
+	wg.Wait()
+}
+
+// Stop arrête la surveillance des listes de domaines. Stop ne doit être appelé qu'après Start.
+func (p *Policy) Stop() {
+	for _, list := range p.lists() {
+		list.Stop()
+	}
+}
+
+// lists retourne les listes de domaines configurées.
+func (p *Policy) lists() []*DomainList {
+	var lists []*DomainList
+	for _, list := range []*DomainList{p.opts.Blocklist, p.opts.Allowlist} {
+		if list != nil {
+			lists = append(lists, list)
+		}
+	}
+	return lists
+}
@@ -0,0 +1,260 @@
+package urlpolicy
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/netip"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestParseHostIP(t *testing.T) {
+	tests := []struct {
+		host string
+		want string // Adresse attendue, vide si host n'est pas une adresse IP
+	}{
+		{"127.0.0.1", "127.0.0.1"},
+		{"0177.0.0.1", "127.0.0.1"},
+		{"0x7f.0.0.1", "127.0.0.1"},
+		{"0X7F.0.0.1", "127.0.0.1"},
+		{"0x7f000001", "127.0.0.1"},
+		{"017700000001", "127.0.0.1"},
+		{"2130706433", "127.0.0.1"},
+		{"0x7f.1", "127.0.0.1"},
+		{"127.1", "127.0.0.1"},
+		{"10.0.258", "10.0.1.2"},
+		{"0xa9.0xfe.0xa9.0xfe", "169.254.169.254"},
+		{"0", "0.0.0.0"},
+		{"[::1]", "::1"},
+		{"::ffff:127.0.0.1", "::ffff:127.0.0.1"},
+		{"example.com", ""},
+		{"1.2.3.4.5", ""},
+		{"256.0.0.1", ""},
+		{"1.2.3.256", ""},
+		{"1.2.65536", ""},
+		{"4294967296", ""},
+		{"0x", ""},
+		{"0x1g", ""},
+		{"08.0.0.1", ""},
+		{"0b1111111.0.0.1", ""},
+		{"0o177.0.0.1", ""},
+		{"1_27.0.0.1", ""},
+		{"127..1", ""},
+		{"", ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.host, func(t *testing.T) {
+			addr, ok := parseHostIP(tt.host)
+			if tt.want == "" {
+				if ok {
+					t.Fatalf("parseHostIP(%q) = %v, ne devrait pas être une adresse", tt.host, addr)
+				}
+				return
+			}
+			if !ok || addr != netip.MustParseAddr(tt.want) {
+				t.Fatalf("parseHostIP(%q) = %v, %v ; attendu %s", tt.host, addr, ok, tt.want)
+			}
+		})
+	}
+}
+
+func TestIsInternal(t *testing.T) {
+	tests := []struct {
+		addr string
+		want bool
+	}{
+		{"127.0.0.1", true},
+		{"127.8.9.10", true},
+		{"::1", true},
+		{"::ffff:127.0.0.1", true},
+		{"::ffff:10.0.0.1", true},
+		{"::ffff:169.254.169.254", true},
+		{"10.0.0.1", true},
+		{"172.16.0.1", true},
+		{"192.168.1.1", true},
+		{"169.254.169.254", true},
+		{"fe80::1", true},
+		{"fc00::1", true},
+		{"fd12:3456::1", true},
+		{"100.64.0.1", true},
+		{"0.0.0.0", true},
+		{"0.1.2.3", true},
+		{"::", true},
+		{"224.0.0.1", true},
+		{"8.8.8.8", false},
+		{"172.32.0.1", false},
+		{"100.128.0.1", false},
+		{"::ffff:8.8.8.8", false},
+		{"2001:4860:4860::8888", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.addr, func(t *testing.T) {
+			if got := isInternal(netip.MustParseAddr(tt.addr)); got != tt.want {
+				t.Fatalf("isInternal(%s) = %v, attendu %v", tt.addr, got, tt.want)
+			}
+		})
+	}
+}
+
+// violationCode retourne le code de la *Violation contenue dans err, ou "" si err est nil.
+func violationCode(t *testing.T, err error) string {
+	t.Helper()
+	if err == nil {
+		return ""
+	}
+	var violation *Violation
+	if !errors.As(err, &violation) {
+		t.Fatalf("erreur %v (%T), attendu une *Violation", err, err)
+	}
+	return violation.Code
+}
+
+func TestCheckRejectsInternalHosts(t *testing.T) {
+	policy := NewPolicy(Options{BlockPrivateIPs: true})
+
+	tests := []struct {
+		url  string
+		want string
+	}{
+		{"https://example.com/page", ""},
+		{"http://93.184.216.34/", ""},
+		{"http://localhost:8080/", CodePrivateAddress},
+		{"http://api.localhost/", CodePrivateAddress},
+		{"http://printer.local/", CodePrivateAddress},
+		{"http://metadata.google.internal/", CodePrivateAddress},
+		{"http://127.0.0.1/", CodePrivateAddress},
+		{"http://2130706433/", CodePrivateAddress},
+		{"http://0x7f.1/", CodePrivateAddress},
+		{"http://0177.0.0.1/", CodePrivateAddress},
+		{"http://169.254.169.254/latest/meta-data/", CodePrivateAddress},
+		{"http://[::1]/", CodePrivateAddress},
+		{"http://[::ffff:127.0.0.1]/", CodePrivateAddress},
+		{"http://[fd00::1]/", CodePrivateAddress},
+		{"https://banque.fr@pirate.example/", CodeInvalidURL},
+		{"javascript:alert(1)", CodeSchemeNotAllowed},
+		{"not a url", CodeInvalidURL},
+	}
+	for _, tt := range tests {
+		t.Run(tt.url, func(t *testing.T) {
+			if got := violationCode(t, policy.Check(tt.url)); got != tt.want {
+				t.Fatalf("Check(%q) = %q, attendu %q", tt.url, got, tt.want)
+			}
+		})
+	}
+
+	// Sans BlockPrivateIPs, les adresses internes sont acceptées
+	if err := NewPolicy(Options{}).Check("http://127.0.0.1/"); err != nil {
+		t.Fatalf("Check sans BlockPrivateIPs: %v", err)
+	}
+}
+
+// failingResolver retourne un résolveur dont toutes les requêtes DNS échouent.
+func failingResolver() *net.Resolver {
+	return &net.Resolver{
+		PreferGo: true,
+		Dial: func(ctx context.Context, network, address string) (net.Conn, error) {
+			return nil, errors.New("serveur DNS injoignable")
+		},
+	}
+}
+
+func TestCheckFailsClosedWhenResolutionFails(t *testing.T) {
+	policy := NewPolicy(Options{BlockPrivateIPs: true, ResolveHosts: true, Resolver: failingResolver()})
+	if got := violationCode(t, policy.Check("https://example.com/")); got != CodeUnresolvableHost {
+		t.Fatalf("code %q, attendu %q", got, CodeUnresolvableHost)
+	}
+	// Une adresse IP littérale ne nécessite pas de résolution
+	if err := policy.Check("http://93.184.216.34/"); err != nil {
+		t.Fatalf("Check d'une adresse publique: %v", err)
+	}
+
+	failOpen := NewPolicy(Options{BlockPrivateIPs: true, ResolveHosts: true, ResolveFailOpen: true, Resolver: failingResolver()})
+	if err := failOpen.Check("https://example.com/"); err != nil {
+		t.Fatalf("Check avec ResolveFailOpen: %v", err)
+	}
+}
+
+func TestHTTPClientRefusesInternalAddresses(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer server.Close()
+
+	// Le nom a été accepté à la création du lien, mais la connexion aboutit sur une adresse interne
+	client := NewPolicy(Options{BlockPrivateIPs: true}).HTTPClient(time.Second)
+	resp, err := client.Head(server.URL)
+	if err == nil {
+		resp.Body.Close()
+		t.Fatal("la connexion à une adresse interne devrait être refusée")
+	}
+	if got := violationCode(t, err); got != CodePrivateAddress {
+		t.Fatalf("code %q, attendu %q", got, CodePrivateAddress)
+	}
+
+	resp, err = NewPolicy(Options{}).HTTPClient(time.Second).Head(server.URL)
+	if err != nil {
+		t.Fatalf("Head sans BlockPrivateIPs: %v", err)
+	}
+	resp.Body.Close()
+}
+
+// writeList écrit une liste de domaines dans un fichier temporaire et retourne son chemin.
+func writeList(t *testing.T, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "list.txt")
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatalf("écriture de la liste: %v", err)
+	}
+	return path
+}
+
+func TestCheckDomainLists(t *testing.T) {
+	blocklist, err := LoadDomainList(writeList(t, "# Domaines refusés\nevil.com\n*.ads.example  # avec joker\n\nTracker.NET.\n"), time.Minute)
+	if err != nil {
+		t.Fatalf("LoadDomainList: %v", err)
+	}
+	allowlist, err := LoadDomainList(writeList(t, "example.com\nads.example\ntracker.net\nevil.com\nfoo.org\n"), time.Minute)
+	if err != nil {
+		t.Fatalf("LoadDomainList: %v", err)
+	}
+	policy := NewPolicy(Options{Blocklist: blocklist, Allowlist: allowlist})
+
+	tests := []struct {
+		url  string
+		want string
+	}{
+		{"https://example.com/", ""},
+		{"https://www.example.com/", ""},
+		{"https://a.b.example.com/", ""},
+		{"https://foo.org/", ""},
+		{"https://evil.com/", CodeDomainBlocked},
+		{"https://EVIL.com./", CodeDomainBlocked},
+		{"https://cdn.evil.com/", CodeDomainBlocked},
+		{"https://ads.example/", CodeDomainBlocked},
+		{"https://x.ads.example/", CodeDomainBlocked},
+		{"https://tracker.net/", CodeDomainBlocked},
+		{"https://notevil.com/", CodeDomainNotAllowed},
+		{"https://example.com.attacker.net/", CodeDomainNotAllowed},
+		{"https://myexample.com/", CodeDomainNotAllowed},
+		{"https://org/", CodeDomainNotAllowed},
+	}
+	for _, tt := range tests {
+		t.Run(tt.url, func(t *testing.T) {
+			if got := violationCode(t, policy.Check(tt.url)); got != tt.want {
+				t.Fatalf("Check(%q) = %q, attendu %q", tt.url, got, tt.want)
+			}
+		})
+	}
+
+	// Une liste d'autorisation vide n'impose aucune restriction
+	empty, err := LoadDomainList(writeList(t, "# aucun domaine\n"), time.Minute)
+	if err != nil {
+		t.Fatalf("LoadDomainList: %v", err)
+	}
+	if err := NewPolicy(Options{Allowlist: empty}).Check("https://anything.example/"); err != nil {
+		t.Fatalf("Check avec une liste d'autorisation vide: %v", err)
+	}
+}
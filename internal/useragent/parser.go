@@ -0,0 +1,165 @@
+package useragent
+
+import (
+	"regexp"
+	"strings"
+)
+
+// Classes d'appareils reconnues.
+const (
+	DeviceDesktop = "desktop"
+	DeviceMobile  = "mobile"
+	DeviceTablet  = "tablet"
+	DeviceBot     = "bot"
+	Unknown       = "unknown"
+)
+
+// Info contient les informations structurées extraites d'une chaîne User-Agent.
+type Info struct {
+	BrowserFamily  string // ex: "Chrome", "Firefox", "Googlebot"
+	BrowserVersion string // Version majeure.mineure quand elle est disponible, ex: "124.0"
+	OS             string // ex: "Windows 10", "iOS 17.4", "Android 14"
+	DeviceType     string // desktop, mobile, tablet ou bot
+}
+
+// rule associe une expression régulière à un nom. Le premier groupe capturé, s'il existe, donne la version.
+type rule struct {
+	name    string
+	pattern *regexp.Regexp
+}
+
+// botRules reconnaît les robots, crawlers et clients HTTP automatisés.
+// L'ordre compte : les règles les plus spécifiques sont évaluées en premier.
+var botRules = []rule{
+	{"Googlebot", regexp.MustCompile(`Googlebot/(\d+(?:\.\d+)?)`)},
+	{"Bingbot", regexp.MustCompile(`bingbot/(\d+(?:\.\d+)?)`)},
+	{"Slackbot", regexp.MustCompile(`Slackbot(?:-LinkExpanding)?(?: (\d+(?:\.\d+)?))?`)},
+	{"Twitterbot", regexp.MustCompile(`Twitterbot/(\d+(?:\.\d+)?)`)},
+	{"Facebook", regexp.MustCompile(`facebookexternalhit/(\d+(?:\.\d+)?)`)},
+	{"LinkedInBot", regexp.MustCompile(`LinkedInBot/(\d+(?:\.\d+)?)`)},
+	{"Discordbot", regexp.MustCompile(`Discordbot/(\d+(?:\.\d+)?)`)},
+	{"TelegramBot", regexp.MustCompile(`TelegramBot`)},
+	{"WhatsApp", regexp.MustCompile(`WhatsApp/(\d+(?:\.\d+)?)`)},
+	{"curl", regexp.MustCompile(`^curl/(\d+(?:\.\d+)?)`)},
+	{"Wget", regexp.MustCompile(`^Wget/(\d+(?:\.\d+)?)`)},
+	{"python-requests", regexp.MustCompile(`python-requests/(\d+(?:\.\d+)?)`)},
+	{"Go-http-client", regexp.MustCompile(`Go-http-client/(\d+(?:\.\d+)?)`)},
+	{"Bot", genericBotPattern},
+}
+
+// genericBotPattern reconnaît les robots sans règle dédiée. Les jetons sont délimités pour ne pas
+// signaler de navigateurs : "bot", "crawler" ou "spider" en fin de nom de produit suivi d'une version
+// ou d'un ";" (AhrefsBot/7.0, Baiduspider/2.0, PetalBot;) ou "bot" isolé (Better Uptime Bot), mais pas
+// un modèle de téléphone comme "CUBOT X30" ; une URL de contact "+http://" propre aux crawlers ;
+// les navigateurs sans interface (HeadlessChrome) et quelques services d'aperçu connus.
+var genericBotPattern = regexp.MustCompile(`(?i)(?:bot|crawler|spider)(?:/|;)|(?:^|[\W_])bot(?:[\W_]|$)|\bslurp\b|\bheadless|\+https?://|Google Web Preview|SkypeUriPreview`)
+
+// browserRules reconnaît les navigateurs. Plusieurs navigateurs reprennent le jeton "Chrome" ou "Safari"
+// dans leur User-Agent : Edge, Opera et Samsung Internet doivent donc être testés avant Chrome, et Chrome avant Safari.
+var browserRules = []rule{
+	{"Edge", regexp.MustCompile(`Edg(?:e|A|iOS)?/(\d+(?:\.\d+)?)`)},
+	{"Opera", regexp.MustCompile(`(?:OPR|Opera)/(\d+(?:\.\d+)?)`)},
+	{"Samsung Internet", regexp.MustCompile(`SamsungBrowser/(\d+(?:\.\d+)?)`)},
+	{"Firefox", regexp.MustCompile(`(?:Firefox|FxiOS)/(\d+(?:\.\d+)?)`)},
+	{"Chrome", regexp.MustCompile(`(?:Chrome|CriOS)/(\d+(?:\.\d+)?)`)},
+	{"Safari", regexp.MustCompile(`Version/(\d+(?:\.\d+)?).*Safari/`)},
+	{"Internet Explorer", regexp.MustCompile(`(?:MSIE |Trident/.*rv:)(\d+(?:\.\d+)?)`)},
+}
+
+// osRules reconnaît les systèmes d'exploitation. iOS et Android doivent précéder macOS et Linux.
+var osRules = []rule{
+	{"iOS", regexp.MustCompile(`(?:iPhone|iPad|iPod).*? OS (\d+(?:_\d+)?)`)},
+	{"Android", regexp.MustCompile(`Android (\d+(?:\.\d+)?)`)},
+	{"Windows", regexp.MustCompile(`Windows NT (\d+\.\d+)`)},
+	{"ChromeOS", regexp.MustCompile(`CrOS`)},
+	{"macOS", regexp.MustCompile(`Mac OS X (\d+(?:[_.]\d+)?)`)},
+	{"Linux", regexp.MustCompile(`Linux`)},
+}
+
+// windowsVersions traduit les versions du noyau Windows NT en noms commerciaux.
+var windowsVersions = map[string]string{
+	"10.0": "10",
+	"6.3":  "8.1",
+	"6.2":  "8",
+	"6.1":  "7",
+	"6.0":  "Vista",
+	"5.1":  "XP",
+}
+
+var (
+	tabletPattern = regexp.MustCompile(`(?i)iPad|Tablet|PlayBook|Kindle|Silk`)
+	mobilePattern = regexp.MustCompile(`(?i)Mobi|iPhone|iPod|Android|Windows Phone`)
+)
+
+// Parse analyse une chaîne User-Agent à l'aide des règles intégrées, sans aucun service externe.
+// Les champs qui ne peuvent pas être déterminés valent Unknown.
+func Parse(userAgent string) Info {
+	userAgent = strings.TrimSpace(userAgent)
+	info := Info{BrowserFamily: Unknown, OS: Unknown, DeviceType: Unknown}
+	if userAgent == "" {
+		return info
+	}
+
+	if name, version, ok := match(botRules, userAgent); ok {
+		info.BrowserFamily, info.BrowserVersion = name, version
+		info.DeviceType = DeviceBot
+	} else if name, version, ok := match(browserRules, userAgent); ok {
+		info.BrowserFamily, info.BrowserVersion = name, version
+	}
+
+	if name, version, ok := match(osRules, userAgent); ok {
+		info.OS = formatOS(name, version)
+	}
+
+	if info.DeviceType != DeviceBot {
+		info.DeviceType = deviceType(userAgent)
+	}
+	return info
+}
+
+// match retourne le nom et la version de la première règle qui correspond.
+func match(rules []rule, userAgent string) (string, string, bool) {
+	for _, r := range rules {
+		groups := r.pattern.FindStringSubmatch(userAgent)
+		if groups == nil {
+			continue
+		}
+		version := ""
+		if len(groups) > 1 {
+			version = groups[1]
+		}
+		return r.name, version, true
+	}
+	return "", "", false
+}
+
+// formatOS construit le libellé d'un système d'exploitation à partir de son nom et de sa version.
+func formatOS(name, version string) string {
+	switch name {
+	case "Windows":
+		if commercial, ok := windowsVersions[version]; ok {
+			version = commercial
+		}
+	case "iOS", "macOS":
+		version = strings.ReplaceAll(version, "_", ".")
+	}
+	if version == "" {
+		return name
+	}
+	return name + " " + version
+}
+
+// deviceType détermine la classe d'appareil d'un navigateur (hors robots).
+// Les tablettes Android n'annoncent pas "Mobile", contrairement aux téléphones.
+func deviceType(userAgent string) string {
+	if tabletPattern.MatchString(userAgent) {
+		return DeviceTablet
+	}
+	if strings.Contains(userAgent, "Android") && !strings.Contains(userAgent, "Mobile") {
+		return DeviceTablet
+	}
+	if mobilePattern.MatchString(userAgent) {
+		return DeviceMobile
+	}
+	return DeviceDesktop
+}
@@ -0,0 +1,120 @@
+package useragent
+
+import "testing"
+
+func TestParseDetectsBots(t *testing.T) {
+	tests := []struct {
+		name      string
+		userAgent string
+		family    string
+	}{
+		{"Googlebot", "Mozilla/5.0 (compatible; Googlebot/2.1; +http://www.google.com/bot.html)", "Googlebot"},
+		{"Bingbot", "Mozilla/5.0 AppleWebKit/537.36 (KHTML, like Gecko; compatible; bingbot/2.0; +http://www.bing.com/bingbot.htm) Chrome/116.0.1938.76 Safari/537.36", "Bingbot"},
+		{"Slackbot", "Slackbot-LinkExpanding 1.0 (+https://api.slack.com/robots)", "Slackbot"},
+		{"curl", "curl/8.5.0", "curl"},
+		{"AhrefsBot", "Mozilla/5.0 (compatible; AhrefsBot/7.0; +http://ahrefs.com/robot/)", "Bot"},
+		{"YandexBot", "Mozilla/5.0 (compatible; YandexBot/3.0; +http://yandex.com/bots)", "Bot"},
+		{"PetalBot", "Mozilla/5.0 (Linux; Android 7.0;) AppleWebKit/537.36 (KHTML, like Gecko) Mobile Safari/537.36 (compatible; PetalBot;+https://webmaster.petalsearch.com/site/petalbot)", "Bot"},
+		{"Baiduspider", "Mozilla/5.0 (compatible; Baiduspider/2.0; +http://www.baidu.com/search/spider.html)", "Bot"},
+		{"Applebot", "Mozilla/5.0 (Macintosh; Intel Mac OS X 10_15_5) AppleWebKit/605.1.15 (KHTML, like Gecko) Version/13.1.1 Safari/605.1.15 (Applebot/0.1; +http://www.apple.com/go/applebot)", "Bot"},
+		{"DuckDuckBot", "DuckDuckBot/1.1; (+http://duckduckgo.com/duckduckbot.html)", "Bot"},
+		{"Yahoo Slurp", "Mozilla/5.0 (compatible; Yahoo! Slurp; http://help.yahoo.com/help/us/ysearch/slurp)", "Bot"},
+		{"HeadlessChrome", "Mozilla/5.0 (X11; Linux x86_64) AppleWebKit/537.36 (KHTML, like Gecko) HeadlessChrome/120.0.6099.109 Safari/537.36", "Bot"},
+		{"UptimeRobot", "Mozilla/5.0+(compatible; UptimeRobot/2.0; http://www.uptimerobot.com/)", "Bot"},
+		{"Pingdom", "Pingdom.com_bot_version_1.4_(http://www.pingdom.com/)", "Bot"},
+		{"Better Uptime", "Better Uptime Bot Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/95.0.4638.54 Safari/537.36", "Bot"},
+		{"Google Web Preview", "Mozilla/5.0 (Windows NT 6.1; rv:6.0) Gecko/20110814 Firefox/6.0 Google Web Preview", "Bot"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			info := Parse(tt.userAgent)
+			if info.DeviceType != DeviceBot || info.BrowserFamily != tt.family {
+				t.Errorf("Parse(%q) = %s/%s, attendu %s/%s", tt.userAgent, info.BrowserFamily, info.DeviceType, tt.family, DeviceBot)
+			}
+		})
+	}
+}
+
+func TestParseDoesNotFlagBrowsers(t *testing.T) {
+	tests := []struct {
+		name      string
+		userAgent string
+		family    string
+		device    string
+	}{
+		{"CUBOT X30", "Mozilla/5.0 (Linux; Android 10; CUBOT X30) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/96.0.4664.104 Mobile Safari/537.36", "Chrome", DeviceMobile},
+		{"CUBOT KingKong", "Mozilla/5.0 (Linux; Android 11; KINGKONG 5 Pro Build/RP1A.200720.011; CUBOT) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/110.0.5481.153 Mobile Safari/537.36", "Chrome", DeviceMobile},
+		{"CUBOT_NOTE_20", "Mozilla/5.0 (Linux; Android 10; CUBOT_NOTE_20) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/119.0.6045.163 Mobile Safari/537.36", "Chrome", DeviceMobile},
+		{"Samsung Smart Monitor", "Mozilla/5.0 (SMART-TV; LINUX; Tizen 6.5) AppleWebKit/537.36 (KHTML, like Gecko) SamsungBrowser/4.0 Chrome/85.0.4183.93 Smart Monitor Safari/537.36", "Samsung Internet", DeviceDesktop},
+		{"Firefox avec un jeton Preview", "Mozilla/5.0 (Windows NT 10.0; Win64; x64; rv:126.0) Gecko/20100101 Firefox/126.0 Preview", "Firefox", DeviceDesktop},
+		{"Chrome sur Windows", "Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/124.0.0.0 Safari/537.36", "Chrome", DeviceDesktop},
+		{"Safari sur iPhone", "Mozilla/5.0 (iPhone; CPU iPhone OS 17_4 like Mac OS X) AppleWebKit/605.1.15 (KHTML, like Gecko) Version/17.4 Mobile/15E148 Safari/604.1", "Safari", DeviceMobile},
+		{"Samsung Internet sur tablette", "Mozilla/5.0 (Linux; Android 13; SM-X710) AppleWebKit/537.36 (KHTML, like Gecko) SamsungBrowser/24.0 Chrome/117.0.0.0 Safari/537.36 Tablet", "Samsung Internet", DeviceTablet},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			info := Parse(tt.userAgent)
+			if info.BrowserFamily != tt.family || info.DeviceType != tt.device {
+				t.Errorf("Parse(%q) = %s/%s, attendu %s/%s", tt.userAgent, info.BrowserFamily, info.DeviceType, tt.family, tt.device)
+			}
+		})
+	}
+}
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		name      string
+		userAgent string
+		want      Info
+	}{
+		{"Chrome sur Windows 10", "Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/124.0.6367.91 Safari/537.36",
+			Info{"Chrome", "124.0", "Windows 10", DeviceDesktop}},
+		{"Firefox sur Linux", "Mozilla/5.0 (X11; Linux x86_64; rv:125.0) Gecko/20100101 Firefox/125.0",
+			Info{"Firefox", "125.0", "Linux", DeviceDesktop}},
+		{"Safari sur macOS", "Mozilla/5.0 (Macintosh; Intel Mac OS X 10_15_7) AppleWebKit/605.1.15 (KHTML, like Gecko) Version/17.4.1 Safari/605.1.15",
+			Info{"Safari", "17.4", "macOS 10.15", DeviceDesktop}},
+		{"Edge sur Windows", "Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/124.0.0.0 Safari/537.36 Edg/124.0.2478.67",
+			Info{"Edge", "124.0", "Windows 10", DeviceDesktop}},
+		{"Opera sur macOS", "Mozilla/5.0 (Macintosh; Intel Mac OS X 10_15_7) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/123.0.0.0 Safari/537.36 OPR/109.0.0.0",
+			Info{"Opera", "109.0", "macOS 10.15", DeviceDesktop}},
+		{"Internet Explorer 11 sur Windows 7", "Mozilla/5.0 (Windows NT 6.1; WOW64; Trident/7.0; rv:11.0) like Gecko",
+			Info{"Internet Explorer", "11.0", "Windows 7", DeviceDesktop}},
+		{"Internet Explorer 8 sur Windows XP", "Mozilla/4.0 (compatible; MSIE 8.0; Windows NT 5.1; Trident/4.0)",
+			Info{"Internet Explorer", "8.0", "Windows XP", DeviceDesktop}},
+		{"Version de Windows inconnue", "Mozilla/5.0 (Windows NT 11.0; Win64; x64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/124.0.0.0 Safari/537.36",
+			Info{"Chrome", "124.0", "Windows 11.0", DeviceDesktop}},
+		{"Chrome sur ChromeOS", "Mozilla/5.0 (X11; CrOS x86_64 14541.0.0) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/124.0.0.0 Safari/537.36",
+			Info{"Chrome", "124.0", "ChromeOS", DeviceDesktop}},
+		{"Chrome sur Android", "Mozilla/5.0 (Linux; Android 14; Pixel 8) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/124.0.6367.82 Mobile Safari/537.36",
+			Info{"Chrome", "124.0", "Android 14", DeviceMobile}},
+		{"Samsung Internet sur Android", "Mozilla/5.0 (Linux; Android 13; SM-S918B) AppleWebKit/537.36 (KHTML, like Gecko) SamsungBrowser/24.0 Chrome/117.0.0.0 Mobile Safari/537.36",
+			Info{"Samsung Internet", "24.0", "Android 13", DeviceMobile}},
+		{"Tablette Android sans Mobile", "Mozilla/5.0 (Linux; Android 12; SM-T870) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/124.0.0.0 Safari/537.36",
+			Info{"Chrome", "124.0", "Android 12", DeviceTablet}},
+		{"Safari sur iPhone", "Mozilla/5.0 (iPhone; CPU iPhone OS 17_4_1 like Mac OS X) AppleWebKit/605.1.15 (KHTML, like Gecko) Version/17.4 Mobile/15E148 Safari/604.1",
+			Info{"Safari", "17.4", "iOS 17.4", DeviceMobile}},
+		{"Safari sur iPad", "Mozilla/5.0 (iPad; CPU OS 16_6 like Mac OS X) AppleWebKit/605.1.15 (KHTML, like Gecko) Version/16.6 Mobile/15E148 Safari/604.1",
+			Info{"Safari", "16.6", "iOS 16.6", DeviceTablet}},
+		{"Chrome sur iPhone", "Mozilla/5.0 (iPhone; CPU iPhone OS 17_4 like Mac OS X) AppleWebKit/605.1.15 (KHTML, like Gecko) CriOS/124.0.6367.88 Mobile/15E148 Safari/604.1",
+			Info{"Chrome", "124.0", "iOS 17.4", DeviceMobile}},
+		{"Firefox sur iPhone", "Mozilla/5.0 (iPhone; CPU iPhone OS 17_4 like Mac OS X) AppleWebKit/605.1.15 (KHTML, like Gecko) FxiOS/125.0 Mobile/15E148 Safari/605.1.15",
+			Info{"Firefox", "125.0", "iOS 17.4", DeviceMobile}},
+		{"Kindle", "Mozilla/5.0 (Linux; U; Android 4.0.3; en-us; KFTT Build/IML74K) AppleWebKit/537.36 (KHTML, like Gecko) Silk/3.68 like Chrome/39.0.2171.93 Safari/537.36",
+			Info{"Chrome", "39.0", "Android 4.0", DeviceTablet}},
+		{"Googlebot smartphone", "Mozilla/5.0 (Linux; Android 6.0.1; Nexus 5X Build/MMB29P) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/124.0.6367.91 Mobile Safari/537.36 (compatible; Googlebot/2.1; +http://www.google.com/bot.html)",
+			Info{"Googlebot", "2.1", "Android 6.0", DeviceBot}},
+		{"TelegramBot sans version", "TelegramBot (like TwitterBot)",
+			Info{"TelegramBot", "", Unknown, DeviceBot}},
+		{"Chaîne inconnue", "MonClient",
+			Info{Unknown, "", Unknown, DeviceDesktop}},
+		{"Chaîne vide", "   ",
+			Info{Unknown, "", Unknown, Unknown}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Parse(tt.userAgent); got != tt.want {
+				t.Errorf("Parse(%q) = %+v, attendu %+v", tt.userAgent, got, tt.want)
+			}
+		})
+	}
+}
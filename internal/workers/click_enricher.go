@@ -0,0 +1,108 @@
+package workers
+
+import (
+	"log"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/axellelanca/urlshortener/internal/anonymize"
+	"github.com/axellelanca/urlshortener/internal/botdetect"
+	"github.com/axellelanca/urlshortener/internal/fingerprint"
+	"github.com/axellelanca/urlshortener/internal/geoip"
+	"github.com/axellelanca/urlshortener/internal/models"
+	"github.com/axellelanca/urlshortener/internal/referrer"
+	"github.com/axellelanca/urlshortener/internal/useragent"
+)
+
+// Tailles des colonnes de models.Click alimentées à partir de données fournies par le client.
+// Les valeurs plus longues sont tronquées : PostgreSQL et MySQL rejetteraient le clic, et l'événement,
+// jamais acquitté, serait rejoué indéfiniment depuis le spool.
+const (
+	maxUserAgentLength      = 255
+	maxBrowserLength        = 50
+	maxBrowserVersionLength = 30
+	maxOSLength             = 50
+	maxReferrerLength       = 255
+	maxLocationLength       = 100
+)
+
+// ClickEnricher transforme un événement de clic brut en clic prêt à être persisté,
+// en y ajoutant les informations dérivées (User-Agent analysé, détection des robots, empreinte du visiteur, domaine référent,
+// géolocalisation), puis en anonymisant l'adresse IP.
+// Il est partagé par tous les workers : ses dépendances doivent supporter les accès concurrents.
+type ClickEnricher struct {
+	botDetector *botdetect.Detector
+	hasher      *fingerprint.Hasher
+	geoResolver *geoip.Resolver
+	anonymizer  *anonymize.Anonymizer
+}
+
+// NewClickEnricher crée et retourne un nouveau ClickEnricher.
+// geoResolver peut être nil : les clics sont alors enregistrés sans géolocalisation.
+func NewClickEnricher(botDetector *botdetect.Detector, hasher *fingerprint.Hasher, geoResolver *geoip.Resolver, anonymizer *anonymize.Anonymizer) *ClickEnricher {
+	return &ClickEnricher{
+		botDetector: botDetector,
+		hasher:      hasher,
+		geoResolver: geoResolver,
+		anonymizer:  anonymizer,
+	}
+}
+
+// Enrich construit le models.Click correspondant à un événement.
+func (e *ClickEnricher) Enrich(event *models.ClickEvent) models.Click {
+	// Analyse du User-Agent avec les règles intégrées (aucun appel externe)
+	agent := useragent.Parse(event.UserAgent)
+
+	// Empreinte du visiteur ; en cas d'échec le clic est tout de même enregistré, sans empreinte
+	visitorID, err := e.hasher.Fingerprint(event.IPAddress, event.UserAgent, event.Timestamp)
+	if err != nil {
+		log.Printf("ERROR: Failed to fingerprint visitor for LinkID %d: %v", event.LinkID, err)
+	}
+
+	// Géolocalisation hors ligne ; une erreur de lecture de la base n'empêche pas l'enregistrement du clic
+	location, err := e.geoResolver.Lookup(event.IPAddress)
+	if err != nil {
+		log.Printf("ERROR: Failed to geolocate click for LinkID %d: %v", event.LinkID, err)
+	}
+
+	// Anonymisation en dernier : les étapes précédentes ont besoin de l'adresse complète.
+	// En cas d'échec, l'adresse n'est pas enregistrée plutôt que d'être conservée en clair.
+	ipAddress, err := e.anonymizer.Anonymize(event.IPAddress, event.Timestamp)
+	if err != nil {
+		log.Printf("ERROR: Failed to anonymize IP address for LinkID %d: %v", event.LinkID, err)
+		ipAddress = ""
+	}
+
+	var eventID *string
+	if event.EventID != "" {
+		eventID = &event.EventID
+	}
+
+	return models.Click{
+		EventID:        eventID,
+		LinkID:         event.LinkID,
+		UserAgent:      truncate(event.UserAgent, maxUserAgentLength),
+		IPAddress:      ipAddress,
+		Timestamp:      event.Timestamp,
+		Browser:        truncate(agent.BrowserFamily, maxBrowserLength),
+		BrowserVersion: truncate(agent.BrowserVersion, maxBrowserVersionLength),
+		OS:             truncate(agent.OS, maxOSLength),
+		DeviceType:     agent.DeviceType,
+		IsBot:          e.botDetector.IsBot(event.UserAgent, agent, event.IPAddress),
+		VisitorID:      visitorID,
+		Referrer:       truncate(referrer.Normalize(event.Referrer), maxReferrerLength),
+		Country:        location.Country,
+		Region:         truncate(location.Region, maxLocationLength),
+		City:           truncate(location.City, maxLocationLength),
+	}
+}
+
+// truncate ramène s à au plus max caractères, sans couper un caractère multi-octets.
+// Les séquences UTF-8 invalides et les octets nuls, refusés par PostgreSQL, sont remplacés ou retirés.
+func truncate(s string, max int) string {
+	s = strings.ReplaceAll(strings.ToValidUTF8(s, "\uFFFD"), "\x00", "")
+	if utf8.RuneCountInString(s) <= max {
+		return s
+	}
+	return string([]rune(s)[:max])
+}
@@ -0,0 +1,89 @@
+package workers
+
+import (
+	"strings"
+	"testing"
+	"time"
+	"unicode/utf8"
+
+	"github.com/axellelanca/urlshortener/internal/anonymize"
+	"github.com/axellelanca/urlshortener/internal/botdetect"
+	"github.com/axellelanca/urlshortener/internal/fingerprint"
+	"github.com/axellelanca/urlshortener/internal/models"
+)
+
+// memorySaltStore est un fingerprint.SaltStore en mémoire pour les tests.
+type memorySaltStore map[string]string
+
+func (m memorySaltStore) GetOrCreateSalt(day, candidate string) (string, error) {
+	if salt, ok := m[day]; ok {
+		return salt, nil
+	}
+	m[day] = candidate
+	return candidate, nil
+}
+
+func (m memorySaltStore) DeleteSaltsBefore(string) error { return nil }
+
+func newTestEnricher(t *testing.T) *ClickEnricher {
+	t.Helper()
+	detector, err := botdetect.NewDetector(true, nil, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	hasher := fingerprint.NewHasher(memorySaltStore{})
+	anonymizer, err := anonymize.NewAnonymizer(anonymize.ModeTruncate, hasher)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return NewClickEnricher(detector, hasher, nil, anonymizer)
+}
+
+func TestEnrichTruncatesOversizedValues(t *testing.T) {
+	enricher := newTestEnricher(t)
+	userAgent := "Mozilla/5.0 (X11; Linux x86_64) Firefox/" + strings.Repeat("9", 400) + " " + strings.Repeat("é", 300)
+
+	click := enricher.Enrich(&models.ClickEvent{
+		LinkID:    1,
+		Timestamp: time.Now(),
+		UserAgent: userAgent,
+		IPAddress: "203.0.113.7",
+		Referrer:  "https://" + strings.Repeat("a", 60) + ".example.com/",
+	})
+
+	if n := utf8.RuneCountInString(click.UserAgent); n != maxUserAgentLength {
+		t.Errorf("UserAgent de %d caractères, attendu %d", n, maxUserAgentLength)
+	}
+	if !utf8.ValidString(click.UserAgent) {
+		t.Error("UserAgent tronqué au milieu d'un caractère multi-octets")
+	}
+	if n := utf8.RuneCountInString(click.BrowserVersion); n > maxBrowserVersionLength {
+		t.Errorf("BrowserVersion de %d caractères, attendu au plus %d", n, maxBrowserVersionLength)
+	}
+	if click.VisitorID == "" {
+		t.Error("l'empreinte doit être calculée sur le User-Agent complet")
+	}
+}
+
+func TestTruncate(t *testing.T) {
+	tests := []struct {
+		name string
+		in   string
+		max  int
+		want string
+	}{
+		{"court", "curl/8.0", 255, "curl/8.0"},
+		{"exact", "abc", 3, "abc"},
+		{"trop long", "abcdef", 3, "abc"},
+		{"multi-octets", "ééé", 2, "éé"},
+		{"UTF-8 invalide", "a\xffb", 10, "a�b"},
+		{"octet nul", "a\x00b", 10, "ab"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := truncate(tt.in, tt.max); got != tt.want {
+				t.Errorf("truncate(%q, %d) = %q, attendu %q", tt.in, tt.max, got, tt.want)
+			}
+		})
+	}
+}
@@ -2,40 +2,189 @@ package workers
 
 import (
 	"log"
+	"sync"
+	"sync/atomic"
+	"time"
 
+	"github.com/axellelanca/urlshortener/internal/metrics"
 	"github.com/axellelanca/urlshortener/internal/models"
 	"github.com/axellelanca/urlshortener/internal/repository" // Nécessaire pour interagir avec le ClickRepository
 )
 
+// Valeurs appliquées lorsque la configuration des lots est absente ou invalide.
+const (
+	defaultBatchSize     = 100
+	defaultFlushInterval = 500 * time.Millisecond
+)
+
+// BatchConfig règle le regroupement des clics avant leur persistance.
+// Un lot est écrit dès qu'il atteint Size clics, ou au plus tard après FlushInterval.
+type BatchConfig struct {
+	Size          int
+	FlushInterval time.Duration
+}
+
+// Acknowledger est notifié des événements dont le clic a été persisté (ou écarté comme doublon).
+// Il est implémenté par spool.Spool, qui supprime ainsi les segments entièrement traités.
+type Acknowledger interface {
+	Ack(events []*models.ClickEvent)
+}
+
+// WorkerPool représente les workers lancés par StartClickWorkers.
+// Il permet d'attendre leur fin lors de l'arrêt du serveur et de savoir combien d'événements restent à traiter.
+type WorkerPool struct {
+	events    <-chan *models.ClickEvent
+	acker     Acknowledger // Optionnel
+	wg        sync.WaitGroup
+	pending   atomic.Int64 // Clics lus dans le channel mais pas encore écrits en base
+	size      int
+	alive     atomic.Int32
+	heartbeat atomic.Int64 // Dernière activité d'un worker (UnixNano)
+	interval  time.Duration
+}
+
+// Backlog retourne le nombre d'événements non encore persistés : ceux en attente dans le channel
+// et ceux déjà lus par les workers, dans un lot pas encore écrit.
+func (p *WorkerPool) Backlog() int {
+	return len(p.events) + int(p.pending.Load())
+}
+
+// Size retourne le nombre de workers lancés.
+func (p *WorkerPool) Size() int {
+	return p.size
+}
+
+// Alive retourne le nombre de workers encore en cours d'exécution.
+func (p *WorkerPool) Alive() int {
+	return int(p.alive.Load())
+}
+
+// LastHeartbeat retourne la date de la dernière activité d'un worker. Chaque worker se manifeste
+// au moins une fois par intervalle de flush : une date ancienne signale des workers bloqués,
+// par exemple sur une écriture en base qui ne se termine pas.
+func (p *WorkerPool) LastHeartbeat() time.Time {
+	return time.Unix(0, p.heartbeat.Load())
+}
+
+// FlushInterval retourne l'intervalle de flush des lots, qui est aussi celui des battements des workers.
+func (p *WorkerPool) FlushInterval() time.Duration {
+	return p.interval
+}
+
+// Wait attend que tous les workers se terminent, au plus jusqu'à timeout.
+// Les workers ne s'arrêtent qu'une fois le channel fermé et vidé. Wait retourne false si le délai a expiré.
+func (p *WorkerPool) Wait(timeout time.Duration) bool {
+	done := make(chan struct{})
+	go func() {
+		p.wg.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		return true
+	case <-time.After(timeout):
+		return false
+	}
+}
+
 // StartClickWorkers lance un pool de goroutines "workers" pour traiter les événements de clic.
-// Chaque worker lira depuis le même 'clickEventsChan' et utilisera le 'clickRepo' pour la persistance.
-func StartClickWorkers(workerCount int, clickEventsChan <-chan *models.ClickEvent, clickRepo repository.ClickRepository) {
-	log.Printf("Starting %d click worker(s)...", workerCount)
+// Chaque worker lira depuis le même 'clickEventsChan', enrichira l'événement avec 'enricher'
+// et utilisera le 'clickRepo' pour la persistance, par lots réglés par 'batch'. Si 'acker' n'est pas nil,
+// il reçoit les événements persistés. Les workers s'arrêtent après avoir écrit les derniers clics,
+// lorsque le channel est fermé.
+func StartClickWorkers(workerCount int, clickEventsChan <-chan *models.ClickEvent, clickRepo repository.ClickRepository, enricher *ClickEnricher, batch BatchConfig, acker Acknowledger) *WorkerPool {
+	if batch.Size <= 0 {
+		batch.Size = defaultBatchSize
+	}
+	if batch.FlushInterval <= 0 {
+		batch.FlushInterval = defaultFlushInterval
+	}
+
+	pool := &WorkerPool{events: clickEventsChan, acker: acker, size: workerCount, interval: batch.FlushInterval}
+	pool.heartbeat.Store(time.Now().UnixNano())
+	log.Printf("Starting %d click worker(s) (batch size %d, flush interval %v)...", workerCount, batch.Size, batch.FlushInterval)
 	for i := 0; i < workerCount; i++ {
 		// Lance chaque worker dans sa propre goroutine.
 		// Le channel est passé en lecture seule (<-chan) pour renforcer l'immutabilité du channel à l'intérieur du worker.
-		go clickWorker(clickEventsChan, clickRepo)
+		pool.wg.Add(1)
+		pool.alive.Add(1)
+		go func() {
+			defer pool.wg.Done()
+			defer pool.alive.Add(-1)
+			pool.clickWorker(clickRepo, enricher, batch)
+		}()
 	}
+	return pool
 }
 
 // clickWorker est la fonction exécutée par chaque goroutine worker.
-// Elle tourne indéfiniment, lisant les événements de clic dès qu'ils sont disponibles dans le channel.
-func clickWorker(clickEventsChan <-chan *models.ClickEvent, clickRepo repository.ClickRepository) {
-	for event := range clickEventsChan {
-		click := models.Click{
-			LinkID:    event.LinkID,
-			UserAgent: event.UserAgent,
-			IPAddress: event.IPAddress,
-			Timestamp: event.Timestamp,
+// Elle accumule les clics enrichis et les écrit par lots, lorsque le lot est plein ou que
+// l'intervalle de flush est écoulé. À la fermeture du channel, le lot en cours est écrit avant de quitter.
+func (p *WorkerPool) clickWorker(clickRepo repository.ClickRepository, enricher *ClickEnricher, batch BatchConfig) {
+	pending := make([]models.Click, 0, batch.Size)
+	events := make([]*models.ClickEvent, 0, batch.Size) // Événements d'origine de pending, dans le même ordre
+	ticker := time.NewTicker(batch.FlushInterval)
+	defer ticker.Stop()
+
+	flush := func() {
+		persisted := flushClicks(clickRepo, pending, events)
+		if p.acker != nil && len(persisted) > 0 {
+			p.acker.Ack(persisted)
+		}
+		p.pending.Add(-int64(len(pending)))
+		pending = pending[:0]
+		events = events[:0]
+	}
+
+	for {
+		p.heartbeat.Store(time.Now().UnixNano())
+		select {
+		case event, ok := <-p.events:
+			if !ok {
+				flush()
+				return
+			}
+			p.pending.Add(1)
+			pending = append(pending, enricher.Enrich(event))
+			events = append(events, event)
+			if len(pending) >= batch.Size {
+				flush()
+			}
+		case <-ticker.C:
+			if len(pending) > 0 {
+				flush()
+			}
 		}
+	}
+}
 
-		// TODO 2: Persister le clic en base de données via le 'clickRepo' (CreateClick).
-		if err := clickRepo.CreateClick(&click); err != nil {
+// flushClicks persiste un lot de clics via le 'clickRepo' (CreateClicks) et retourne les événements
+// dont le clic a été enregistré. Si l'insertion du lot échoue, les clics sont réinsérés un par un
+// pour ne perdre que ceux qui sont en erreur.
+func flushClicks(clickRepo repository.ClickRepository, clicks []models.Click, events []*models.ClickEvent) []*models.ClickEvent {
+	if len(clicks) == 0 {
+		return nil
+	}
+	err := clickRepo.CreateClicks(clicks)
+	if err == nil {
+		log.Printf("%d click(s) recorded successfully", len(clicks))
+		metrics.ClicksPersistedTotal.Add(float64(len(clicks)))
+		return events
+	}
+	log.Printf("ERROR: Failed to save batch of %d click(s), retrying one by one: %v", len(clicks), err)
 
-			log.Printf("ERROR: Failed to save click for LinkID %d: %v", event.LinkID, err)
-		} else {
-			// Log optionnel pour confirmer l'enregistrement (utile pour le débogage)
-			log.Printf("Click recorded successfully for LinkID %d", event.LinkID)
+	persisted := make([]*models.ClickEvent, 0, len(events))
+	for i := range clicks {
+		// La transaction du lot a été annulée : les ID éventuellement attribués ne sont plus valides
+		clicks[i].ID = 0
+		if err := clickRepo.CreateClick(&clicks[i]); err != nil {
+			log.Printf("ERROR: Failed to save click for LinkID %d: %v", clicks[i].LinkID, err)
+			metrics.ClickPersistErrorsTotal.Inc()
+			continue
 		}
+		persisted = append(persisted, events[i])
 	}
+	metrics.ClicksPersistedTotal.Add(float64(len(persisted)))
+	return persisted
 }
@@ -0,0 +1,168 @@
+package workers
+
+import (
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/axellelanca/urlshortener/internal/models"
+	"github.com/axellelanca/urlshortener/internal/repository"
+)
+
+// failingClickRepository refuse les insertions par lot, ainsi que les clics unitaires des liens de reject.
+// Les autres méthodes de repository.ClickRepository ne sont pas utilisées par les workers.
+type failingClickRepository struct {
+	repository.ClickRepository
+	reject map[uint]bool
+
+	mu    sync.Mutex
+	saved []models.Click
+}
+
+func (r *failingClickRepository) CreateClicks([]models.Click) error {
+	return errors.New("lot refusé")
+}
+
+func (r *failingClickRepository) CreateClick(click *models.Click) error {
+	if r.reject[click.LinkID] {
+		return errors.New("clic refusé")
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.saved = append(r.saved, *click)
+	return nil
+}
+
+// recordingAcker conserve les événements acquittés.
+type recordingAcker struct {
+	mu    sync.Mutex
+	acked []*models.ClickEvent
+}
+
+func (a *recordingAcker) Ack(events []*models.ClickEvent) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.acked = append(a.acked, events...)
+}
+
+func TestFlushClicksFallsBackToOneByOne(t *testing.T) {
+	repo := &failingClickRepository{reject: map[uint]bool{2: true}}
+	events := []*models.ClickEvent{{EventID: "a", LinkID: 1}, {EventID: "b", LinkID: 2}, {EventID: "c", LinkID: 3}}
+	clicks := []models.Click{{ID: 10, LinkID: 1}, {ID: 11, LinkID: 2}, {ID: 12, LinkID: 3}}
+
+	persisted := flushClicks(repo, clicks, events)
+
+	if len(persisted) != 2 || persisted[0] != events[0] || persisted[1] != events[2] {
+		t.Fatalf("événements persistés %v, attendu a et c", eventIDs(persisted))
+	}
+	if len(repo.saved) != 2 {
+		t.Fatalf("%d clic(s) enregistré(s), attendu 2", len(repo.saved))
+	}
+	for _, click := range repo.saved {
+		if click.ID != 0 {
+			t.Errorf("clic du lien %d réinséré avec l'ID %d du lot annulé", click.LinkID, click.ID)
+		}
+	}
+}
+
+func TestWorkerPoolAcksOnlyPersistedEvents(t *testing.T) {
+	repo := &failingClickRepository{reject: map[uint]bool{2: true}}
+	acker := &recordingAcker{}
+	events := make(chan *models.ClickEvent, 3)
+	events <- &models.ClickEvent{EventID: "a", LinkID: 1, Timestamp: time.Now()}
+	events <- &models.ClickEvent{EventID: "b", LinkID: 2, Timestamp: time.Now()}
+	events <- &models.ClickEvent{EventID: "c", LinkID: 3, Timestamp: time.Now()}
+	close(events)
+
+	pool := StartClickWorkers(1, events, repo, newTestEnricher(t), BatchConfig{Size: 10, FlushInterval: time.Hour}, acker)
+	if !pool.Wait(5 * time.Second) {
+		t.Fatal("les workers ne se sont pas arrêtés")
+	}
+
+	if got := eventIDs(acker.acked); len(got) != 2 || got[0] != "a" || got[1] != "c" {
+		t.Fatalf("événements acquittés %v, attendu [a c] : l'événement en erreur doit rester dans le spool", got)
+	}
+	if pool.Backlog() != 0 {
+		t.Fatalf("backlog %d après l'arrêt, attendu 0", pool.Backlog())
+	}
+}
+
+// blockingClickRepository enregistre les lots, mais bloque chaque écriture jusqu'à la fermeture de release.
+type blockingClickRepository struct {
+	repository.ClickRepository
+	release chan struct{}
+
+	mu    sync.Mutex
+	saved int
+}
+
+func (r *blockingClickRepository) CreateClicks(clicks []models.Click) error {
+	<-r.release
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.saved += len(clicks)
+	return nil
+}
+
+func (r *blockingClickRepository) count() int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.saved
+}
+
+func TestWorkerPoolWritesPendingEventsOnClose(t *testing.T) {
+	repo := &blockingClickRepository{release: make(chan struct{})}
+	close(repo.release)
+	events := make(chan *models.ClickEvent, 10)
+	for _, id := range []string{"a", "b", "c", "d", "e"} {
+		events <- &models.ClickEvent{EventID: id, LinkID: 1, Timestamp: time.Now()}
+	}
+
+	// Lot plus grand que le nombre d'événements et flush lointain : seule la fermeture déclenche l'écriture
+	pool := StartClickWorkers(2, events, repo, newTestEnricher(t), BatchConfig{Size: 100, FlushInterval: time.Hour}, nil)
+	close(events)
+	if !pool.Wait(5 * time.Second) {
+		t.Fatal("les workers ne se sont pas arrêtés")
+	}
+	if repo.count() != 5 {
+		t.Fatalf("%d clic(s) écrit(s) à l'arrêt, attendu 5", repo.count())
+	}
+	if pool.Backlog() != 0 || pool.Alive() != 0 {
+		t.Fatalf("backlog %d et %d worker(s) en vie après l'arrêt, attendu 0 et 0", pool.Backlog(), pool.Alive())
+	}
+}
+
+func TestWorkerPoolWaitTimesOut(t *testing.T) {
+	repo := &blockingClickRepository{release: make(chan struct{})}
+	events := make(chan *models.ClickEvent, 10)
+	for _, id := range []string{"a", "b", "c"} {
+		events <- &models.ClickEvent{EventID: id, LinkID: 1, Timestamp: time.Now()}
+	}
+
+	// Un lot d'un clic : le worker bloque sur la première écriture, les deux autres restent dans le channel
+	pool := StartClickWorkers(1, events, repo, newTestEnricher(t), BatchConfig{Size: 1, FlushInterval: time.Hour}, nil)
+	close(events)
+	if pool.Wait(50 * time.Millisecond) {
+		t.Fatal("Wait a réussi alors que l'écriture en base est bloquée")
+	}
+	if pool.Backlog() != 3 {
+		t.Fatalf("backlog %d au délai d'arrêt, attendu 3", pool.Backlog())
+	}
+
+	close(repo.release)
+	if !pool.Wait(5 * time.Second) {
+		t.Fatal("les workers ne se sont pas arrêtés une fois la base débloquée")
+	}
+	if repo.count() != 3 {
+		t.Fatalf("%d clic(s) écrit(s), attendu 3", repo.count())
+	}
+}
+
+func eventIDs(events []*models.ClickEvent) []string {
+	ids := make([]string, len(events))
+	for i, event := range events {
+		ids[i] = event.EventID
+	}
+	return ids
+}